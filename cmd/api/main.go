@@ -12,6 +12,8 @@ import (
 	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
 	"github.com/ASHISH26940/manim-orchestrator-api/pkg/handlers"
 	"github.com/ASHISH26940/manim-orchestrator-api/pkg/middleware" // <--- Import middleware package
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/services"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/storage"
 	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils" 
 	"github.com/gin-gonic/gin"
 	cors "github.com/gin-contrib/cors"
@@ -22,48 +24,134 @@ func main(){
 	log.SetOutput(gin.DefaultWriter)
 	log.SetLevel(log.InfoLevel)
 	log.SetFormatter(&log.JSONFormatter{})
+	log.AddHook(middleware.RequestIDHook{}) // request_id on entries logged with log.WithContext
 	log.Info("Starting Manim Orchestrator API...")
 
 	cfg:=config.LoadConfig()
+	cfg.LogSafe()
 
 	if err:=db.InitDB(cfg.DatabaseURL); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 	defer db.CloseDB()
+	if err := db.InitFieldEncryption(cfg.DataEncryptionKey, cfg.EncryptSensitiveFields); err != nil {
+		log.Fatalf("Invalid DATA_ENCRYPTION_KEY: %v", err)
+	}
 
-	llmClient, err := llm.NewGeminiService(cfg.GeminiAPIKey)
+	llmClient, err := llm.NewGeminiService(cfg.GeminiAPIKey, cfg.GeminiModel, llm.Options{
+		EmptyRetry:            cfg.GeminiEmptyRetry,
+		PromptGuard:           cfg.PromptInjectionGuard,
+		TruncationRetryTokens: int32(cfg.GeminiTruncationRetryTokens),
+		BreakerThreshold:      cfg.LLMBreakerThreshold,
+		BreakerCooldown:       time.Duration(cfg.LLMBreakerCooldownSeconds) * time.Second,
+		RetryAttempts:         cfg.GeminiRetryAttempts,
+		RetryBaseDelay:        time.Duration(cfg.GeminiRetryBaseDelayMs) * time.Millisecond,
+	})
 	if err != nil {
 		log.Fatalf("Failed to initialize LLM client: %v", err)
 	}
 	defer llmClient.Close()
 	
-	apiHandlers := handlers.NewHandlers(cfg, llmClient)
+	// Direct bucket access is optional; it's only needed when the API writes or signs objects itself.
+	var storageClient *storage.Client
+	if cfg.StorageConfigured() {
+		storageClient, err = storage.NewClient(storage.Config{
+			Endpoint:        cfg.R2Endpoint,
+			Bucket:          cfg.R2Bucket,
+			AccessKeyID:     cfg.R2AccessKeyID,
+			SecretAccessKey: cfg.R2SecretAccessKey,
+			PublicBaseURL:   cfg.R2PublicBaseURL,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize storage client: %v", err)
+		}
+	}
+
+	// The renderer's Manim version, fetched in the background and optionally given to Gemini
+	var rendererInfo *services.RendererInfoCache
+	if cfg.RendererEnabled {
+		var onUpdate func(services.RendererInfo)
+		if cfg.RendererVersionHint {
+			onUpdate = func(info services.RendererInfo) { llmClient.SetManimVersion(info.ManimVersion) }
+		}
+		rendererInfo = services.NewRendererInfoCache(cfg.ManimRendererURL, onUpdate)
+	}
+
+	// A wrong MANIM_RENDERER_URL would otherwise only show up when the first render fails.
+	// An unreachable renderer is reported, not fatal: it may simply be starting up too.
+	var rendererHealth *services.RendererHealth
+	if cfg.RendererEnabled {
+		rendererHealth = services.NewRendererHealth(cfg.ManimRendererURL, time.Duration(cfg.RendererProbeTimeoutMs)*time.Millisecond)
+		if cfg.RendererStartupProbe {
+			if err := rendererHealth.Check(context.Background()); err != nil {
+				log.Warnf("!!! Manim renderer at %s is UNREACHABLE: %v. Renders will fail until it is reachable; check MANIM_RENDERER_URL.", cfg.ManimRendererURL, err)
+			} else {
+				log.Infof("Manim renderer at %s is reachable.", cfg.ManimRendererURL)
+			}
+		}
+	}
+
+	apiHandlers := handlers.NewHandlers(cfg, llmClient, storageClient, rendererInfo, rendererHealth)
 
-	router:=gin.Default()
+	utils.UseJSONFieldNames() // Validation errors name fields as clients send them
+	router:=gin.New()
+	router.Use(middleware.RequestID()) // X-Request-ID for correlating logs with Gemini and renderer calls
+	router.Use(gin.Logger(), middleware.Recovery()) // JSON 500s with the request and user logged, instead of gin's plain recovery
+
+	// Only honor X-Forwarded-For from our own load balancers (TRUSTED_PROXIES); with none
+	// configured, c.ClientIP() is the connecting address and can't be spoofed with a header.
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	// --- CORS CONFIGURATION ---
 	// Configure CORS middleware
 	router.Use(cors.New(cors.Config{
-		// 🚨 DANGER: Allows ALL origins. Use with EXTREME CAUTION in production.
-		AllowOrigins: []string{"*"},
+		// CORS_ALLOWED_ORIGINS, e.g. "http://localhost:3000,https://manime-frontend-gen.vercel.app".
+		// 🚨 Unset allows ALL origins ("*"); restrict it in production.
+		AllowOrigins: cfg.AllowedOrigins,
 		// If you allow all origins, AllowCredentials MUST be false unless you
 		// specifically handle authenticated requests without cookies.
 		// For JWTs in Authorization header, this can often be false.
 		AllowCredentials: false, // Set to false when AllowOrigins is "*"
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-API-Key", middleware.RequestIDHeader},
+		ExposeHeaders:    []string{middleware.RequestIDHeader},
 		MaxAge:           12 * time.Hour,
 	}))
 	
 
-	router.GET("/health",handlers.HealthCheck)
-	router.POST("/api/projects/render-callback", apiHandlers.HandleRenderCallback) // <--- CRITICAL: Callback route
-	router.POST("/api/merge_videos",apiHandlers.MergeVideosHandler)
+	// Compress large JSON/code responses for clients that accept it (SSE streams are excluded).
+	router.Use(middleware.Compression(cfg.CompressionMinBytes, cfg.CompressionContentTypes))
+
+	// Opt-in request/response body logging for debugging client integrations (secrets redacted)
+	if cfg.DebugLogBodies {
+		log.Warn("DEBUG_LOG_BODIES is enabled; request and response bodies will be logged.")
+		router.Use(middleware.BodyLogging(cfg.DebugLogBodyMaxBytes))
+	}
+
+	// Explicit preflight handling for every path, including the unauthenticated
+	// callback and merge routes registered outside the protected group.
+	router.OPTIONS("/*path", handlers.Preflight)
+
+	router.GET("/health", apiHandlers.Readiness) // Dependency status; 503 when one is down, for load balancers
+	router.GET("/health/live", handlers.HealthCheck) // Process is up, no dependency checks
+	router.GET("/health/ready", apiHandlers.Readiness)
+	router.GET("/readyz", apiHandlers.Readiness) // 503 when the database is down or the Gemini breaker is open
+	router.POST("/api/projects/render-callback", apiHandlers.RequireRenderCallbackSignature, apiHandlers.StoreInlineRenderVideo, middleware.Transaction(), apiHandlers.HandleRenderCallback) // <--- CRITICAL: Callback route, signature checked and inline video uploaded before the transaction opens
+	router.POST("/api/projects/thumbnail-callback", apiHandlers.HandleThumbnailCallback)
+	requireRenderer := middleware.RequireRenderer(cfg) // 501 in generation-only mode (RENDERER_ENABLED=false)
+	renderLimit := middleware.RateLimit(apiHandlers.RenderLimiter) // One limit shared by every route that makes paid Gemini calls
+	router.POST("/api/merge_videos", requireRenderer, apiHandlers.MergeVideosHandler)
+	router.POST("/api/merge-callback", apiHandlers.HandleMergeCallback) // Renderer reports the outcome of a merge
 
 	authRoutes:=router.Group("/auth")
 	{
-		authRoutes.POST("/register",handlers.RegisterUser)
+		authRoutes.POST("/register", middleware.Transaction(), apiHandlers.RegisterUser)
 		authRoutes.POST("/login", handlers.LoginUser)
+		authRoutes.POST("/refresh", handlers.RefreshAccessToken)
+		authRoutes.POST("/logout", middleware.AuthMiddleware(), handlers.LogoutUser) // Revokes the presented access token
+		authRoutes.POST("/introspect", middleware.RequireServiceToken(cfg.IntrospectionToken), handlers.IntrospectToken) // Token validation for sibling services
 		
 	}
 
@@ -85,20 +173,95 @@ func main(){
 				"username": claims.Username,
 			})
 		})
-		protectedRoutes.POST("/delete",handlers.DeleteUser)
+		protectedRoutes.PATCH("/profile", middleware.Transaction(), apiHandlers.UpdateProfile) // Change own username/email
+		protectedRoutes.POST("/change-password", middleware.Transaction(), handlers.ChangePassword)
+		protectedRoutes.POST("/delete", middleware.Transaction(), handlers.DeleteUser)
+		// Full data export (GDPR-style), rate limited per user as it's an expensive query
+		exportLimiter := middleware.NewRateLimiter(cfg.ExportRateLimitPerHour, time.Hour)
+		protectedRoutes.GET("/me/export", middleware.RateLimit(exportLimiter), handlers.ExportUserData)
+		protectedRoutes.POST("/prompts/assess", renderLimit, apiHandlers.AssessPrompt) // Pre-flight prompt quality check (GEMINI_PROMPT_QC)
+		protectedRoutes.GET("/merged-videos", apiHandlers.ListMergedVideos) // Caller's merged videos, newest first
+		protectedRoutes.GET("/merged-videos/:id", apiHandlers.GetMergedVideo)
+		protectedRoutes.GET("/merged-videos/:id/sources", apiHandlers.GetMergedVideoSources)
+		protectedRoutes.POST("/merge/:jobId/retry", requireRenderer, apiHandlers.RetryMerge) // Resubmit a failed merge with its stored inputs
+		protectedRoutes.GET("/stats/timeline", handlers.GetRenderTimeline) // Render counts bucketed by hour/day/week
+		protectedRoutes.GET("/render-queue", apiHandlers.GetRenderQueue) // Approximate queue depth and wait
+		protectedRoutes.GET("/enums", handlers.GetEnums) // Canonical statuses, qualities, formats and roles
+		protectedRoutes.GET("/examples", handlers.GetExamples) // Curated prompts for a prompt gallery
+		protectedRoutes.GET("/renderer-info", requireRenderer, apiHandlers.GetRendererInfo) // Manim version the renderer runs
+		// Long-lived API keys for programmatic access (sent as X-API-Key)
+		protectedRoutes.POST("/keys", handlers.CreateAPIKey)
+		protectedRoutes.GET("/keys", handlers.ListAPIKeys)
+		protectedRoutes.DELETE("/keys/:id", handlers.RevokeAPIKey)
+		// Named render settings applied via {"preset": "<name>"} on generate-render
+		protectedRoutes.POST("/presets", handlers.CreateRenderPreset)
+		protectedRoutes.GET("/presets", handlers.ListRenderPresets)
+		protectedRoutes.PUT("/presets/:id", handlers.UpdateRenderPreset)
+		protectedRoutes.DELETE("/presets/:id", handlers.DeleteRenderPreset)
 		// Other protected routes will go here in future iterations
 		// protectedRoutes.POST("/projects", handlers.CreateProject)
 
 		projectsRoutes := protectedRoutes.Group("/projects")
 		{
-			projectsRoutes.POST("", handlers.CreateManimProject)                // POST /api/projects
-			projectsRoutes.GET("", handlers.GetUserManimProjects)               // GET /api/projects
+			projectsRoutes.POST("", middleware.Transaction(), apiHandlers.CreateManimProject)                // POST /api/projects
+			projectsRoutes.GET("", apiHandlers.GetUserManimProjects)               // GET /api/projects
+			projectsRoutes.GET("/export.csv", apiHandlers.ExportProjectsCSV)     // GET /api/projects/export.csv
+			projectsRoutes.POST("/batch-create", apiHandlers.BatchCreateManimProjects) // POST /api/projects/batch-create
+			projectsRoutes.POST("/batch-get", apiHandlers.BatchGetManimProjects) // POST /api/projects/batch-get
+			projectsRoutes.GET("/shared-with-me", apiHandlers.GetProjectsSharedWithMe) // Projects other users transferred to the caller
+			projectsRoutes.POST("/from-example/:id", handlers.CreateProjectFromExample) // New project from one of GET /api/examples
+			projectsRoutes.POST("/re-render-failed", requireRenderer, apiHandlers.ReRenderFailedProjects) // POST /api/projects/re-render-failed
 			projectsRoutes.GET("/:id", handlers.GetManimProjectByID)            // GET /api/projects/:id
-			projectsRoutes.PUT("/:id", handlers.UpdateManimProject)             // PUT /api/projects/:id
-			projectsRoutes.DELETE("/:id", handlers.DeleteManimProject)          // DELETE /api/projects/:id
+			projectsRoutes.GET("/:id/full", handlers.GetManimProjectFull)       // Project + latest render job + sub-projects
+			projectsRoutes.GET("/:id/status", handlers.GetProjectStatus)        // Render status and video URL only, for polling
+			projectsRoutes.GET("/:id/children", apiHandlers.GetProjectChildren) // Scenes of a decomposed project and style variants
+			projectsRoutes.PUT("/:id", middleware.Transaction(), handlers.UpdateManimProject)             // PUT /api/projects/:id
+			projectsRoutes.DELETE("/:id", middleware.Transaction(), handlers.DeleteManimProject)          // DELETE /api/projects/:id
+			projectsRoutes.POST("/:id/restore", middleware.Transaction(), apiHandlers.RestoreManimProject) // Undo a delete within the retention period
 			// --- NEW: Trigger Generation and Render Endpoint ---
-			projectsRoutes.POST("/:id/generate-render", apiHandlers.TriggerManimGenerationAndRender)
+			// Rate limited per user since every call is a paid Gemini request
+			projectsRoutes.POST("/:id/generate-render", requireRenderer, renderLimit, apiHandlers.TriggerManimGenerationAndRender)
+			projectsRoutes.POST("/:id/retry", requireRenderer, apiHandlers.RetryRender) // Re-send a failed render's stored code
+			projectsRoutes.POST("/:id/generate-code", renderLimit, apiHandlers.GenerateProjectCode) // Manim code only, no render
+			projectsRoutes.GET("/:id/generate-stream", renderLimit, apiHandlers.GenerateProjectCodeStream) // generate-code as Server-Sent Events
+			projectsRoutes.POST("/:id/variant", requireRenderer, renderLimit, apiHandlers.CreateProjectVariant) // Render a style variation as a child project
+			projectsRoutes.GET("/:id/effective-prompt", apiHandlers.GetEffectivePrompt) // Exact prompt that would be sent to Gemini
+			projectsRoutes.POST("/:id/render-code", requireRenderer, apiHandlers.RenderProjectCode) // Render user-supplied Manim code, skipping the LLM
+			projectsRoutes.POST("/:id/regenerate-thumbnail", requireRenderer, apiHandlers.RegenerateThumbnail)
+			projectsRoutes.POST("/:id/pin-render", handlers.PinRender) // Exempt a long render from the stuck-render reconciler
+			projectsRoutes.POST("/:id/lock", handlers.LockProject)     // Make the project read-only
+			projectsRoutes.POST("/:id/unlock", handlers.UnlockProject)
+			projectsRoutes.PATCH("/:id/notes", handlers.UpdateProjectNotes) // Internal/team notes
+			projectsRoutes.POST("/:id/transfer", middleware.Transaction(), handlers.TransferProject) // Hand the project to another user
+			projectsRoutes.GET("/:id/renders/:jobId/usage", apiHandlers.GetRenderUsage) // Gemini tokens and estimated cost of a render
+			projectsRoutes.GET("/:id/eta", apiHandlers.GetRenderETA) // Estimated render time from render history and queue depth
+			projectsRoutes.GET("/:id/merges", apiHandlers.GetProjectMerges) // Merged videos that include this project
+			projectsRoutes.GET("/:id/download", apiHandlers.GetProjectDownloadURL) // Short-lived presigned URL for private buckets
+			projectsRoutes.HEAD("/:id/download", apiHandlers.HeadProjectDownload) // Video size and type, no body
+			projectsRoutes.POST("/:id/assets", apiHandlers.UploadProjectAsset) // Images/SVGs passed to the renderer with each render
 		}
+
+		// Support tooling, restricted to ADMIN_USER_IDS
+		adminRoutes := protectedRoutes.Group("/admin")
+		adminRoutes.Use(middleware.RequireAdmin(cfg))
+		{
+			adminRoutes.GET("/projects", handlers.ListAllProjects) // GET /api/admin/projects
+			adminRoutes.GET("/users", handlers.ListUsers)         // GET /api/admin/users
+			adminRoutes.GET("/users/:id", handlers.GetUser)       // User with project/render counts
+			adminRoutes.GET("/llm-health", apiHandlers.GetLLMHealth) // Gemini circuit breaker state
+		}
+	}
+
+	// Fail renders whose callback never arrived (STUCK_RENDER_TIMEOUT_SECONDS, off by default)
+	reconcilerCtx, stopReconciler := context.WithCancel(context.Background())
+	defer stopReconciler()
+	if cfg.StuckRenderTimeoutSeconds > 0 {
+		services.StartRenderReconciler(reconcilerCtx, time.Duration(cfg.StuckRenderTimeoutSeconds)*time.Second)
+	}
+	services.StartRevokedTokenSweeper(reconcilerCtx) // Drop blocklist entries of tokens that have expired anyway
+	services.StartDeletedProjectPurger(reconcilerCtx, cfg, storageClient) // Remove deleted projects and their files once they can't be restored
+	if rendererInfo != nil {
+		rendererInfo.Start(reconcilerCtx, time.Duration(cfg.RendererInfoRefreshSeconds)*time.Second)
 	}
 
 	srv:=&http.Server{
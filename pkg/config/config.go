@@ -1,7 +1,12 @@
 package config
 
 import(
+	"net/url"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	log "github.com/sirupsen/logrus"
 )
@@ -11,8 +16,79 @@ type Config struct{
 	Host string
 	Port string
 	JwtSecret string
+	JwtKeyID string // Key ID ("kid") of JwtSecret, stamped on newly issued tokens
+	JwtVerificationKeys map[string]string // kid -> secret for every key still accepted when validating tokens
 	GeminiAPIKey string
+	GeminiModel string // Default Gemini model for code generation; projects and render presets can override it
 	ManimRendererURL   string
+	RendererEnabled bool // False deploys the service as a generation-only (LLM-to-code) API
+	ExportRateLimitPerHour int
+	RenderRateLimitPerHour int // Gemini generations (generate-render, generate-code/stream, variants, prompt assessment, one per bulk-queued render) allowed per user per hour
+	RenderConcurrency int // Maximum number of background renders dispatched at once
+	RenderCooldownSeconds int // Minimum time between renders of the same project (0, the default, disables)
+	StuckRenderTimeoutSeconds int // Renders in flight longer than this are failed by the reconciler (0 disables)
+	AdminUserIDs []uuid.UUID // Users with administrative privileges, by ID (ADMIN_USER_IDS)
+	AllowedEmailDomains []string // If set, only these email domains may register (lowercased)
+	R2Endpoint string // S3-compatible endpoint, e.g. https://<account>.r2.cloudflarestorage.com
+	R2Bucket string
+	R2AccessKeyID string
+	R2SecretAccessKey string
+	R2PublicBaseURL string // Public URL prefix for objects uploaded by the API
+	RendererReturnsInline bool // Accept base64 video bytes in render callbacks and upload them ourselves
+	InlineVideoMaxBytes int // Largest decoded inline video accepted from the renderer
+	DownloadURLTTLSeconds int // Lifetime of presigned download URLs
+	CompressionMinBytes int // Responses smaller than this are sent uncompressed
+	CompressionContentTypes []string // Media types eligible for gzip/deflate compression
+	MaxScriptBytes int // Largest user-submitted Manim script accepted by render-code
+	ScriptDenylist []*regexp.Regexp // Scripts matching any of these are rejected by render-code
+	R2InternalDomain string // Origin (scheme://host) the renderer writes video URLs with
+	R2PublicDomain string // Origin clients should load videos from instead of R2InternalDomain
+	GeminiPromptQC bool // Enables POST /api/prompts/assess
+	GeminiEmptyRetry bool // Retry code generation once when Gemini returns an empty response
+	GeminiTruncationRetryTokens int // Output token limit for one retry of truncated code generation (0 fails the render instead)
+	GeminiRetryAttempts int // Calls made for code generation when Gemini keeps failing with 429/5xx or timeouts (1 disables retries)
+	GeminiRetryBaseDelayMs int // Wait before the first retry of a failed Gemini call; doubled for each further one
+	PromptInjectionGuard bool // Sanitize prompts against instruction overrides and validate the generated scene class
+	GeminiInputCostPerMillion float64 // USD per million prompt tokens, for render cost estimates
+	GeminiOutputCostPerMillion float64 // USD per million response tokens
+	DebugLogBodies bool // Log (redacted) request/response bodies of non-auth routes
+	DebugLogBodyMaxBytes int // Bodies are truncated to this many bytes in the log
+	AutoNameProjects bool // Name projects created without one from their prompt
+	DefaultProjectDescription string // Description given to projects created without one
+	IntrospectionToken string // Service credential required by POST /auth/introspect (open when empty)
+	LLMBreakerThreshold int // Consecutive Gemini failures before generation fails fast with 503 (0 disables)
+	LLMBreakerCooldownSeconds int // How long generation fails fast before Gemini is tried again
+	MaxMergeInputs int // Most videos accepted in one merge (0 disables the limit)
+	MaxMergeDurationSeconds int // Longest merge accepted when clip trims make its length known (0 disables)
+	AutoDecomposeOnFailure bool // Split prompts that fail as too complex into child projects, one per scene
+	AssetMaxBytes int // Largest asset file accepted by POST /api/projects/:id/assets
+	CallbackSigningSecret string // Signs renderer callback URLs; required when the renderer is enabled, as unsigned callbacks are rejected
+	CallbackTTLSeconds int // How long a signed render callback URL stays valid
+	TrustedProxies []string // IPs/CIDRs of proxies whose X-Forwarded-For is honored; none by default
+	EncryptSensitiveFields bool // Encrypt project prompts at rest with DataEncryptionKey
+	DataEncryptionKey string // Base64-encoded 32-byte AES key for encrypted fields; kept to read them after encryption is turned off
+	AllowedOrigins []string // CORS origins allowed to call the API; every origin ("*") when unset
+	RendererTriggerRetries int // Extra attempts when starting a render gets a 5xx or no response (e.g. renderer restarting)
+	RendererTriggerBackoffMs int // Wait before the first retry of a render request, doubled for each further retry
+	RendererInfoRefreshSeconds int // How often the renderer's Manim version is re-fetched
+	RendererVersionHint bool // Tell Gemini which Manim version the renderer runs
+	RendererStartupProbe bool // GET the renderer's /health at boot and warn if it's unreachable
+	RendererProbeTimeoutMs int // Timeout of a renderer health probe
+	ReadinessCheckRenderer bool // Probe the renderer on every readiness check and report not ready when it's unreachable
+	DeletedProjectRetentionDays int // Deleted projects can be restored for this long, then they're purged
+}
+
+// defaultScriptDenylist blocks the obvious ways a submitted script could run commands, open
+// network connections or touch files outside the renderer's working directory.
+var defaultScriptDenylist = []string{
+	`\bos\.(system|popen|exec[lv]p?e?|spawn[lv]p?e?|remove|unlink|rmdir|removedirs|chmod|chown|kill)\b`,
+	`\bsubprocess\b`,
+	`\bshutil\.(rmtree|move|copy\w*)\b`,
+	`\bsocket\b`,
+	`\b(eval|exec|compile)\s*\(`,
+	`__import__`,
+	`\bopen\s*\(\s*[rbfu]*['"](/|~|\.\.)`,
+	`\bctypes\b`,
 }
 
 func LoadConfig() *Config{
@@ -25,8 +101,63 @@ func LoadConfig() *Config{
 		Host: os.Getenv("HOST"),
 		Port: os.Getenv("PORT"),
 		JwtSecret: os.Getenv("JWT_SECRET"),
+		JwtKeyID: os.Getenv("JWT_KEY_ID"),
 		GeminiAPIKey: os.Getenv("GEMINI_API_KEY"),
+		GeminiModel: strings.TrimSpace(os.Getenv("GEMINI_MODEL")),
 		ManimRendererURL: os.Getenv("MANIM_RENDERER_URL"),
+		ExportRateLimitPerHour: getEnvInt("EXPORT_RATE_LIMIT_PER_HOUR", 3),
+		RenderRateLimitPerHour: getEnvInt("RENDER_RATE_LIMIT_PER_HOUR", 10),
+		RenderConcurrency: getEnvInt("RENDER_CONCURRENCY", 4),
+		RenderCooldownSeconds: getEnvInt("RENDER_COOLDOWN_SECONDS", 0),
+		StuckRenderTimeoutSeconds: getEnvInt("STUCK_RENDER_TIMEOUT_SECONDS", 0),
+		AllowedEmailDomains: getEnvList("ALLOWED_EMAIL_DOMAINS", true),
+		R2Endpoint: os.Getenv("R2_ENDPOINT"),
+		R2Bucket: os.Getenv("R2_BUCKET"),
+		R2AccessKeyID: os.Getenv("R2_ACCESS_KEY_ID"),
+		R2SecretAccessKey: os.Getenv("R2_SECRET_ACCESS_KEY"),
+		R2PublicBaseURL: os.Getenv("R2_PUBLIC_BASE_URL"),
+		RendererReturnsInline: getEnvBool("RENDERER_RETURNS_INLINE", false),
+		InlineVideoMaxBytes: getEnvInt("INLINE_VIDEO_MAX_BYTES", 100*1024*1024),
+		DownloadURLTTLSeconds: getEnvInt("DOWNLOAD_URL_TTL_SECONDS", 300),
+		CompressionMinBytes: getEnvInt("COMPRESSION_MIN_BYTES", 1024),
+		CompressionContentTypes: getEnvList("COMPRESSION_CONTENT_TYPES", true),
+		MaxScriptBytes: getEnvInt("MAX_SCRIPT_BYTES", 100*1024),
+		R2InternalDomain: strings.TrimSuffix(os.Getenv("PYTHON_R2_INTERNAL_DOMAIN"), "/"),
+		R2PublicDomain: strings.TrimSuffix(os.Getenv("FRONTEND_R2_PUBLIC_DOMAIN"), "/"),
+		GeminiPromptQC: getEnvBool("GEMINI_PROMPT_QC", false),
+		GeminiEmptyRetry: getEnvBool("GEMINI_EMPTY_RETRY", false),
+		GeminiTruncationRetryTokens: getEnvInt("GEMINI_TRUNCATION_RETRY_MAX_TOKENS", 0),
+		GeminiRetryAttempts: getEnvInt("GEMINI_RETRY_ATTEMPTS", 3),
+		GeminiRetryBaseDelayMs: getEnvInt("GEMINI_RETRY_BASE_DELAY_MS", 1000),
+		PromptInjectionGuard: getEnvBool("PROMPT_INJECTION_GUARD", true),
+		GeminiInputCostPerMillion: getEnvFloat("GEMINI_INPUT_COST_PER_MILLION", 0.075), // gemini-1.5-flash list price
+		GeminiOutputCostPerMillion: getEnvFloat("GEMINI_OUTPUT_COST_PER_MILLION", 0.30),
+		RendererEnabled: getEnvBool("RENDERER_ENABLED", true),
+		DebugLogBodies: getEnvBool("DEBUG_LOG_BODIES", false),
+		DebugLogBodyMaxBytes: getEnvInt("DEBUG_LOG_BODY_MAX_BYTES", 4096),
+		AutoNameProjects: getEnvBool("AUTO_NAME_PROJECTS", false),
+		DefaultProjectDescription: os.Getenv("DEFAULT_PROJECT_DESCRIPTION"),
+		IntrospectionToken: os.Getenv("INTROSPECTION_TOKEN"),
+		LLMBreakerThreshold: getEnvInt("LLM_BREAKER_THRESHOLD", 5),
+		LLMBreakerCooldownSeconds: getEnvInt("LLM_BREAKER_COOLDOWN_SECONDS", 30),
+		MaxMergeInputs: getEnvInt("MAX_MERGE_INPUTS", 20),
+		MaxMergeDurationSeconds: getEnvInt("MAX_MERGE_DURATION_SECONDS", 0),
+		AutoDecomposeOnFailure: getEnvBool("AUTO_DECOMPOSE_ON_FAILURE", false),
+		AssetMaxBytes: getEnvInt("ASSET_MAX_BYTES", 5*1024*1024),
+		CallbackSigningSecret: os.Getenv("CALLBACK_SIGNING_SECRET"),
+		CallbackTTLSeconds: getEnvInt("CALLBACK_TTL_SECONDS", 6*60*60),
+		TrustedProxies: getEnvList("TRUSTED_PROXIES", false),
+		EncryptSensitiveFields: getEnvBool("ENCRYPT_SENSITIVE_FIELDS", false),
+		DataEncryptionKey: os.Getenv("DATA_ENCRYPTION_KEY"),
+		AllowedOrigins: getEnvList("CORS_ALLOWED_ORIGINS", false),
+		RendererTriggerRetries: getEnvInt("RENDERER_TRIGGER_RETRIES", 2),
+		RendererTriggerBackoffMs: getEnvInt("RENDERER_TRIGGER_BACKOFF_MS", 500),
+		RendererInfoRefreshSeconds: getEnvInt("RENDERER_INFO_REFRESH_SECONDS", 60*60),
+		RendererVersionHint: getEnvBool("RENDERER_VERSION_HINT", false),
+		RendererStartupProbe: getEnvBool("RENDERER_STARTUP_PROBE", true),
+		RendererProbeTimeoutMs: getEnvInt("RENDERER_PROBE_TIMEOUT_MS", 3000),
+		ReadinessCheckRenderer: getEnvBool("READINESS_CHECK_RENDERER", false),
+		DeletedProjectRetentionDays: getEnvInt("DELETED_PROJECT_RETENTION_DAYS", 30),
 	}
 
 	if cfg.Host == "" {
@@ -38,15 +169,206 @@ func LoadConfig() *Config{
 	if cfg.JwtSecret == "" {
 		log.Fatal("JWT_SECRET environment variable is not set. This is critical for authentication.")
 	}
+	if cfg.JwtKeyID == "" {
+		cfg.JwtKeyID = "default"
+	}
+	// JWT_PREVIOUS_KEYS lists retired secrets as comma-separated "kid:secret" pairs.
+	// Tokens signed with them keep validating until they expire, allowing zero-downtime rotation.
+	cfg.JwtVerificationKeys = parseKeyPairs(os.Getenv("JWT_PREVIOUS_KEYS"))
+	cfg.JwtVerificationKeys[cfg.JwtKeyID] = cfg.JwtSecret
 	if cfg.DatabaseURL == "" {
 		log.Fatal("DATABASE_URL is not set")
 	}
 	if cfg.GeminiAPIKey == "" {
 		log.Fatal("GEMINI_API_KEY is not set")
 	}
-	if cfg.ManimRendererURL == ""{
-		log.Fatal("MANIM RENDERER is empty")
+	if cfg.GeminiModel == "" {
+		cfg.GeminiModel = "gemini-1.5-flash"
+	}
+	if len(cfg.CompressionContentTypes) == 0 {
+		cfg.CompressionContentTypes = []string{"application/json", "text/x-python", "text/plain", "text/csv"}
+	}
+	// Admins are identified by user ID: emails are self-editable, unverified and stay in tokens until they expire
+	for _, entry := range getEnvList("ADMIN_USER_IDS", false) {
+		id, err := uuid.Parse(entry)
+		if err != nil {
+			log.Fatalf("Invalid ADMIN_USER_IDS entry %q: %v", entry, err)
+		}
+		cfg.AdminUserIDs = append(cfg.AdminUserIDs, id)
+	}
+	if os.Getenv("ADMIN_EMAILS") != "" {
+		log.Warn("ADMIN_EMAILS is no longer supported and is ignored; list administrators by user ID in ADMIN_USER_IDS.")
+	}
+	// SCRIPT_DENYLIST is a comma-separated list of regular expressions (so patterns can't contain commas).
+	denylist := getEnvList("SCRIPT_DENYLIST", false)
+	if len(denylist) == 0 {
+		denylist = defaultScriptDenylist
+	}
+	for _, pattern := range denylist {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			log.Fatalf("Invalid SCRIPT_DENYLIST pattern %q: %v", pattern, err)
+		}
+		cfg.ScriptDenylist = append(cfg.ScriptDenylist, re)
+	}
+	if len(cfg.AllowedOrigins) == 0 {
+		cfg.AllowedOrigins = []string{"*"}
+	}
+	if cfg.RendererInfoRefreshSeconds < 1 {
+		cfg.RendererInfoRefreshSeconds = 60 * 60
+	}
+	if cfg.DeletedProjectRetentionDays < 1 {
+		cfg.DeletedProjectRetentionDays = 30
+	}
+	if cfg.RendererProbeTimeoutMs < 1 {
+		cfg.RendererProbeTimeoutMs = 3000
+	}
+	if cfg.RenderConcurrency < 1 {
+		cfg.RenderConcurrency = 1
+	}
+	if cfg.RendererEnabled && cfg.ManimRendererURL == ""{
+		log.Fatal("MANIM RENDERER is empty (set RENDERER_ENABLED=false to run without a renderer)")
+	}
+	if cfg.RendererEnabled && cfg.CallbackSigningSecret == "" {
+		log.Fatal("CALLBACK_SIGNING_SECRET is not set. It's required to authenticate renderer callbacks (set RENDERER_ENABLED=false to run without a renderer)")
+	}
+	if cfg.RendererReturnsInline && !cfg.StorageConfigured() {
+		log.Fatal("RENDERER_RETURNS_INLINE requires R2_ENDPOINT, R2_BUCKET, R2_ACCESS_KEY_ID and R2_SECRET_ACCESS_KEY")
+	}
+	if cfg.RendererReturnsInline && cfg.R2PublicBaseURL == "" {
+		log.Fatal("RENDERER_RETURNS_INLINE requires R2_PUBLIC_BASE_URL to build video URLs")
+	}
+	if cfg.EncryptSensitiveFields && cfg.DataEncryptionKey == "" {
+		log.Fatal("ENCRYPT_SENSITIVE_FIELDS requires DATA_ENCRYPTION_KEY")
 	}
 
 	return cfg
-}
\ No newline at end of file
+}
+
+// StorageConfigured reports whether enough R2 settings are present for the API to talk to the bucket itself.
+func (c *Config) StorageConfigured() bool {
+	return c.R2Endpoint != "" && c.R2Bucket != "" && c.R2AccessKeyID != "" && c.R2SecretAccessKey != ""
+}
+
+// RewriteVideoURL maps a video URL on R2InternalDomain to the same path on R2PublicDomain.
+// URLs on any other origin, and all URLs when either domain isn't configured, are returned unchanged.
+func (c *Config) RewriteVideoURL(videoURL string) string {
+	if videoURL == "" || c.R2InternalDomain == "" || c.R2PublicDomain == "" {
+		return videoURL
+	}
+	parsedURL, err := url.Parse(videoURL)
+	if err != nil || !strings.EqualFold(parsedURL.Scheme+"://"+parsedURL.Host, c.R2InternalDomain) {
+		return videoURL
+	}
+	rewritten := c.R2PublicDomain + parsedURL.EscapedPath()
+	if parsedURL.RawQuery != "" {
+		rewritten += "?" + parsedURL.RawQuery
+	}
+	return rewritten
+}
+
+// IsAdmin reports whether the given user is a configured administrator.
+func (c *Config) IsAdmin(userID uuid.UUID) bool {
+	for _, admin := range c.AdminUserIDs {
+		if admin == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEmailDomainAllowed reports whether the email's domain may register.
+// All domains are allowed when AllowedEmailDomains is empty.
+func (c *Config) IsEmailDomainAllowed(email string) bool {
+	if len(c.AllowedEmailDomains) == 0 {
+		return true
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(strings.TrimSpace(email[at+1:]))
+	for _, allowed := range c.AllowedEmailDomains {
+		if domain == strings.TrimPrefix(allowed, "@") {
+			return true
+		}
+	}
+	return false
+}
+
+// getEnvList reads a comma-separated environment variable into a slice of trimmed, non-empty
+// entries, optionally lowercasing them.
+func getEnvList(key string, lower bool) []string {
+	var values []string
+	for _, entry := range strings.Split(os.Getenv(key), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if lower {
+			entry = strings.ToLower(entry)
+		}
+		values = append(values, entry)
+	}
+	return values
+}
+
+// getEnvInt reads an integer environment variable, falling back to def when it is unset or invalid.
+func getEnvInt(key string, def int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		log.Warnf("Invalid integer for %s (%q), using default %d", key, raw, def)
+		return def
+	}
+	return value
+}
+
+// getEnvFloat reads a float environment variable, falling back to def when it is unset or invalid.
+func getEnvFloat(key string, def float64) float64 {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		log.Warnf("Invalid number for %s (%q), using default %g", key, raw, def)
+		return def
+	}
+	return value
+}
+
+// getEnvBool reads a boolean environment variable, falling back to def when it is unset or invalid.
+func getEnvBool(key string, def bool) bool {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		log.Warnf("Invalid boolean for %s (%q), using default %t", key, raw, def)
+		return def
+	}
+	return value
+}
+
+// parseKeyPairs parses a comma-separated list of "id:secret" pairs into a map, skipping malformed entries.
+func parseKeyPairs(raw string) map[string]string {
+	keys := make(map[string]string)
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			log.Warn("Ignoring malformed key entry in key list (expected \"id:secret\")")
+			continue
+		}
+		keys[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return keys
+}
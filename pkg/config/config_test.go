@@ -0,0 +1,66 @@
+package config
+
+import "testing"
+
+func TestIsEmailDomainAllowed(t *testing.T) {
+	// Domains are lowercased when loaded from ALLOWED_EMAIL_DOMAINS
+	restricted := &Config{AllowedEmailDomains: []string{"example.com", "@corp.example.org"}}
+
+	tests := []struct {
+		name  string
+		cfg   *Config
+		email string
+		want  bool
+	}{
+		{"no allowlist", &Config{}, "someone@anywhere.net", true},
+		{"allowed domain", restricted, "alice@example.com", true},
+		{"allowed domain with leading @", restricted, "bob@corp.example.org", true},
+		{"uppercase domain", restricted, "Alice@EXAMPLE.COM", true},
+		{"mixed case domain", restricted, "alice@Example.Com", true},
+		{"trailing whitespace", restricted, "alice@example.com ", true},
+		{"other domain", restricted, "mallory@evil.com", false},
+		{"subdomain", restricted, "alice@mail.example.com", false},
+		{"lookalike suffix", restricted, "alice@notexample.com", false},
+		{"allowed domain as prefix", restricted, "alice@example.com.evil.com", false},
+		{"no @", restricted, "example.com", false},
+		{"empty", restricted, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.IsEmailDomainAllowed(tt.email); got != tt.want {
+				t.Errorf("IsEmailDomainAllowed(%q) = %v, want %v", tt.email, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRewriteVideoURL(t *testing.T) {
+	const internal = "https://bucket.r2.internal.example"
+	const public = "https://videos.example.com"
+	const videoURL = internal + "/renders/abc.mp4"
+
+	tests := []struct {
+		name     string
+		cfg      *Config
+		videoURL string
+		want     string
+	}{
+		{"unconfigured", &Config{}, videoURL, videoURL},
+		{"only internal domain", &Config{R2InternalDomain: internal}, videoURL, videoURL},
+		{"only public domain", &Config{R2PublicDomain: public}, videoURL, videoURL},
+		{"unconfigured empty URL", &Config{}, "", ""},
+		{"configured empty URL", &Config{R2InternalDomain: internal, R2PublicDomain: public}, "", ""},
+		{"internal URL", &Config{R2InternalDomain: internal, R2PublicDomain: public}, videoURL, public + "/renders/abc.mp4"},
+		{"internal URL with query", &Config{R2InternalDomain: internal, R2PublicDomain: public}, videoURL + "?v=2", public + "/renders/abc.mp4?v=2"},
+		{"internal URL in other case", &Config{R2InternalDomain: internal, R2PublicDomain: public}, "https://BUCKET.r2.internal.example/renders/abc.mp4", public + "/renders/abc.mp4"},
+		{"other origin", &Config{R2InternalDomain: internal, R2PublicDomain: public}, "https://cdn.other.example/abc.mp4", "https://cdn.other.example/abc.mp4"},
+		{"internal host over http", &Config{R2InternalDomain: internal, R2PublicDomain: public}, "http://bucket.r2.internal.example/abc.mp4", "http://bucket.r2.internal.example/abc.mp4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.RewriteVideoURL(tt.videoURL); got != tt.want {
+				t.Errorf("RewriteVideoURL(%q) = %q, want %q", tt.videoURL, got, tt.want)
+			}
+		})
+	}
+}
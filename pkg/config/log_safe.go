@@ -0,0 +1,101 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// secretFields are redacted by LogSafe.
+var secretFields = map[string]bool{
+	"JwtSecret":             true,
+	"GeminiAPIKey":          true,
+	"R2AccessKeyID":         true,
+	"R2SecretAccessKey":     true,
+	"IntrospectionToken":    true,
+	"CallbackSigningSecret": true,
+	"DataEncryptionKey":     true,
+}
+
+// LogSafe logs every effective configuration value at startup so deployments can confirm which
+// settings took effect. Secrets, previous JWT keys and the database password are redacted to
+// their last 4 characters.
+func (c *Config) LogSafe() {
+	fields := log.Fields{}
+	value := reflect.ValueOf(c).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		name := value.Type().Field(i).Name
+		field := value.Field(i).Interface()
+		switch {
+		case secretFields[name]:
+			fields[name] = redactSecret(value.Field(i).String())
+		case name == "JwtVerificationKeys":
+			keys := make(map[string]string, len(c.JwtVerificationKeys))
+			for kid, secret := range c.JwtVerificationKeys {
+				keys[kid] = redactSecret(secret)
+			}
+			fields[name] = keys
+		case name == "DatabaseURL":
+			fields[name] = redactURLPassword(c.DatabaseURL)
+		default:
+			fields[name] = field
+		}
+	}
+	log.WithFields(fields).Info("Effective configuration")
+}
+
+// redactSecret hides all but the last 4 characters of a secret. Short secrets are hidden entirely.
+func redactSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	if len(secret) <= 4 {
+		return "****"
+	}
+	return "****" + secret[len(secret)-4:]
+}
+
+// keyValuePasswordPattern matches the password setting of a libpq key=value connection string,
+// whose value is either single-quoted (with backslash escapes) or runs to the next whitespace.
+var keyValuePasswordPattern = regexp.MustCompile(`(?i)(\bpassword\s*=\s*)('(?:[^'\\]|\\.)*'|\S*)`)
+
+// redactURLPassword redacts the password of a database connection string, given either as a URL
+// or as libpq key=value settings. Unparseable URLs are redacted whole, since they may still embed
+// credentials.
+func redactURLPassword(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	if !strings.Contains(raw, "://") {
+		return redactKeyValuePassword(raw)
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return redactSecret(raw)
+	}
+	if password, ok := parsed.User.Password(); ok {
+		parsed.User = url.UserPassword(parsed.User.Username(), redactSecret(password))
+	}
+	// lib/pq also takes the password as a query parameter
+	if query := parsed.Query(); query.Has("password") {
+		query.Set("password", redactSecret(query.Get("password")))
+		parsed.RawQuery = query.Encode()
+	}
+	return parsed.String()
+}
+
+// redactKeyValuePassword redacts password settings in a key=value connection string.
+func redactKeyValuePassword(raw string) string {
+	return keyValuePasswordPattern.ReplaceAllStringFunc(raw, func(setting string) string {
+		match := keyValuePasswordPattern.FindStringSubmatch(setting)
+		key, value := match[1], match[2]
+		if len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
+			unquoted := strings.NewReplacer(`\'`, "'", `\\`, `\`).Replace(value[1 : len(value)-1])
+			return key + "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(redactSecret(unquoted)) + "'"
+		}
+		return key + redactSecret(value)
+	})
+}
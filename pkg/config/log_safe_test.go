@@ -0,0 +1,36 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactURLPassword(t *testing.T) {
+	tests := []struct {
+		name string
+		dsn  string
+		want string
+	}{
+		{"empty", "", ""},
+		{"URL with password", "postgres://app:s3cretpass@db:5432/manim?sslmode=disable", "postgres://app:%2A%2A%2A%2Apass@db:5432/manim?sslmode=disable"},
+		{"URL without password", "postgres://app@db:5432/manim", "postgres://app@db:5432/manim"},
+		{"URL with password parameter", "postgres://app@db/manim?password=s3cretpass", "postgres://app@db/manim?password=%2A%2A%2A%2Apass"},
+		{"key=value", "host=db user=app password=s3cretpass dbname=manim", "host=db user=app password=****pass dbname=manim"},
+		{"key=value with spaces around =", "host=db password = s3cretpass dbname=manim", "host=db password = ****pass dbname=manim"},
+		{"key=value quoted", `host=db password='s3cret pa\'ss' dbname=manim`, `host=db password='****a\'ss' dbname=manim`},
+		{"key=value uppercase key", "host=db PASSWORD=s3cretpass", "host=db PASSWORD=****pass"},
+		{"key=value short password", "host=db password=abc", "host=db password=****"},
+		{"key=value without password", "host=db user=app dbname=manim", "host=db user=app dbname=manim"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := redactURLPassword(tt.dsn)
+			if got != tt.want {
+				t.Errorf("redactURLPassword(%q) = %q, want %q", tt.dsn, got, tt.want)
+			}
+			if strings.Contains(got, "s3cret") {
+				t.Errorf("redactURLPassword(%q) = %q leaks the password", tt.dsn, got)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/migrations"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq" // PostgreSQL driver for database/sql
 	log "github.com/sirupsen/logrus"
@@ -51,6 +52,12 @@ func InitDB(dbURL string) error {
 	// And to close connections after a certain total lifetime:
 	// DB.SetConnMaxLifetime(5 * time.Minute)
 
+	// Bring the schema up to date, so a fresh database works without running migrations by hand.
+	if err = migrations.RunMigrations(DB); err != nil {
+		log.Errorf("Failed to run database migrations: %v", err)
+		DB.Close()
+		return err
+	}
 
 	log.Info("Database connection pool initialized successfully.")
 	return nil
@@ -0,0 +1,140 @@
+package db
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// encryptedFieldPrefix marks a column value written by EncryptField. Values without it are
+// plaintext, so rows written before encryption was enabled stay readable.
+const encryptedFieldPrefix = "enc:v1:"
+
+// ErrEncryptionKeyMissing is returned when an encrypted value is read without a key configured.
+var ErrEncryptionKeyMissing = errors.New("encrypted field found but no DATA_ENCRYPTION_KEY is configured")
+
+var (
+	// keyCipher wraps the per-value data keys; nil when no key is configured.
+	keyCipher cipher.AEAD
+	// encryptWrites is true when new values are encrypted, not just existing ones decrypted.
+	encryptWrites bool
+)
+
+// InitFieldEncryption configures at-rest encryption of sensitive columns. key is a base64-encoded
+// 32-byte AES key. With enabled false the key, if given, is still used to decrypt values written
+// while encryption was on, but new values are stored as plaintext.
+func InitFieldEncryption(key string, enabled bool) error {
+	if key == "" {
+		if enabled {
+			return errors.New("field encryption is enabled but no key is set")
+		}
+		return nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return fmt.Errorf("encryption key is not valid base64: %w", err)
+	}
+	if len(raw) != 32 {
+		return fmt.Errorf("encryption key must be 32 bytes, got %d", len(raw))
+	}
+	aead, err := newAEAD(raw)
+	if err != nil {
+		return err
+	}
+	keyCipher = aead
+	encryptWrites = enabled
+	return nil
+}
+
+// EncryptField returns the value to store for a sensitive column. Each value is sealed with its
+// own random data key, which is in turn sealed with the configured key (envelope encryption), so
+// the configured key only ever encrypts 32 random bytes. Without encryption enabled, or for an
+// empty value, plaintext is returned unchanged.
+func EncryptField(plaintext string) (string, error) {
+	if !encryptWrites || plaintext == "" {
+		return plaintext, nil
+	}
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", fmt.Errorf("error generating data key: %w", err)
+	}
+	wrappedKey, err := seal(keyCipher, dataKey)
+	if err != nil {
+		return "", err
+	}
+	dataCipher, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(dataCipher, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return encryptedFieldPrefix + base64.StdEncoding.EncodeToString(wrappedKey) + "." +
+		base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// DecryptField reverses EncryptField. Plaintext values are returned unchanged.
+func DecryptField(value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedFieldPrefix) {
+		return value, nil
+	}
+	if keyCipher == nil {
+		return "", ErrEncryptionKeyMissing
+	}
+	encodedKey, encodedText, ok := strings.Cut(strings.TrimPrefix(value, encryptedFieldPrefix), ".")
+	if !ok {
+		return "", errors.New("malformed encrypted field")
+	}
+	wrappedKey, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted field key: %w", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(encodedText)
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted field: %w", err)
+	}
+	dataKey, err := open(keyCipher, wrappedKey)
+	if err != nil {
+		return "", fmt.Errorf("error unwrapping data key: %w", err)
+	}
+	dataCipher, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(dataCipher, ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("error decrypting field: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts data under a fresh random nonce, returning nonce followed by ciphertext.
+func seal(aead cipher.AEAD, data []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("error generating nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, data, nil), nil
+}
+
+// open reverses seal.
+func open(aead cipher.AEAD, sealed []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// Sentinel errors for constraint violations, so callers can tell a bad write apart from a
+// failing database with errors.Is. Errors returned by TranslateError wrap both the sentinel
+// and the original *pq.Error.
+var (
+	ErrDuplicate    = errors.New("duplicate value violates a unique constraint")
+	ErrForeignKey   = errors.New("referenced record does not exist or is still referenced")
+	ErrNotNull      = errors.New("required value is missing")
+	ErrCheck        = errors.New("value violates a check constraint")
+	ErrInvalidInput = errors.New("value is invalid for its column")
+)
+
+// pqErrorSentinels maps Postgres SQLSTATE codes to the sentinel errors above.
+var pqErrorSentinels = map[pq.ErrorCode]error{
+	"23505": ErrDuplicate,    // unique_violation
+	"23503": ErrForeignKey,   // foreign_key_violation
+	"23502": ErrNotNull,      // not_null_violation
+	"23514": ErrCheck,        // check_violation
+	"22P02": ErrInvalidInput, // invalid_text_representation
+	"22001": ErrInvalidInput, // string_data_right_truncation
+	"22007": ErrInvalidInput, // invalid_datetime_format
+}
+
+// TranslateError wraps Postgres constraint errors with the matching sentinel error.
+// Other errors, including nil, are returned unchanged.
+func TranslateError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	sentinel, ok := pqErrorSentinels[pqErr.Code]
+	if !ok {
+		return err
+	}
+	if pqErr.Constraint != "" {
+		return fmt.Errorf("%w (%s): %w", sentinel, pqErr.Constraint, err)
+	}
+	return fmt.Errorf("%w: %w", sentinel, err)
+}
+
+// IsConstraintError reports whether err is one of the constraint sentinels, i.e. the write was
+// rejected because of the data rather than a database failure.
+func IsConstraintError(err error) bool {
+	for _, sentinel := range []error{ErrDuplicate, ErrForeignKey, ErrNotNull, ErrCheck, ErrInvalidInput} {
+		if errors.Is(err, sentinel) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestTranslateError(t *testing.T) {
+	errOther := errors.New("connection refused")
+
+	tests := []struct {
+		name           string
+		err            error
+		wantSentinel   error // nil when the error should be returned unchanged
+		wantConstraint string
+	}{
+		{"nil", nil, nil, ""},
+		{"not a pq error", errOther, nil, ""},
+		{"no rows", sql.ErrNoRows, nil, ""},
+		{"unique violation", &pq.Error{Code: "23505", Constraint: "users_email_key"}, ErrDuplicate, "users_email_key"},
+		{"wrapped unique violation", fmt.Errorf("insert user: %w", &pq.Error{Code: "23505"}), ErrDuplicate, ""},
+		{"foreign key violation", &pq.Error{Code: "23503", Constraint: "manim_projects_user_id_fkey"}, ErrForeignKey, "manim_projects_user_id_fkey"},
+		{"not null violation", &pq.Error{Code: "23502"}, ErrNotNull, ""},
+		{"check violation", &pq.Error{Code: "23514", Constraint: "manim_projects_fps_check"}, ErrCheck, "manim_projects_fps_check"},
+		{"invalid text representation", &pq.Error{Code: "22P02"}, ErrInvalidInput, ""},
+		{"string too long", &pq.Error{Code: "22001"}, ErrInvalidInput, ""},
+		{"invalid datetime", &pq.Error{Code: "22007"}, ErrInvalidInput, ""},
+		{"unmapped pq error", &pq.Error{Code: "40001"}, nil, ""}, // serialization_failure
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TranslateError(tt.err)
+			if tt.wantSentinel == nil {
+				if got != tt.err {
+					t.Fatalf("TranslateError(%v) = %v, want it unchanged", tt.err, got)
+				}
+				if IsConstraintError(got) {
+					t.Errorf("IsConstraintError(%v) = true, want false", got)
+				}
+				return
+			}
+			if !errors.Is(got, tt.wantSentinel) {
+				t.Errorf("TranslateError(%v) = %v, want it to wrap %v", tt.err, got, tt.wantSentinel)
+			}
+			var pqErr *pq.Error
+			if !errors.As(got, &pqErr) {
+				t.Errorf("TranslateError(%v) = %v no longer wraps the *pq.Error", tt.err, got)
+			}
+			if tt.wantConstraint != "" && !strings.Contains(got.Error(), tt.wantConstraint) {
+				t.Errorf("TranslateError(%v) = %q, want it to name constraint %q", tt.err, got, tt.wantConstraint)
+			}
+			if !IsConstraintError(got) {
+				t.Errorf("IsConstraintError(%v) = false, want true", got)
+			}
+		})
+	}
+}
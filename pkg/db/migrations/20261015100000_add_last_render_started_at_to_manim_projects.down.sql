@@ -0,0 +1,5 @@
+-- migrations/5_add_last_render_started_at_to_manim_projects.down.sql
+
+-- Remove the render cooldown tracking column.
+ALTER TABLE manim_projects
+DROP COLUMN IF EXISTS last_render_started_at;
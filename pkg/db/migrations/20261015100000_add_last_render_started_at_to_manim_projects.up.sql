@@ -0,0 +1,6 @@
+-- migrations/5_add_last_render_started_at_to_manim_projects.up.sql
+
+-- Track when the most recent render was triggered for each project.
+-- Used to enforce a minimum cooldown between consecutive renders of the same project.
+ALTER TABLE manim_projects
+ADD COLUMN last_render_started_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,6 @@
+-- migrations/6_add_metadata_to_manim_projects.down.sql
+
+DROP INDEX IF EXISTS idx_manim_projects_metadata;
+
+ALTER TABLE manim_projects
+DROP COLUMN IF EXISTS metadata;
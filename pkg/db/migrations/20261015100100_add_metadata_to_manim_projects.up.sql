@@ -0,0 +1,9 @@
+-- migrations/6_add_metadata_to_manim_projects.up.sql
+
+-- Free-form, client-owned JSON object attached to a project (e.g. course ID, external reference).
+-- Kept separate from server-controlled columns so integrations can never overwrite them.
+ALTER TABLE manim_projects
+ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+-- GIN index so metadata key/value lookups stay fast as the table grows.
+CREATE INDEX idx_manim_projects_metadata ON manim_projects USING GIN (metadata);
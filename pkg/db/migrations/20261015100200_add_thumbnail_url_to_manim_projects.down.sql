@@ -0,0 +1,4 @@
+-- migrations/7_add_thumbnail_url_to_manim_projects.down.sql
+
+ALTER TABLE manim_projects
+DROP COLUMN IF EXISTS thumbnail_url;
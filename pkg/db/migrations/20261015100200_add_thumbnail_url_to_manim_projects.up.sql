@@ -0,0 +1,5 @@
+-- migrations/7_add_thumbnail_url_to_manim_projects.up.sql
+
+-- Preview frame for a rendered video, set by the renderer via callback.
+ALTER TABLE manim_projects
+ADD COLUMN thumbnail_url TEXT NULL;
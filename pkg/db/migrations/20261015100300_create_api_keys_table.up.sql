@@ -0,0 +1,16 @@
+-- migrations/8_create_api_keys_table.up.sql
+
+-- Long-lived, revocable API keys for programmatic access (CI, scripts).
+-- Only a SHA-256 hash of each key is stored; the short prefix is kept in clear for lookup and display.
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Owner; keys are removed with the user
+    name VARCHAR(100) NOT NULL,                                    -- Label chosen by the user, e.g. "CI pipeline"
+    prefix VARCHAR(16) UNIQUE NOT NULL,                            -- Public, non-secret part of the key used for lookup
+    key_hash VARCHAR(64) NOT NULL,                                 -- Hex-encoded SHA-256 of the full key
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP WITH TIME ZONE NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE NULL                       -- Set when revoked; revoked keys are rejected
+);
+
+CREATE INDEX idx_api_keys_user_id ON api_keys (user_id);
@@ -0,0 +1,3 @@
+-- migrations/9_create_render_jobs_table.down.sql
+
+DROP TABLE IF EXISTS render_jobs;
@@ -0,0 +1,17 @@
+-- migrations/9_create_render_jobs_table.up.sql
+
+-- One row per render attempt, kept after the project moves on so usage can be reported over time.
+CREATE TABLE render_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    project_id UUID NOT NULL REFERENCES manim_projects(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(50) NOT NULL DEFAULT 'running', -- running, completed or failed
+    error TEXT NULL,                               -- Failure reason, e.g. code_gen_error or renderer_status_500
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+-- Per-user timelines filter by user and time range
+CREATE INDEX idx_render_jobs_user_id_started_at ON render_jobs (user_id, started_at);
+-- Callbacks close the most recent job of a project
+CREATE INDEX idx_render_jobs_project_id_started_at ON render_jobs (project_id, started_at DESC);
@@ -0,0 +1,3 @@
+-- migrations/10_create_merged_video_sources_table.down.sql
+
+DROP TABLE IF EXISTS merged_video_sources;
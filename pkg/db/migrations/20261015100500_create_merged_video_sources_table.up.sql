@@ -0,0 +1,19 @@
+-- migrations/10_create_merged_video_sources_table.up.sql
+
+-- merged_videos predates these migrations; create it on fresh databases so the reference below resolves.
+CREATE TABLE IF NOT EXISTS merged_videos (
+    id UUID PRIMARY KEY,
+    r2_url TEXT NOT NULL
+);
+
+-- Source projects of each merged video, in merge order. Lets users see which compilations a
+-- project is part of before deleting it.
+CREATE TABLE merged_video_sources (
+    merged_video_id UUID NOT NULL REFERENCES merged_videos(id) ON DELETE CASCADE,
+    project_id UUID NOT NULL REFERENCES manim_projects(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL, -- 0-based order of the clip in the merged video
+    PRIMARY KEY (merged_video_id, position)
+);
+
+-- Reverse lookup: merged videos that include a project
+CREATE INDEX idx_merged_video_sources_project_id ON merged_video_sources (project_id);
@@ -0,0 +1,4 @@
+-- migrations/11_add_auto_reconcile_to_manim_projects.down.sql
+
+ALTER TABLE manim_projects
+DROP COLUMN IF EXISTS auto_reconcile;
@@ -0,0 +1,5 @@
+-- migrations/11_add_auto_reconcile_to_manim_projects.up.sql
+
+-- When false, the stuck-render reconciler leaves the project alone (for intentionally long renders).
+ALTER TABLE manim_projects
+ADD COLUMN auto_reconcile BOOLEAN NOT NULL DEFAULT TRUE;
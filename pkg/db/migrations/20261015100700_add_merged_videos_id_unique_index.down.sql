@@ -0,0 +1,3 @@
+-- migrations/12_add_merged_videos_id_unique_index.down.sql
+
+DROP INDEX IF EXISTS idx_merged_videos_id;
@@ -0,0 +1,5 @@
+-- migrations/12_add_merged_videos_id_unique_index.up.sql
+
+-- merged_videos predates these migrations and older databases may lack a key on id, which the
+-- ON CONFLICT (id) upsert requires. Redundant (but harmless) where id is already the primary key.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_merged_videos_id ON merged_videos (id);
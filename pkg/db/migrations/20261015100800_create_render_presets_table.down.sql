@@ -0,0 +1,3 @@
+-- migrations/13_create_render_presets_table.down.sql
+
+DROP TABLE IF EXISTS render_presets;
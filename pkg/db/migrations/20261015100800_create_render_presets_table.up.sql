@@ -0,0 +1,16 @@
+-- migrations/13_create_render_presets_table.up.sql
+
+-- Named render configurations saved by a user and applied by name when triggering a render.
+-- Empty option columns mean "use the service default".
+CREATE TABLE render_presets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Owner; presets are removed with the user
+    name VARCHAR(100) NOT NULL,
+    quality VARCHAR(20) NOT NULL DEFAULT '',                      -- Renderer quality: low, medium, high, production, 4k
+    format VARCHAR(10) NOT NULL DEFAULT '',                       -- Output container: mp4, mov, webm, gif
+    model VARCHAR(64) NOT NULL DEFAULT '',                        -- Gemini model used for code generation
+    profile VARCHAR(32) NOT NULL DEFAULT '',                      -- Renderer-side profile name
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name)
+);
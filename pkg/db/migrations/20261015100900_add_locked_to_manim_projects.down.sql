@@ -0,0 +1,4 @@
+-- migrations/14_add_locked_to_manim_projects.down.sql
+
+ALTER TABLE manim_projects
+DROP COLUMN IF EXISTS locked;
@@ -0,0 +1,5 @@
+-- migrations/14_add_locked_to_manim_projects.up.sql
+
+-- Locked projects are read-only: edits, deletion and new renders are refused until unlocked.
+ALTER TABLE manim_projects
+ADD COLUMN locked BOOLEAN NOT NULL DEFAULT FALSE;
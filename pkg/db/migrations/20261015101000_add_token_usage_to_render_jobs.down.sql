@@ -0,0 +1,5 @@
+-- migrations/15_add_token_usage_to_render_jobs.down.sql
+
+ALTER TABLE render_jobs
+DROP COLUMN IF EXISTS output_tokens,
+DROP COLUMN IF EXISTS input_tokens;
@@ -0,0 +1,7 @@
+-- migrations/15_add_token_usage_to_render_jobs.up.sql
+
+-- Gemini token usage reported for the code generation of each render (NULL when not reported,
+-- e.g. renders of user-supplied code).
+ALTER TABLE render_jobs
+ADD COLUMN input_tokens INTEGER,
+ADD COLUMN output_tokens INTEGER;
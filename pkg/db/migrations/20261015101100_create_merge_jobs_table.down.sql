@@ -0,0 +1,3 @@
+-- migrations/16_create_merge_jobs_table.down.sql
+
+DROP TABLE IF EXISTS merge_jobs;
@@ -0,0 +1,13 @@
+-- migrations/16_create_merge_jobs_table.up.sql
+
+-- One row per merge submitted to the renderer, keeping the exact inputs so failed merges can be retried.
+CREATE TABLE merge_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    inputs JSONB NOT NULL,                          -- Payload sent to the renderer: {"ids": [...], "clips": [...]}
+    status VARCHAR(50) NOT NULL DEFAULT 'running',  -- running, completed or failed
+    error TEXT NULL,                                -- Failure reason of the last attempt
+    merged_video_id UUID NULL,                      -- Set once the merge completes
+    attempts INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,4 @@
+-- migrations/17_add_notes_to_manim_projects.down.sql
+
+ALTER TABLE manim_projects
+DROP COLUMN IF EXISTS notes;
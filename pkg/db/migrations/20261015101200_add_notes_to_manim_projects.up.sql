@@ -0,0 +1,5 @@
+-- migrations/17_add_notes_to_manim_projects.up.sql
+
+-- Internal/team notes on a project, separate from the user-facing description.
+ALTER TABLE manim_projects
+ADD COLUMN notes TEXT NOT NULL DEFAULT '';
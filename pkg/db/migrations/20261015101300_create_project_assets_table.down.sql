@@ -0,0 +1,3 @@
+-- migrations/18_create_project_assets_table.down.sql
+
+DROP TABLE IF EXISTS project_assets;
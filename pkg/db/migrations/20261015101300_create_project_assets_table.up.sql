@@ -0,0 +1,13 @@
+-- migrations/18_create_project_assets_table.up.sql
+
+-- Files (images, SVGs) uploaded for a project's Manim script to reference, stored in R2.
+CREATE TABLE project_assets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    project_id UUID NOT NULL REFERENCES manim_projects(id) ON DELETE CASCADE,
+    filename VARCHAR(100) NOT NULL,      -- Name the script refers to the asset by
+    object_key TEXT NOT NULL,            -- R2 key: assets/<project_id>/<filename>
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (project_id, filename)
+);
@@ -0,0 +1,7 @@
+-- migrations/19_add_callback_nonce_to_render_jobs.down.sql
+
+DROP INDEX IF EXISTS idx_render_jobs_callback_nonce;
+
+ALTER TABLE render_jobs
+DROP COLUMN IF EXISTS callback_used_at,
+DROP COLUMN IF EXISTS callback_nonce;
@@ -0,0 +1,9 @@
+-- migrations/19_add_callback_nonce_to_render_jobs.up.sql
+
+-- One-time nonce embedded in the signed callback URL of each render (NULL when callbacks aren't
+-- signed), and when the callback using it was accepted.
+ALTER TABLE render_jobs
+ADD COLUMN callback_nonce VARCHAR(64),
+ADD COLUMN callback_used_at TIMESTAMP WITH TIME ZONE;
+
+CREATE UNIQUE INDEX idx_render_jobs_callback_nonce ON render_jobs (callback_nonce);
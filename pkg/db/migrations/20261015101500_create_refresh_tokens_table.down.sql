@@ -0,0 +1,3 @@
+-- migrations/20_create_refresh_tokens_table.down.sql
+
+DROP TABLE IF EXISTS refresh_tokens;
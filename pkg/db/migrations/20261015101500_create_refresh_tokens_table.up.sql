@@ -0,0 +1,14 @@
+-- migrations/20_create_refresh_tokens_table.up.sql
+
+-- Long-lived refresh tokens exchanged at POST /auth/refresh for new access tokens. Each token is
+-- single-use: refreshing revokes it and issues a replacement. Only a SHA-256 hash is stored.
+CREATE TABLE refresh_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Tokens are removed with the user
+    token_hash VARCHAR(64) UNIQUE NOT NULL,                        -- Hex-encoded SHA-256 of the token
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE NULL                       -- Set when the token is rotated or revoked
+);
+
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens (user_id);
@@ -0,0 +1,3 @@
+-- migrations/21_create_revoked_tokens_table.down.sql
+
+DROP TABLE IF EXISTS revoked_tokens;
@@ -0,0 +1,11 @@
+-- migrations/21_create_revoked_tokens_table.up.sql
+
+-- Access tokens revoked before their expiry by POST /auth/logout, keyed on the token's "jti".
+-- Rows are only needed until the token would have expired anyway and are swept after that.
+CREATE TABLE revoked_tokens (
+    jti VARCHAR(64) PRIMARY KEY,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL, -- The token's "exp"; the row can be deleted after this
+    revoked_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
@@ -0,0 +1,3 @@
+-- migrations/22_create_project_transfers_table.down.sql
+
+DROP TABLE IF EXISTS project_transfers;
@@ -0,0 +1,13 @@
+-- migrations/22_create_project_transfers_table.up.sql
+
+-- One row per POST /api/projects/:id/transfer, so recipients can list what was handed to them.
+-- Transfers take effect immediately; sub-projects move with the recorded project.
+CREATE TABLE project_transfers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    project_id UUID NOT NULL REFERENCES manim_projects(id) ON DELETE CASCADE,
+    from_user_id UUID REFERENCES users(id) ON DELETE SET NULL, -- NULL once the previous owner is deleted
+    to_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    transferred_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_project_transfers_to_user_id ON project_transfers (to_user_id);
@@ -0,0 +1,3 @@
+-- migrations/23_create_deleted_projects_table.down.sql
+
+DROP TABLE IF EXISTS deleted_projects;
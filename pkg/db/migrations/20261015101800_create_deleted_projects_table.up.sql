@@ -0,0 +1,9 @@
+-- migrations/23_create_deleted_projects_table.up.sql
+
+-- Tombstones of deleted projects, so a render callback that arrives after its project was
+-- deleted can be told apart from one for a project that never existed, and its output cleaned up.
+CREATE TABLE deleted_projects (
+    project_id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
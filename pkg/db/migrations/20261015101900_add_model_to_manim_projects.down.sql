@@ -0,0 +1,4 @@
+-- migrations/24_add_model_to_manim_projects.down.sql
+
+ALTER TABLE manim_projects
+DROP COLUMN IF EXISTS model;
@@ -0,0 +1,5 @@
+-- migrations/24_add_model_to_manim_projects.up.sql
+
+-- Gemini model chosen for the project's code generation; empty uses GEMINI_MODEL.
+ALTER TABLE manim_projects
+ADD COLUMN model TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,7 @@
+-- migrations/25_add_status_to_merged_videos.down.sql
+
+ALTER TABLE merged_videos ALTER COLUMN r2_url DROP DEFAULT;
+
+ALTER TABLE merged_videos
+DROP COLUMN IF EXISTS error,
+DROP COLUMN IF EXISTS status;
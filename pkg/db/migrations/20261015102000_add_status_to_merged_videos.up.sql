@@ -0,0 +1,9 @@
+-- migrations/25_add_status_to_merged_videos.up.sql
+
+-- Merges run asynchronously: the row is created as 'merging' when the merge is submitted and the
+-- renderer's callback moves it to 'completed' (with r2_url) or 'failed'. Existing rows are finished merges.
+ALTER TABLE merged_videos
+ADD COLUMN status VARCHAR(50) NOT NULL DEFAULT 'completed',
+ADD COLUMN error TEXT NULL;
+
+ALTER TABLE merged_videos ALTER COLUMN r2_url SET DEFAULT '';
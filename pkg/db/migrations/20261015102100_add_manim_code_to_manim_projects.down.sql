@@ -0,0 +1,4 @@
+-- migrations/26_add_manim_code_to_manim_projects.down.sql
+
+ALTER TABLE manim_projects
+DROP COLUMN IF EXISTS manim_code;
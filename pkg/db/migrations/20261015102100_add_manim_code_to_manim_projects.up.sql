@@ -0,0 +1,7 @@
+-- migrations/26_add_manim_code_to_manim_projects.up.sql
+
+-- Script last sent to the renderer for the project (generated or user-supplied), so a failed
+-- render can be retried without calling Gemini again. Encrypted like the prompt when
+-- ENCRYPT_SENSITIVE_FIELDS is on.
+ALTER TABLE manim_projects
+ADD COLUMN manim_code TEXT NULL;
@@ -0,0 +1,5 @@
+-- migrations/27_add_resolution_and_fps_to_manim_projects.down.sql
+
+ALTER TABLE manim_projects
+DROP COLUMN IF EXISTS fps,
+DROP COLUMN IF EXISTS resolution;
@@ -0,0 +1,6 @@
+-- migrations/27_add_resolution_and_fps_to_manim_projects.up.sql
+
+-- Output settings sent to the renderer. Existing projects get the defaults.
+ALTER TABLE manim_projects
+ADD COLUMN resolution VARCHAR(10) NOT NULL DEFAULT '720p',
+ADD COLUMN fps INTEGER NOT NULL DEFAULT 30;
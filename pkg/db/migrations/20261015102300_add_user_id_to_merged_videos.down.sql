@@ -0,0 +1,7 @@
+-- migrations/28_add_user_id_to_merged_videos.down.sql
+
+DROP INDEX IF EXISTS idx_merged_videos_user_id_created_at;
+
+ALTER TABLE merged_videos
+DROP COLUMN IF EXISTS created_at,
+DROP COLUMN IF EXISTS user_id;
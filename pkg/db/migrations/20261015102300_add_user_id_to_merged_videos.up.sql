@@ -0,0 +1,26 @@
+-- migrations/28_add_user_id_to_merged_videos.up.sql
+
+-- Owner of each merged video, so merges can be listed per user. The merge route isn't
+-- authenticated, so the owner is the user owning all of its sources; NULL when they differ.
+ALTER TABLE merged_videos
+ADD COLUMN user_id UUID NULL REFERENCES users(id) ON DELETE CASCADE,
+ADD COLUMN created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+UPDATE merged_videos mv
+SET user_id = owners.user_id
+FROM (
+    SELECT s.merged_video_id, (array_agg(DISTINCT p.user_id))[1] AS user_id
+    FROM merged_video_sources s
+    JOIN manim_projects p ON p.id = s.project_id
+    GROUP BY s.merged_video_id
+    HAVING COUNT(DISTINCT p.user_id) = 1
+) owners
+WHERE owners.merged_video_id = mv.id;
+
+-- Existing merges were submitted when their first merge job was
+UPDATE merged_videos mv
+SET created_at = jobs.created_at
+FROM (SELECT merged_video_id, MIN(created_at) AS created_at FROM merge_jobs GROUP BY merged_video_id) jobs
+WHERE jobs.merged_video_id = mv.id;
+
+CREATE INDEX idx_merged_videos_user_id_created_at ON merged_videos (user_id, created_at DESC);
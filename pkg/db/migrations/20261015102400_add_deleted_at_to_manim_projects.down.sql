@@ -0,0 +1,11 @@
+-- migrations/29_add_deleted_at_to_manim_projects.down.sql
+
+-- Soft-deleted projects would otherwise reappear as live ones
+DELETE FROM manim_projects WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_manim_projects_deleted_at;
+DROP INDEX IF EXISTS idx_manim_projects_user_id_name;
+CREATE UNIQUE INDEX idx_manim_projects_user_id_name ON manim_projects (user_id, name);
+
+ALTER TABLE manim_projects
+DROP COLUMN IF EXISTS deleted_at;
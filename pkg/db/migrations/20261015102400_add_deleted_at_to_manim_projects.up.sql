@@ -0,0 +1,13 @@
+-- migrations/29_add_deleted_at_to_manim_projects.up.sql
+
+-- Deleting a project only stamps deleted_at, so it can be restored until the purge job removes
+-- it for good. Soft-deleted projects are left out of every query that finds projects.
+ALTER TABLE manim_projects
+ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE NULL;
+
+-- Names only need to be unique among live projects, so a deleted project's name can be reused
+DROP INDEX IF EXISTS idx_manim_projects_user_id_name;
+CREATE UNIQUE INDEX idx_manim_projects_user_id_name ON manim_projects (user_id, name) WHERE deleted_at IS NULL;
+
+-- For the purge job
+CREATE INDEX idx_manim_projects_deleted_at ON manim_projects (deleted_at) WHERE deleted_at IS NOT NULL;
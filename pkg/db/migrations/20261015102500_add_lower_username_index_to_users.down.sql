@@ -0,0 +1,3 @@
+-- migrations/30_add_lower_username_index_to_users.down.sql
+
+DROP INDEX IF EXISTS idx_users_username_lower;
@@ -0,0 +1,4 @@
+-- migrations/30_add_lower_username_index_to_users.up.sql
+
+-- Usernames are checked for uniqueness ignoring case, which the plain unique index can't serve
+CREATE INDEX idx_users_username_lower ON users (LOWER(username));
@@ -0,0 +1,10 @@
+-- migrations/31_drop_deleted_projects_table.down.sql
+
+CREATE TABLE deleted_projects (
+    project_id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO deleted_projects (project_id, user_id, deleted_at)
+SELECT id, user_id, deleted_at FROM manim_projects WHERE deleted_at IS NOT NULL;
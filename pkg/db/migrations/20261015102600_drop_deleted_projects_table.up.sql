@@ -0,0 +1,5 @@
+-- migrations/31_drop_deleted_projects_table.up.sql
+
+-- Deleted projects are now soft-deleted rows of manim_projects (deleted_at), which is all the
+-- render callback needs, so the tombstones are redundant.
+DROP TABLE IF EXISTS deleted_projects;
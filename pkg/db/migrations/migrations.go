@@ -0,0 +1,132 @@
+// Package migrations holds the database schema as versioned SQL files and applies them.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	log "github.com/sirupsen/logrus"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// lockKey is the Postgres advisory lock held while a migration is applied, so that instances
+// starting together don't apply the same migration twice.
+const lockKey = 7146921560
+
+// migration is one <version>_<name>.up.sql file.
+type migration struct {
+	Version int64
+	File    string
+}
+
+// RunMigrations applies the embedded migrations that are newer than the database's schema
+// version, in order, each in its own transaction. The version is tracked in schema_migrations
+// the way golang-migrate tracks it (a single version/dirty row), so databases migrated with the
+// migrate CLI are picked up where they left off.
+func RunMigrations(db *sqlx.DB) error {
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	pending, err := upMigrations()
+	if err != nil {
+		return err
+	}
+	applied := 0
+	for _, m := range pending {
+		ran, err := apply(ctx, db, m)
+		if err != nil {
+			return fmt.Errorf("migration %s failed: %w", m.File, err)
+		}
+		if ran {
+			log.Infof("Applied database migration %s.", m.File)
+			applied++
+		}
+	}
+	if applied == 0 {
+		log.Info("Database schema is up to date.")
+	}
+	return nil
+}
+
+// upMigrations lists the embedded up migrations in version order.
+func upMigrations() ([]migration, error) {
+	names, err := files.ReadDir(".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded migrations: %w", err)
+	}
+	var list []migration
+	for _, entry := range names {
+		name := entry.Name()
+		if !strings.HasSuffix(name, ".up.sql") {
+			continue
+		}
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has no numeric version prefix", name)
+		}
+		list = append(list, migration{Version: version, File: name})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	for i := 1; i < len(list); i++ {
+		if list[i].Version == list[i-1].Version {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", list[i-1].File, list[i].File, list[i].Version)
+		}
+	}
+	return list, nil
+}
+
+// apply runs m unless the database is already at or past its version, reporting whether it ran.
+func apply(ctx context.Context, db *sqlx.DB, m migration) (bool, error) {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback() // No-op once committed
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, lockKey); err != nil {
+		return false, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	var current struct {
+		Version int64 `db:"version"`
+		Dirty   bool  `db:"dirty"`
+	}
+	err = tx.GetContext(ctx, &current, `SELECT version, dirty FROM schema_migrations LIMIT 1`)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		current.Version = -1
+	case err != nil:
+		return false, fmt.Errorf("failed to read schema version: %w", err)
+	case current.Dirty:
+		return false, fmt.Errorf("database is marked dirty at version %d; repair it and clear the flag in schema_migrations", current.Version)
+	}
+	if m.Version <= current.Version {
+		return false, nil
+	}
+
+	body, err := files.ReadFile(m.File)
+	if err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, string(body)); err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`, m.Version); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
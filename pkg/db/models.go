@@ -2,6 +2,8 @@ package db
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -27,4 +29,123 @@ type ManimProject struct {
 	CreatedAt   time.Time `db:"created_at"`
 	UpdatedAt   time.Time `db:"updated_at"`
 	ParentProjectID sql.NullString `db:"parent_project_id"`
-}
\ No newline at end of file
+	LastRenderStartedAt sql.NullTime `db:"last_render_started_at"` // When the most recent render was triggered
+	Metadata    JSONB     `db:"metadata"` // Client-supplied JSON object
+	ThumbnailURL sql.NullString `db:"thumbnail_url"` // Preview frame extracted from the rendered video
+	AutoReconcile bool `db:"auto_reconcile"` // False when pinned: the stuck-render reconciler skips the project
+	Locked bool `db:"locked"` // Read-only: no edits, deletion or renders until unlocked
+	Notes string `db:"notes"` // Internal/team notes, unlike the user-facing description
+	Model string `db:"model"` // Gemini model for code generation; empty uses the service default
+	Resolution string `db:"resolution"` // Output resolution sent to the renderer, e.g. 720p
+	FPS int `db:"fps"` // Output frame rate sent to the renderer
+	DeletedAt sql.NullTime `db:"deleted_at"` // Set when soft-deleted; such projects are only found as merge sources
+}
+
+// JSONB holds a raw JSON document stored in a Postgres JSONB column.
+// An empty value is stored as an empty object.
+type JSONB []byte
+
+// Value implements driver.Valuer.
+func (j JSONB) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return "{}", nil
+	}
+	return string(j), nil
+}
+
+// Scan implements sql.Scanner.
+func (j *JSONB) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		*j = append((*j)[:0], v...)
+	case string:
+		*j = JSONB(v)
+	case nil:
+		*j = nil
+	default:
+		return fmt.Errorf("cannot scan %T into JSONB", src)
+	}
+	return nil
+}
+
+// APIKey is a long-lived credential for programmatic access. Only the hash of the key is stored.
+type APIKey struct {
+	ID         uuid.UUID    `db:"id"`
+	UserID     uuid.UUID    `db:"user_id"`
+	Name       string       `db:"name"`
+	Prefix     string       `db:"prefix"`   // Non-secret lookup prefix, shown to the user
+	KeyHash    string       `db:"key_hash"` // Hex SHA-256 of the full key
+	CreatedAt  time.Time    `db:"created_at"`
+	LastUsedAt sql.NullTime `db:"last_used_at"`
+	RevokedAt  sql.NullTime `db:"revoked_at"`
+}
+
+// RenderPreset is a named set of render options saved by a user. Empty options use the service default.
+type RenderPreset struct {
+	ID        uuid.UUID `db:"id"`
+	UserID    uuid.UUID `db:"user_id"`
+	Name      string    `db:"name"`
+	Quality   string    `db:"quality"`
+	Format    string    `db:"format"`
+	Model     string    `db:"model"`
+	Profile   string    `db:"profile"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// RenderJob records a single render attempt of a project.
+type RenderJob struct {
+	ID         uuid.UUID      `db:"id"`
+	ProjectID  uuid.UUID      `db:"project_id"`
+	UserID     uuid.UUID      `db:"user_id"`
+	Status     string         `db:"status"` // running, completed or failed
+	Error      sql.NullString `db:"error"`
+	StartedAt  time.Time      `db:"started_at"`
+	FinishedAt sql.NullTime   `db:"finished_at"`
+
+	InputTokens  sql.NullInt64 `db:"input_tokens"`  // Gemini prompt tokens, if reported
+	OutputTokens sql.NullInt64 `db:"output_tokens"` // Gemini response tokens, if reported
+}
+
+// MergeJob is one merge submitted to the renderer, with the inputs needed to retry it.
+type MergeJob struct {
+	ID            uuid.UUID      `db:"id"`
+	Inputs        JSONB          `db:"inputs"` // Renderer payload: {"ids": [...], "clips": [...]}
+	Status        string         `db:"status"` // running, completed or failed
+	Error         sql.NullString `db:"error"`
+	MergedVideoID uuid.NullUUID  `db:"merged_video_id"` // Merged video the job produces
+	Attempts      int            `db:"attempts"`
+	CreatedAt     time.Time      `db:"created_at"`
+	UpdatedAt     time.Time      `db:"updated_at"`
+}
+
+// MergedVideo is a compilation of several rendered projects.
+type MergedVideo struct {
+	ID        uuid.UUID      `db:"id"`
+	UserID    uuid.NullUUID  `db:"user_id"` // Owner of all its sources; NULL if they belong to different users
+	R2URL     string         `db:"r2_url"`  // Empty until the merge completes
+	Status    string         `db:"status"`  // merging, completed or failed
+	Error     sql.NullString `db:"error"`   // Failure reason reported by the renderer
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+// ProjectAsset is a file uploaded for a project's script to reference, handed to the renderer with each render.
+type ProjectAsset struct {
+	ID          uuid.UUID `db:"id"`
+	ProjectID   uuid.UUID `db:"project_id"`
+	Filename    string    `db:"filename"`
+	ObjectKey   string    `db:"object_key"`
+	ContentType string    `db:"content_type"`
+	SizeBytes   int64     `db:"size_bytes"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// RefreshToken is a single-use credential exchanged for a new access token. Only its hash is stored.
+type RefreshToken struct {
+	ID        uuid.UUID    `db:"id"`
+	UserID    uuid.UUID    `db:"user_id"`
+	TokenHash string       `db:"token_hash"` // Hex SHA-256 of the token
+	CreatedAt time.Time    `db:"created_at"`
+	ExpiresAt time.Time    `db:"expires_at"`
+	RevokedAt sql.NullTime `db:"revoked_at"`
+}
@@ -0,0 +1,55 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	log "github.com/sirupsen/logrus"
+)
+
+// AdminProject is a Manim project along with its owner's email, for the admin project list.
+type AdminProject struct {
+	UserEmail string `db:"user_email"`
+	db.ManimProject
+}
+
+// AdminProjectFilter narrows the admin project list across all users.
+type AdminProjectFilter struct {
+	ProjectFilter
+	UserEmail string // Owner's email (exact, case-insensitive)
+	Limit     int
+	Offset    int
+}
+
+// FindAllManimProjects returns one page of projects across all users matching filter, newest
+// first, along with the total number of matching projects.
+func FindAllManimProjects(ctx context.Context, filter AdminProjectFilter) ([]AdminProject, int, error) {
+	conditions, args := filter.prefixedConditions("p", nil)
+	if filter.UserEmail != "" {
+		args = append(args, filter.UserEmail)
+		conditions += fmt.Sprintf(" AND lower(u.email) = lower($%d)", len(args))
+	}
+	from := ` FROM manim_projects p JOIN users u ON u.id = p.user_id WHERE TRUE` + conditions
+
+	var total int
+	if err := db.Conn(ctx).Get(&total, `SELECT COUNT(*)`+from, args...); err != nil {
+		log.Errorf("Error counting projects for admin list: %v", err)
+		return nil, 0, fmt.Errorf("error counting projects: %w", err)
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := `SELECT u.email AS user_email, ` + prefixedManimProjectColumns("p") + from +
+		fmt.Sprintf(` ORDER BY p.created_at DESC LIMIT $%d OFFSET $%d`, len(args)-1, len(args))
+	var projects []AdminProject
+	if err := db.Conn(ctx).Select(&projects, query, args...); err != nil {
+		log.Errorf("Error listing projects for admin list: %v", err)
+		return nil, 0, fmt.Errorf("error listing projects: %w", err)
+	}
+	for i := range projects {
+		if err := decryptProject(&projects[i].ManimProject); err != nil {
+			return nil, 0, err
+		}
+	}
+	return projects, total, nil
+}
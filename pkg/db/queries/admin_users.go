@@ -0,0 +1,102 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// AdminUser is a user as shown to support staff; it never carries the password hash.
+type AdminUser struct {
+	ID        uuid.UUID `db:"id"`
+	Username  string    `db:"username"`
+	Email     string    `db:"email"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+const adminUserColumns = `id, username, email, created_at, updated_at`
+
+// AdminUserFilter narrows the admin user list.
+type AdminUserFilter struct {
+	EmailContains string    // Case-insensitive substring of the email
+	CreatedAfter  time.Time // created_at >= CreatedAfter
+	CreatedBefore time.Time // created_at < CreatedBefore
+	Limit         int
+	Offset        int
+}
+
+// FindUsersPaginated returns one page of users matching filter, newest first, along with the
+// total number of matching users.
+func FindUsersPaginated(ctx context.Context, filter AdminUserFilter) ([]AdminUser, int, error) {
+	conditions := ""
+	var args []interface{}
+	if filter.EmailContains != "" {
+		args = append(args, filter.EmailContains)
+		conditions += fmt.Sprintf(" AND strpos(lower(email), lower($%d)) > 0", len(args))
+	}
+	if !filter.CreatedAfter.IsZero() {
+		args = append(args, filter.CreatedAfter)
+		conditions += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
+	if !filter.CreatedBefore.IsZero() {
+		args = append(args, filter.CreatedBefore)
+		conditions += fmt.Sprintf(" AND created_at < $%d", len(args))
+	}
+	from := ` FROM users WHERE TRUE` + conditions
+
+	var total int
+	if err := db.Conn(ctx).Get(&total, `SELECT COUNT(*)`+from, args...); err != nil {
+		log.Errorf("Error counting users for admin list: %v", err)
+		return nil, 0, fmt.Errorf("error counting users: %w", err)
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := `SELECT ` + adminUserColumns + from +
+		fmt.Sprintf(` ORDER BY created_at DESC LIMIT $%d OFFSET $%d`, len(args)-1, len(args))
+	var users []AdminUser
+	if err := db.Conn(ctx).Select(&users, query, args...); err != nil {
+		log.Errorf("Error listing users for admin list: %v", err)
+		return nil, 0, fmt.Errorf("error listing users: %w", err)
+	}
+	return users, total, nil
+}
+
+// AdminUserSummary holds a user's project and render counts.
+type AdminUserSummary struct {
+	Projects         int `db:"projects" json:"projects"`
+	Renders          int `db:"renders" json:"renders"`
+	RendersCompleted int `db:"renders_completed" json:"renders_completed"`
+	RendersFailed    int `db:"renders_failed" json:"renders_failed"`
+}
+
+// FindAdminUserByID returns a user and their activity summary, or nil, nil, nil if the user doesn't exist.
+func FindAdminUserByID(ctx context.Context, id uuid.UUID) (*AdminUser, *AdminUserSummary, error) {
+	user := &AdminUser{}
+	if err := db.Conn(ctx).Get(user, `SELECT `+adminUserColumns+` FROM users WHERE id = $1`, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, nil
+		}
+		log.Errorf("Error finding user '%s' for admin: %v", id.String(), err)
+		return nil, nil, fmt.Errorf("error finding user: %w", err)
+	}
+
+	summary := &AdminUserSummary{}
+	query := `
+        SELECT (SELECT COUNT(*) FROM manim_projects WHERE user_id = $1 AND deleted_at IS NULL) AS projects,
+               COUNT(*) AS renders,
+               COUNT(*) FILTER (WHERE status = 'completed') AS renders_completed,
+               COUNT(*) FILTER (WHERE status = 'failed') AS renders_failed
+        FROM render_jobs
+        WHERE user_id = $1`
+	if err := db.Conn(ctx).Get(summary, query, id); err != nil {
+		log.Errorf("Error summarizing user '%s' for admin: %v", id.String(), err)
+		return nil, nil, fmt.Errorf("error summarizing user: %w", err)
+	}
+	return user, summary, nil
+}
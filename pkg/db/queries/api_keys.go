@@ -0,0 +1,103 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, created_at, last_used_at, revoked_at`
+
+// CreateAPIKey inserts a new API key and fills in its generated fields.
+func CreateAPIKey(ctx context.Context, key *db.APIKey) (*db.APIKey, error) {
+	query := `
+        INSERT INTO api_keys (user_id, name, prefix, key_hash)
+        VALUES (:user_id, :name, :prefix, :key_hash)
+        RETURNING ` + apiKeyColumns
+
+	rows, err := db.Conn(ctx).NamedQuery(query, key)
+	if err != nil {
+		log.Errorf("Error creating API key for user '%s': %v", key.UserID.String(), err)
+		return nil, fmt.Errorf("error creating API key: %w", db.TranslateError(err))
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.StructScan(key); err != nil {
+			log.Errorf("Error scanning API key after creation: %v", err)
+			return nil, fmt.Errorf("error scanning created API key: %w", err)
+		}
+	} else {
+		return nil, fmt.Errorf("no rows returned after API key creation")
+	}
+	return key, nil
+}
+
+// FindAPIKeysByUserID lists a user's API keys, including revoked ones, newest first.
+func FindAPIKeysByUserID(ctx context.Context, userID uuid.UUID) ([]db.APIKey, error) {
+	var keys []db.APIKey
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC`
+	if err := db.Conn(ctx).Select(&keys, query, userID); err != nil {
+		log.Errorf("Error finding API keys for user ID '%s': %v", userID.String(), err)
+		return nil, fmt.Errorf("error finding API keys by user ID: %w", err)
+	}
+	return keys, nil
+}
+
+// CountActiveAPIKeysByUserID counts a user's keys that haven't been revoked.
+func CountActiveAPIKeysByUserID(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM api_keys WHERE user_id = $1 AND revoked_at IS NULL`
+	if err := db.Conn(ctx).Get(&count, query, userID); err != nil {
+		log.Errorf("Error counting API keys for user ID '%s': %v", userID.String(), err)
+		return 0, fmt.Errorf("error counting API keys: %w", err)
+	}
+	return count, nil
+}
+
+// FindActiveAPIKeyByPrefix retrieves a non-revoked API key by its lookup prefix.
+// Returns nil, nil if no such key exists.
+func FindActiveAPIKeyByPrefix(ctx context.Context, prefix string) (*db.APIKey, error) {
+	key := &db.APIKey{}
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE prefix = $1 AND revoked_at IS NULL`
+	err := db.Conn(ctx).Get(key, query, prefix)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Errorf("Error finding API key by prefix: %v", err)
+		return nil, fmt.Errorf("error finding API key: %w", err)
+	}
+	return key, nil
+}
+
+// RevokeAPIKey marks a user's API key as revoked. Returns sql.ErrNoRows if the key doesn't exist,
+// belongs to someone else or was already revoked.
+func RevokeAPIKey(ctx context.Context, id, userID uuid.UUID) error {
+	query := `UPDATE api_keys SET revoked_at = $1 WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL`
+	result, err := db.Conn(ctx).Exec(query, time.Now().UTC(), id, userID)
+	if err != nil {
+		log.Errorf("Error revoking API key '%s': %v", id.String(), err)
+		return fmt.Errorf("error revoking API key: %w", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	log.Infof("API key '%s' revoked for user '%s'.", id.String(), userID.String())
+	return nil
+}
+
+// TouchAPIKey records that an API key was just used.
+func TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	_, err := db.Conn(ctx).Exec(`UPDATE api_keys SET last_used_at = $1 WHERE id = $2`, time.Now().UTC(), id)
+	if err != nil {
+		log.Warnf("Error updating last_used_at for API key '%s': %v", id.String(), err)
+	}
+	return err
+}
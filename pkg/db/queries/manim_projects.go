@@ -3,34 +3,100 @@
 package queries
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt" // Import fmt for error formatting
+	"strings"
 	"time"
 
 	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db" // Import your db package (assuming db.DB is *sqlx.DB)
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	log "github.com/sirupsen/logrus"
 )
 
+// ErrProjectLocked is returned when an operation is refused because the project is locked.
+var ErrProjectLocked = errors.New("project is locked")
+
+// manimProjectColumns lists the columns selected into a db.ManimProject by the find queries.
+const manimProjectColumns = `id, user_id, name, description, prompt, render_status, video_url, created_at, updated_at,
+	parent_project_id, last_render_started_at, metadata, thumbnail_url, auto_reconcile, locked, notes, model, resolution, fps, deleted_at`
+
+// encryptedRow returns a copy of project for writing, with its sensitive fields encrypted when
+// ENCRYPT_SENSITIVE_FIELDS is on. The prompt is the only one on db.ManimProject; the Manim code is
+// written separately by SetManimProjectCode.
+func encryptedRow(project *db.ManimProject) (*db.ManimProject, error) {
+	row := *project
+	prompt, err := db.EncryptField(project.Prompt)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting project prompt: %w", err)
+	}
+	row.Prompt = prompt
+	return &row, nil
+}
+
+// decryptProject decrypts the sensitive fields of a project read from the database in place.
+func decryptProject(project *db.ManimProject) error {
+	prompt, err := db.DecryptField(project.Prompt)
+	if err != nil {
+		log.Errorf("Error decrypting prompt of Manim project '%s': %v", project.ID.String(), err)
+		return fmt.Errorf("error decrypting project prompt: %w", err)
+	}
+	project.Prompt = prompt
+	return nil
+}
+
+// decryptProjects applies decryptProject to every project in the slice.
+func decryptProjects(projects []db.ManimProject) error {
+	for i := range projects {
+		if err := decryptProject(&projects[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Output settings of projects created without them, matching the column defaults.
+const (
+	defaultResolution = "720p"
+	defaultFPS        = 30
+)
+
+// setDefaultOutputSettings fills in the resolution and frame rate of a project that has none.
+func setDefaultOutputSettings(project *db.ManimProject) {
+	if project.Resolution == "" {
+		project.Resolution = defaultResolution
+	}
+	if project.FPS == 0 {
+		project.FPS = defaultFPS
+	}
+}
+
 // CreateManimProject inserts a new Manim project into the database.
 // It now includes 'prompt', 'render_status', 'video_url', and 'parent_project_id' in the insert.
-func CreateManimProject(project *db.ManimProject) (*db.ManimProject, error) {
+func CreateManimProject(ctx context.Context, project *db.ManimProject) (*db.ManimProject, error) {
 	// Ensure default status if not set
 	if project.RenderStatus == "" {
 		project.RenderStatus = "pending"
 	}
+	setDefaultOutputSettings(project)
 
 	query := `
-        INSERT INTO manim_projects (user_id, name, description, prompt, render_status, video_url, parent_project_id)
-        VALUES (:user_id, :name, :description, :prompt, :render_status, :video_url, :parent_project_id)
-        RETURNING id, created_at, updated_at`
+        INSERT INTO manim_projects (user_id, name, description, prompt, render_status, video_url, parent_project_id, metadata, model, resolution, fps)
+        VALUES (:user_id, :name, :description, :prompt, :render_status, :video_url, :parent_project_id, :metadata, :model, :resolution, :fps)
+        RETURNING id, created_at, updated_at, auto_reconcile`
 
+	row, err := encryptedRow(project)
+	if err != nil {
+		return nil, err
+	}
 	// NamedQuery works well with struct tags if fields match column names.
 	// db.ManimProject already has sql.NullString for ParentProjectID, which sqlx handles correctly.
-	rows, err := db.DB.NamedQuery(query, project)
+	rows, err := db.Conn(ctx).NamedQuery(query, row)
 	if err != nil {
 		log.Errorf("Error creating Manim project: %v", err)
-		return nil, fmt.Errorf("failed to create project: %w", err)
+		return nil, fmt.Errorf("failed to create project: %w", db.TranslateError(err))
 	}
 	defer rows.Close()
 
@@ -50,13 +116,52 @@ func CreateManimProject(project *db.ManimProject) (*db.ManimProject, error) {
 	return project, nil
 }
 
+// CreateManimProjectWithID inserts a project using the client-supplied project.ID as its primary key.
+// If a project with that ID already exists nothing is written and nil, nil is returned, so a
+// retried create can look up and return the original.
+func CreateManimProjectWithID(ctx context.Context, project *db.ManimProject) (*db.ManimProject, error) {
+	if project.RenderStatus == "" {
+		project.RenderStatus = "pending"
+	}
+	setDefaultOutputSettings(project)
+
+	query := `
+        INSERT INTO manim_projects (id, user_id, name, description, prompt, render_status, video_url, parent_project_id, metadata, model, resolution, fps)
+        VALUES (:id, :user_id, :name, :description, :prompt, :render_status, :video_url, :parent_project_id, :metadata, :model, :resolution, :fps)
+        ON CONFLICT (id) DO NOTHING
+        RETURNING id, created_at, updated_at, auto_reconcile`
+
+	row, err := encryptedRow(project)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Conn(ctx).NamedQuery(query, row)
+	if err != nil {
+		log.Errorf("Error creating Manim project with ID '%s': %v", project.ID.String(), err)
+		return nil, fmt.Errorf("failed to create project: %w", db.TranslateError(err))
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		log.Debugf("Manim project with ID '%s' already exists; nothing inserted.", project.ID.String())
+		return nil, rows.Err()
+	}
+	if err := rows.StructScan(project); err != nil {
+		log.Errorf("Error scanning Manim project data after creation: %v", err)
+		return nil, fmt.Errorf("error scanning project after creation: %w", err)
+	}
+
+	log.Infof("Manim project '%s' created for user ID: %s (ID: %s)", project.Name, project.UserID.String(), project.ID.String())
+	return project, nil
+}
+
 // FindManimProjectByID retrieves a Manim project by its ID.
 // Includes new 'parent_project_id' field in the SELECT.
-func FindManimProjectByID(projectID uuid.UUID) (*db.ManimProject, error) {
+func FindManimProjectByID(ctx context.Context, projectID uuid.UUID) (*db.ManimProject, error) {
 	project := &db.ManimProject{}
 	// Added parent_project_id to the SELECT statement
-	query := `SELECT id, user_id, name, description, prompt, render_status, video_url, created_at, updated_at, parent_project_id FROM manim_projects WHERE id = $1`
-	err := db.DB.Get(project, query, projectID)
+	query := `SELECT ` + manimProjectColumns + ` FROM manim_projects WHERE id = $1 AND deleted_at IS NULL`
+	err := db.Conn(ctx).Get(project, query, projectID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Debugf("Manim project with ID '%s' not found.", projectID.String())
@@ -65,30 +170,208 @@ func FindManimProjectByID(projectID uuid.UUID) (*db.ManimProject, error) {
 		log.Errorf("Error finding Manim project by ID '%s': %v", projectID.String(), err)
 		return nil, fmt.Errorf("error finding project by ID: %w", err)
 	}
+	if err := decryptProject(project); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// FindDeletedManimProjectByID retrieves a soft-deleted project that hasn't been purged yet.
+// Returns nil, nil if there is none.
+func FindDeletedManimProjectByID(ctx context.Context, projectID uuid.UUID) (*db.ManimProject, error) {
+	project := &db.ManimProject{}
+	query := `SELECT ` + manimProjectColumns + ` FROM manim_projects WHERE id = $1 AND deleted_at IS NOT NULL`
+	err := db.Conn(ctx).Get(project, query, projectID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Errorf("Error finding deleted Manim project '%s': %v", projectID.String(), err)
+		return nil, fmt.Errorf("error finding deleted project: %w", err)
+	}
+	if err := decryptProject(project); err != nil {
+		return nil, err
+	}
 	return project, nil
 }
 
-// FindManimProjectsByUserID retrieves all Manim projects for a specific user ID.
+// FindManimProjectByIDForUpdate is FindManimProjectByID that also locks the row until the
+// transaction in ctx ends, serializing writers that derive the project from other rows.
+func FindManimProjectByIDForUpdate(ctx context.Context, projectID uuid.UUID) (*db.ManimProject, error) {
+	project := &db.ManimProject{}
+	query := `SELECT ` + manimProjectColumns + ` FROM manim_projects WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+	err := db.Conn(ctx).Get(project, query, projectID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Errorf("Error locking Manim project '%s': %v", projectID.String(), err)
+		return nil, fmt.Errorf("error locking project: %w", err)
+	}
+	if err := decryptProject(project); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// prefixedManimProjectColumns returns manimProjectColumns qualified with a table alias, for joins.
+func prefixedManimProjectColumns(alias string) string {
+	columns := strings.Split(manimProjectColumns, ",")
+	for i, column := range columns {
+		columns[i] = alias + "." + strings.TrimSpace(column)
+	}
+	return strings.Join(columns, ", ")
+}
+
+// ProjectFilter narrows project list queries. Zero-valued fields don't filter; soft-deleted
+// projects are always left out.
+type ProjectFilter struct {
+	Metadata      map[string]string // metadata->>key must equal value (compared as text)
+	Status        string            // Exact render_status; "failed" matches every failed* status
+	CreatedAfter  time.Time         // created_at >= CreatedAfter
+	CreatedBefore time.Time         // created_at < CreatedBefore
+}
+
+// conditions returns the SQL conditions for the filter, numbering placeholders after the
+// given args, along with the extended argument list.
+func (f ProjectFilter) conditions(args []interface{}) (string, []interface{}) {
+	return f.prefixedConditions("", args)
+}
+
+// prefixedConditions is conditions with columns qualified by a table alias, for joins.
+func (f ProjectFilter) prefixedConditions(alias string, args []interface{}) (string, []interface{}) {
+	prefix := ""
+	if alias != "" {
+		prefix = alias + "."
+	}
+	clauses := []string{prefix + "deleted_at IS NULL"}
+	for key, value := range f.Metadata {
+		args = append(args, key, value)
+		clauses = append(clauses, fmt.Sprintf("%smetadata ->> $%d = $%d", prefix, len(args)-1, len(args)))
+	}
+	if f.Status == "failed" {
+		clauses = append(clauses, prefix+"render_status LIKE 'failed%'")
+	} else if f.Status != "" {
+		args = append(args, f.Status)
+		clauses = append(clauses, fmt.Sprintf("%srender_status = $%d", prefix, len(args)))
+	}
+	if !f.CreatedAfter.IsZero() {
+		args = append(args, f.CreatedAfter)
+		clauses = append(clauses, fmt.Sprintf("%screated_at >= $%d", prefix, len(args)))
+	}
+	if !f.CreatedBefore.IsZero() {
+		args = append(args, f.CreatedBefore)
+		clauses = append(clauses, fmt.Sprintf("%screated_at < $%d", prefix, len(args)))
+	}
+	return " AND " + strings.Join(clauses, " AND "), args
+}
+
+// FindManimProjectsByUserID retrieves all Manim projects for a specific user ID, narrowed by filter.
 // Includes new 'parent_project_id' field in the SELECT.
-func FindManimProjectsByUserID(userID uuid.UUID) ([]db.ManimProject, error) {
+func FindManimProjectsByUserID(ctx context.Context, userID uuid.UUID, filter ProjectFilter) ([]db.ManimProject, error) {
 	var projects []db.ManimProject
-	// Added parent_project_id to the SELECT statement
-	query := `SELECT id, user_id, name, description, prompt, render_status, video_url, created_at, updated_at, parent_project_id FROM manim_projects WHERE user_id = $1 ORDER BY created_at DESC`
-	err := db.DB.Select(&projects, query, userID)
+	conditions, args := filter.conditions([]interface{}{userID})
+	query := `SELECT ` + manimProjectColumns + ` FROM manim_projects WHERE user_id = $1` + conditions + ` ORDER BY created_at DESC`
+	err := db.Conn(ctx).Select(&projects, query, args...)
 	if err != nil {
 		log.Errorf("Error finding Manim projects for user ID '%s': %v", userID.String(), err)
 		return nil, fmt.Errorf("error finding projects by user ID: %w", err)
 	}
+	if err := decryptProjects(projects); err != nil {
+		return nil, err
+	}
 	return projects, nil
 }
 
+// StreamManimProjectsByUserID iterates over a user's Manim projects matching filter one row at a time,
+// calling fn for each. Unlike FindManimProjectsByUserID it never loads the full set into memory,
+// which keeps large exports cheap. Iteration stops at the first error returned by fn.
+func StreamManimProjectsByUserID(ctx context.Context, userID uuid.UUID, filter ProjectFilter, fn func(*db.ManimProject) error) error {
+	conditions, args := filter.conditions([]interface{}{userID})
+	query := `SELECT ` + manimProjectColumns + ` FROM manim_projects WHERE user_id = $1` + conditions + ` ORDER BY created_at ASC`
+	rows, err := db.Conn(ctx).Queryx(query, args...)
+	if err != nil {
+		log.Errorf("Error streaming Manim projects for user ID '%s': %v", userID.String(), err)
+		return fmt.Errorf("error streaming projects by user ID: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var project db.ManimProject
+		if err := rows.StructScan(&project); err != nil {
+			log.Errorf("Error scanning Manim project while streaming for user ID '%s': %v", userID.String(), err)
+			return fmt.Errorf("error scanning streamed project: %w", err)
+		}
+		if err := decryptProject(&project); err != nil {
+			return err
+		}
+		if err := fn(&project); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// StreamManimProjectsWithCodeByUserID is StreamManimProjectsByUserID for every live project of the
+// user, additionally passing fn the decrypted Manim code last generated for it ("" if none).
+func StreamManimProjectsWithCodeByUserID(ctx context.Context, userID uuid.UUID, fn func(*db.ManimProject, string) error) error {
+	query := `SELECT ` + manimProjectColumns + `, manim_code FROM manim_projects WHERE user_id = $1 AND deleted_at IS NULL ORDER BY created_at ASC`
+	rows, err := db.Conn(ctx).Queryx(query, userID)
+	if err != nil {
+		log.Errorf("Error streaming Manim projects with code for user ID '%s': %v", userID.String(), err)
+		return fmt.Errorf("error streaming projects with code by user ID: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row struct {
+			db.ManimProject
+			ManimCode sql.NullString `db:"manim_code"`
+		}
+		if err := rows.StructScan(&row); err != nil {
+			log.Errorf("Error scanning Manim project with code while streaming for user ID '%s': %v", userID.String(), err)
+			return fmt.Errorf("error scanning streamed project: %w", err)
+		}
+		if err := decryptProject(&row.ManimProject); err != nil {
+			return err
+		}
+		code, err := db.DecryptField(row.ManimCode.String)
+		if err != nil {
+			log.Errorf("Error decrypting Manim code of project '%s': %v", row.ID.String(), err)
+			return fmt.Errorf("error decrypting project code: %w", err)
+		}
+		if err := fn(&row.ManimProject, code); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ProjectSetVersion summarizes a user's project set; it changes whenever a project is created,
+// updated or deleted.
+type ProjectSetVersion struct {
+	Count       int          `db:"count"`
+	LastUpdated sql.NullTime `db:"last_updated"` // NULL when the user has no projects
+}
+
+// FindProjectSetVersion returns the count and latest updated_at of the user's projects.
+func FindProjectSetVersion(ctx context.Context, userID uuid.UUID) (*ProjectSetVersion, error) {
+	version := &ProjectSetVersion{}
+	query := `SELECT COUNT(*) AS count, MAX(updated_at) AS last_updated FROM manim_projects WHERE user_id = $1 AND deleted_at IS NULL`
+	if err := db.Conn(ctx).Get(version, query, userID); err != nil {
+		log.Errorf("Error computing project set version for user '%s': %v", userID.String(), err)
+		return nil, fmt.Errorf("error computing project set version: %w", err)
+	}
+	return version, nil
+}
+
 // FindManimProjectByNameAndUserID retrieves a Manim project by its name and user ID.
 // Includes new 'parent_project_id' field in the SELECT.
-func FindManimProjectByNameAndUserID(name string, userID uuid.UUID) (*db.ManimProject, error) {
+func FindManimProjectByNameAndUserID(ctx context.Context, name string, userID uuid.UUID) (*db.ManimProject, error) {
 	project := &db.ManimProject{}
 	// Added parent_project_id to the SELECT statement
-	query := `SELECT id, user_id, name, description, prompt, render_status, video_url, created_at, updated_at, parent_project_id FROM manim_projects WHERE name = $1 AND user_id = $2`
-	err := db.DB.Get(project, query, name, userID)
+	query := `SELECT ` + manimProjectColumns + ` FROM manim_projects WHERE name = $1 AND user_id = $2 AND deleted_at IS NULL`
+	err := db.Conn(ctx).Get(project, query, name, userID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Debugf("Manim project with name '%s' not found for user ID '%s'.", name, userID.String())
@@ -97,38 +380,70 @@ func FindManimProjectByNameAndUserID(name string, userID uuid.UUID) (*db.ManimPr
 		log.Errorf("Error finding Manim project by name '%s' for user ID '%s': %v", name, userID.String(), err)
 		return nil, fmt.Errorf("error finding project by name and user ID: %w", err)
 	}
+	if err := decryptProject(project); err != nil {
+		return nil, err
+	}
 	return project, nil
 }
 
+// FindManimProjectsByIDsAndUserID retrieves the given projects that belong to userID in one query,
+// newest first. IDs that don't exist or belong to someone else are simply absent from the result.
+func FindManimProjectsByIDsAndUserID(ctx context.Context, projectIDs []uuid.UUID, userID uuid.UUID) ([]db.ManimProject, error) {
+	var projects []db.ManimProject
+	ids := make([]string, len(projectIDs))
+	for i, id := range projectIDs {
+		ids[i] = id.String()
+	}
+	query := `SELECT ` + manimProjectColumns + ` FROM manim_projects WHERE id = ANY($1::uuid[]) AND user_id = $2 AND deleted_at IS NULL ORDER BY created_at DESC`
+	err := db.Conn(ctx).Select(&projects, query, pq.Array(ids), userID)
+	if err != nil {
+		log.Errorf("Error finding %d Manim projects for user ID '%s': %v", len(projectIDs), userID.String(), err)
+		return nil, fmt.Errorf("error finding projects by IDs: %w", err)
+	}
+	if err := decryptProjects(projects); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
 // FindManimProjectsByParentID retrieves all sub-projects for a given parent project ID.
 // This is a new function to support decomposed complex animations.
-func FindManimProjectsByParentID(parentProjectID uuid.UUID) ([]db.ManimProject, error) {
+func FindManimProjectsByParentID(ctx context.Context, parentProjectID uuid.UUID) ([]db.ManimProject, error) {
 	var projects []db.ManimProject
 	// Select all fields including parent_project_id, filtered by the parent_project_id column.
-	query := `SELECT id, user_id, name, description, prompt, render_status, video_url, created_at, updated_at, parent_project_id FROM manim_projects WHERE parent_project_id = $1 ORDER BY created_at ASC`
-	err := db.DB.Select(&projects, query, parentProjectID)
+	query := `SELECT ` + manimProjectColumns + ` FROM manim_projects WHERE parent_project_id = $1 AND deleted_at IS NULL ORDER BY created_at ASC`
+	err := db.Conn(ctx).Select(&projects, query, parentProjectID)
 	if err != nil {
 		log.Errorf("Error finding sub-projects for parent ID '%s': %v", parentProjectID.String(), err)
 		return nil, fmt.Errorf("error finding sub-projects by parent ID: %w", err)
 	}
+	if err := decryptProjects(projects); err != nil {
+		return nil, err
+	}
 	return projects, nil
 }
 
 // UpdateManimProject updates an existing Manim project in the database.
 // Includes new 'parent_project_id' field in the UPDATE, allowing it to be changed (though rare for existing projects).
-func UpdateManimProject(project *db.ManimProject) error {
+func UpdateManimProject(ctx context.Context, project *db.ManimProject) error {
 	project.UpdatedAt = time.Now().UTC() // Ensure updated_at is refreshed
 
 	query := `
         UPDATE manim_projects
         SET name = :name, description = :description, prompt = :prompt, render_status = :render_status,
-            video_url = :video_url, updated_at = :updated_at, parent_project_id = :parent_project_id
-        WHERE id = :id AND user_id = :user_id` // Keep user_id in WHERE for security/ownership
+            video_url = :video_url, updated_at = :updated_at, parent_project_id = :parent_project_id,
+            last_render_started_at = :last_render_started_at, metadata = :metadata, thumbnail_url = :thumbnail_url,
+            resolution = :resolution, fps = :fps
+        WHERE id = :id AND user_id = :user_id` // Keep user_id in WHERE for security/ownership; deleted projects still take render results
 
-	result, err := db.DB.NamedExec(query, project)
+	row, err := encryptedRow(project)
+	if err != nil {
+		return err
+	}
+	result, err := db.Conn(ctx).NamedExec(query, row)
 	if err != nil {
 		log.Errorf("Error updating Manim project with ID '%s': %v", project.ID.String(), err)
-		return fmt.Errorf("failed to update project: %w", err)
+		return fmt.Errorf("failed to update project: %w", db.TranslateError(err))
 	}
 
 	rowsAffected, _ := result.RowsAffected()
@@ -141,21 +456,227 @@ func UpdateManimProject(project *db.ManimProject) error {
 	return nil
 }
 
-// DeleteManimProject (no changes needed here as it deletes by ID and user_id, unaffected by parent_project_id)
-func DeleteManimProject(projectID, userID uuid.UUID) error {
-	query := `DELETE FROM manim_projects WHERE id = $1 AND user_id = $2`
-	result, err := db.DB.Exec(query, projectID, userID)
+// SetManimProjectCode stores the script last sent to the renderer for the project, encrypted like
+// the prompt. It's kept out of manimProjectColumns so project reads don't carry whole scripts.
+func SetManimProjectCode(ctx context.Context, projectID uuid.UUID, code string) error {
+	stored, err := db.EncryptField(code)
+	if err != nil {
+		return fmt.Errorf("error encrypting project code: %w", err)
+	}
+	if _, err := db.Conn(ctx).Exec(`UPDATE manim_projects SET manim_code = $1 WHERE id = $2`, stored, projectID); err != nil {
+		log.Errorf("Error storing Manim code of project '%s': %v", projectID.String(), err)
+		return fmt.Errorf("error storing project code: %w", db.TranslateError(err))
+	}
+	return nil
+}
+
+// FindManimProjectCode returns the script last sent to the renderer for the project, or "" if
+// none was stored.
+func FindManimProjectCode(ctx context.Context, projectID uuid.UUID) (string, error) {
+	var stored sql.NullString
+	if err := db.Conn(ctx).Get(&stored, `SELECT manim_code FROM manim_projects WHERE id = $1`, projectID); err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		log.Errorf("Error finding Manim code of project '%s': %v", projectID.String(), err)
+		return "", fmt.Errorf("error finding project code: %w", err)
+	}
+	code, err := db.DecryptField(stored.String)
+	if err != nil {
+		return "", fmt.Errorf("error decrypting project code: %w", err)
+	}
+	return code, nil
+}
+
+// FindFailedManimProjectsByUserID retrieves the user's projects whose last render failed
+// (any render_status starting with "failed"), oldest first.
+func FindFailedManimProjectsByUserID(ctx context.Context, userID uuid.UUID) ([]db.ManimProject, error) {
+	var projects []db.ManimProject
+	query := `SELECT ` + manimProjectColumns + ` FROM manim_projects WHERE user_id = $1 AND render_status LIKE 'failed%' AND deleted_at IS NULL ORDER BY created_at ASC`
+	err := db.Conn(ctx).Select(&projects, query, userID)
+	if err != nil {
+		log.Errorf("Error finding failed Manim projects for user ID '%s': %v", userID.String(), err)
+		return nil, fmt.Errorf("error finding failed projects by user ID: %w", err)
+	}
+	if err := decryptProjects(projects); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// SetManimProjectThumbnailURL replaces only the thumbnail of a project, leaving the rest of the
+// row untouched so it can't clobber a render callback processed at the same time.
+func SetManimProjectThumbnailURL(ctx context.Context, projectID uuid.UUID, thumbnailURL string) error {
+	query := `UPDATE manim_projects SET thumbnail_url = $1, updated_at = $2 WHERE id = $3 AND deleted_at IS NULL`
+	result, err := db.Conn(ctx).Exec(query, thumbnailURL, time.Now().UTC(), projectID)
+	if err != nil {
+		log.Errorf("Error setting thumbnail for Manim project '%s': %v", projectID.String(), err)
+		return fmt.Errorf("error setting project thumbnail: %w", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetManimProjectAutoReconcile sets whether the stuck-render reconciler may fail the project.
+// Returns sql.ErrNoRows if the project doesn't exist or isn't owned by the user.
+func SetManimProjectAutoReconcile(ctx context.Context, projectID, userID uuid.UUID, autoReconcile bool) error {
+	query := `UPDATE manim_projects SET auto_reconcile = $1, updated_at = $2 WHERE id = $3 AND user_id = $4 AND deleted_at IS NULL`
+	result, err := db.Conn(ctx).Exec(query, autoReconcile, time.Now().UTC(), projectID, userID)
+	if err != nil {
+		log.Errorf("Error setting auto_reconcile for Manim project '%s': %v", projectID.String(), err)
+		return fmt.Errorf("error setting project auto_reconcile: %w", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetManimProjectNotes replaces the notes of the user's project.
+// Returns sql.ErrNoRows if the project doesn't exist or isn't owned by the user.
+func SetManimProjectNotes(ctx context.Context, projectID, userID uuid.UUID, notes string) error {
+	query := `UPDATE manim_projects SET notes = $1, updated_at = $2 WHERE id = $3 AND user_id = $4 AND deleted_at IS NULL`
+	result, err := db.Conn(ctx).Exec(query, notes, time.Now().UTC(), projectID, userID)
+	if err != nil {
+		log.Errorf("Error setting notes for Manim project '%s': %v", projectID.String(), err)
+		return fmt.Errorf("error setting project notes: %w", db.TranslateError(err))
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetManimProjectLocked locks or unlocks the user's project.
+// Returns sql.ErrNoRows if the project doesn't exist or isn't owned by the user.
+func SetManimProjectLocked(ctx context.Context, projectID, userID uuid.UUID, locked bool) error {
+	query := `UPDATE manim_projects SET locked = $1, updated_at = $2 WHERE id = $3 AND user_id = $4 AND deleted_at IS NULL`
+	result, err := db.Conn(ctx).Exec(query, locked, time.Now().UTC(), projectID, userID)
+	if err != nil {
+		log.Errorf("Error setting locked for Manim project '%s': %v", projectID.String(), err)
+		return fmt.Errorf("error setting project locked: %w", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// TransferManimProject moves an unlocked project and its sub-projects from one user to another and
+// returns how many projects were moved. Unlike UpdateManimProject it changes user_id itself.
+// Returns sql.ErrNoRows if fromUserID doesn't own an unlocked project with that ID, and
+// db.ErrDuplicate if the recipient already has a project with one of the moved names.
+func TransferManimProject(ctx context.Context, projectID, fromUserID, toUserID uuid.UUID) (int64, error) {
+	query := `
+        UPDATE manim_projects SET user_id = $1, updated_at = $2
+        WHERE user_id = $3 AND (parent_project_id = $4 OR id = $4) AND deleted_at IS NULL
+          AND EXISTS (SELECT 1 FROM manim_projects WHERE id = $4 AND user_id = $3 AND NOT locked AND deleted_at IS NULL)`
+	result, err := db.Conn(ctx).Exec(query, toUserID, time.Now().UTC(), fromUserID, projectID)
+	if err != nil {
+		log.Errorf("Error transferring Manim project '%s' to user '%s': %v", projectID.String(), toUserID.String(), err)
+		return 0, fmt.Errorf("error transferring project: %w", db.TranslateError(err))
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return 0, sql.ErrNoRows
+	}
+	return rowsAffected, nil
+}
+
+// inFlightRenderStatuses are the render statuses of a project whose render has started but not
+// finished: queued by a re-render ("pending"), generating code, or waiting on the renderer.
+var inFlightRenderStatuses = []string{"pending", "generating", "rendering"}
+
+// FailStuckManimProjects marks projects whose render has been in flight since before startedBefore as
+// "failed: render_timeout", skipping projects with auto_reconcile disabled. Projects that were never
+// rendered have no last_render_started_at and are left alone. Returns the failed project IDs.
+func FailStuckManimProjects(ctx context.Context, startedBefore time.Time) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	query := `
+        UPDATE manim_projects
+        SET render_status = 'failed: render_timeout', updated_at = $1
+        WHERE render_status = ANY($3) AND auto_reconcile AND last_render_started_at < $2 AND deleted_at IS NULL
+        RETURNING id`
+	if err := db.Conn(ctx).Select(&ids, query, time.Now().UTC(), startedBefore, pq.Array(inFlightRenderStatuses)); err != nil {
+		log.Errorf("Error failing stuck Manim projects: %v", err)
+		return nil, fmt.Errorf("error failing stuck projects: %w", err)
+	}
+	return ids, nil
+}
+
+// DeleteManimProject soft-deletes the user's project: it disappears from every find query but can
+// be restored with RestoreManimProject until PurgeDeletedManimProjects removes it. Locked projects
+// are kept and ErrProjectLocked is returned.
+func DeleteManimProject(ctx context.Context, projectID, userID uuid.UUID) error {
+	query := `
+        UPDATE manim_projects SET deleted_at = $1, updated_at = $1
+        WHERE id = $2 AND user_id = $3 AND NOT locked AND deleted_at IS NULL`
+	result, err := db.Conn(ctx).Exec(query, time.Now().UTC(), projectID, userID)
 	if err != nil {
 		log.Errorf("Error deleting Manim project with ID '%s' for user ID '%s': %v", projectID.String(), userID.String(), err)
-		return err
+		return db.TranslateError(err)
 	}
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
+		var locked bool
+		err := db.Conn(ctx).Get(&locked, `SELECT locked FROM manim_projects WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`, projectID, userID)
+		if err == nil && locked {
+			return ErrProjectLocked
+		}
 		log.Warnf("No Manim project found with ID '%s' for user ID '%s' for deletion.", projectID.String(), userID.String())
 		return sql.ErrNoRows
 	}
 
 	log.Infof("Manim project with ID '%s' deleted.", projectID.String())
 	return nil
-}
\ No newline at end of file
+}
+
+// RestoreManimProject undoes the soft delete of the user's project if it was deleted at or after
+// deletedSince, and returns the restored project. Returns sql.ErrNoRows if there is no such
+// project, and db.ErrDuplicate if the user has since created another project with its name.
+func RestoreManimProject(ctx context.Context, projectID, userID uuid.UUID, deletedSince time.Time) (*db.ManimProject, error) {
+	project := &db.ManimProject{}
+	query := `
+        UPDATE manim_projects SET deleted_at = NULL, updated_at = $1
+        WHERE id = $2 AND user_id = $3 AND deleted_at >= $4
+        RETURNING ` + manimProjectColumns
+	if err := db.Conn(ctx).Get(project, query, time.Now().UTC(), projectID, userID, deletedSince); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		log.Errorf("Error restoring Manim project '%s' for user ID '%s': %v", projectID.String(), userID.String(), err)
+		return nil, fmt.Errorf("error restoring project: %w", db.TranslateError(err))
+	}
+	if err := decryptProject(project); err != nil {
+		return nil, err
+	}
+	log.Infof("Manim project with ID '%s' restored.", projectID.String())
+	return project, nil
+}
+
+// PurgedManimProject is a project removed by PurgeDeletedManimProjects, with the stored files it
+// referenced.
+type PurgedManimProject struct {
+	ID           uuid.UUID      `db:"id"`
+	VideoURL     sql.NullString `db:"video_url"`
+	ThumbnailURL sql.NullString `db:"thumbnail_url"`
+}
+
+// PurgeDeletedManimProjects permanently removes projects soft-deleted before deletedBefore, along
+// with their render jobs, assets and merge source records, and returns the removed projects.
+func PurgeDeletedManimProjects(ctx context.Context, deletedBefore time.Time) ([]PurgedManimProject, error) {
+	var purged []PurgedManimProject
+	query := `DELETE FROM manim_projects WHERE deleted_at < $1 RETURNING id, video_url, thumbnail_url`
+	if err := db.Conn(ctx).Select(&purged, query, deletedBefore); err != nil {
+		log.Errorf("Error purging deleted Manim projects: %v", err)
+		return nil, fmt.Errorf("error purging deleted projects: %w", err)
+	}
+	return purged, nil
+}
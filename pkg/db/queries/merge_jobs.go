@@ -0,0 +1,76 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// Merge job statuses.
+const (
+	MergeJobRunning   = "running"
+	MergeJobCompleted = "completed"
+	MergeJobFailed    = "failed"
+)
+
+const mergeJobColumns = `id, inputs, status, error, merged_video_id, attempts, created_at, updated_at`
+
+// CreateMergeJob records a merge about to be submitted to the renderer with the given payload,
+// producing the given merged video.
+func CreateMergeJob(ctx context.Context, inputs db.JSONB, mergedVideoID uuid.UUID) (*db.MergeJob, error) {
+	job := &db.MergeJob{}
+	query := `INSERT INTO merge_jobs (inputs, status, merged_video_id) VALUES ($1, $2, $3) RETURNING ` + mergeJobColumns
+	if err := db.Conn(ctx).Get(job, query, inputs, MergeJobRunning, mergedVideoID); err != nil {
+		log.Errorf("Error creating merge job: %v", err)
+		return nil, fmt.Errorf("error creating merge job: %w", db.TranslateError(err))
+	}
+	return job, nil
+}
+
+// FindMergeJobByID retrieves a merge job. Returns nil, nil if it doesn't exist.
+func FindMergeJobByID(ctx context.Context, id uuid.UUID) (*db.MergeJob, error) {
+	job := &db.MergeJob{}
+	if err := db.Conn(ctx).Get(job, `SELECT `+mergeJobColumns+` FROM merge_jobs WHERE id = $1`, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Errorf("Error finding merge job '%s': %v", id.String(), err)
+		return nil, fmt.Errorf("error finding merge job: %w", err)
+	}
+	return job, nil
+}
+
+// RestartMergeJob moves a failed merge job back to running for a retry producing the given merged
+// video, and counts the attempt. Returns sql.ErrNoRows if the job doesn't exist or isn't failed,
+// so concurrent retries can't both resubmit it.
+func RestartMergeJob(ctx context.Context, id, mergedVideoID uuid.UUID) error {
+	query := `
+        UPDATE merge_jobs SET status = $1, error = NULL, attempts = attempts + 1, merged_video_id = $2, updated_at = $3
+        WHERE id = $4 AND status = $5`
+	result, err := db.Conn(ctx).Exec(query, MergeJobRunning, mergedVideoID, time.Now().UTC(), id, MergeJobFailed)
+	if err != nil {
+		log.Errorf("Error restarting merge job '%s': %v", id.String(), err)
+		return fmt.Errorf("error restarting merge job: %w", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// FinishMergeJobs records the outcome of a merge on the running job producing the merged video.
+func FinishMergeJobs(ctx context.Context, mergedVideoID uuid.UUID, status, errorMessage string) error {
+	query := `UPDATE merge_jobs SET status = $1, error = $2, updated_at = $3 WHERE merged_video_id = $4 AND status = $5`
+	errorValue := sql.NullString{String: errorMessage, Valid: errorMessage != ""}
+	if _, err := db.Conn(ctx).Exec(query, status, errorValue, time.Now().UTC(), mergedVideoID, MergeJobRunning); err != nil {
+		log.Errorf("Error finishing merge jobs of merged video '%s': %v", mergedVideoID.String(), err)
+		return fmt.Errorf("error finishing merge job: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// Merged video statuses.
+const (
+	MergedVideoMerging   = "merging"
+	MergedVideoCompleted = "completed"
+	MergedVideoFailed    = "failed"
+)
+
+const mergedVideoColumns = `id, user_id, r2_url, status, error, created_at`
+
+// MergedVideoSource is a source project of a merged video, in merge order.
+type MergedVideoSource struct {
+	Position int `db:"position"`
+	db.ManimProject
+}
+
+// ReplaceMergedVideoSources records the source projects of a merged video in order,
+// replacing any previously recorded sources.
+func ReplaceMergedVideoSources(ctx context.Context, mergedVideoID uuid.UUID, projectIDs []uuid.UUID) error {
+	conn := db.Conn(ctx)
+	if _, err := conn.Exec(`DELETE FROM merged_video_sources WHERE merged_video_id = $1`, mergedVideoID); err != nil {
+		log.Errorf("Error clearing sources of merged video '%s': %v", mergedVideoID.String(), err)
+		return fmt.Errorf("error clearing merged video sources: %w", db.TranslateError(err))
+	}
+	for position, projectID := range projectIDs {
+		_, err := conn.Exec(`INSERT INTO merged_video_sources (merged_video_id, project_id, position) VALUES ($1, $2, $3)`,
+			mergedVideoID, projectID, position)
+		if err != nil {
+			log.Errorf("Error recording source %s of merged video '%s': %v", projectID.String(), mergedVideoID.String(), err)
+			return fmt.Errorf("error recording merged video source: %w", db.TranslateError(err))
+		}
+	}
+	return nil
+}
+
+// FindMergedVideoByID retrieves a merged video. Returns nil, nil if it doesn't exist.
+func FindMergedVideoByID(ctx context.Context, id uuid.UUID) (*db.MergedVideo, error) {
+	merged := &db.MergedVideo{}
+	err := db.Conn(ctx).Get(merged, `SELECT `+mergedVideoColumns+` FROM merged_videos WHERE id = $1`, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Errorf("Error finding merged video '%s': %v", id.String(), err)
+		return nil, fmt.Errorf("error finding merged video: %w", err)
+	}
+	return merged, nil
+}
+
+// FindMergedVideosByUser lists the user's merged videos, newest first.
+func FindMergedVideosByUser(ctx context.Context, userID uuid.UUID) ([]db.MergedVideo, error) {
+	var merged []db.MergedVideo
+	query := `SELECT ` + mergedVideoColumns + ` FROM merged_videos WHERE user_id = $1 ORDER BY created_at DESC`
+	if err := db.Conn(ctx).Select(&merged, query, userID); err != nil {
+		log.Errorf("Error finding merged videos for user '%s': %v", userID.String(), err)
+		return nil, fmt.Errorf("error finding merged videos by user: %w", err)
+	}
+	return merged, nil
+}
+
+// StreamMergedVideosByUser iterates over the user's merged videos oldest first, one row at a time.
+// Iteration stops at the first error returned by fn.
+func StreamMergedVideosByUser(ctx context.Context, userID uuid.UUID, fn func(*db.MergedVideo) error) error {
+	query := `SELECT ` + mergedVideoColumns + ` FROM merged_videos WHERE user_id = $1 ORDER BY created_at ASC`
+	rows, err := db.Conn(ctx).Queryx(query, userID)
+	if err != nil {
+		log.Errorf("Error streaming merged videos for user '%s': %v", userID.String(), err)
+		return fmt.Errorf("error streaming merged videos by user: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var merged db.MergedVideo
+		if err := rows.StructScan(&merged); err != nil {
+			log.Errorf("Error scanning merged video while streaming for user '%s': %v", userID.String(), err)
+			return fmt.Errorf("error scanning streamed merged video: %w", err)
+		}
+		if err := fn(&merged); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// FindMergedVideosByProjectID lists the merged videos that include the project as a source.
+func FindMergedVideosByProjectID(ctx context.Context, projectID uuid.UUID) ([]db.MergedVideo, error) {
+	var merged []db.MergedVideo
+	query := `
+        SELECT DISTINCT mv.id, mv.user_id, mv.r2_url, mv.status, mv.error, mv.created_at
+        FROM merged_videos mv
+        JOIN merged_video_sources s ON s.merged_video_id = mv.id
+        WHERE s.project_id = $1`
+	if err := db.Conn(ctx).Select(&merged, query, projectID); err != nil {
+		log.Errorf("Error finding merged videos for project '%s': %v", projectID.String(), err)
+		return nil, fmt.Errorf("error finding merged videos by project: %w", err)
+	}
+	return merged, nil
+}
+
+// FindMergedVideoSources lists the source projects of a merged video in merge order.
+func FindMergedVideoSources(ctx context.Context, mergedVideoID uuid.UUID) ([]MergedVideoSource, error) {
+	var sources []MergedVideoSource
+	query := `
+        SELECT s.position, ` + prefixedManimProjectColumns("p") + `
+        FROM merged_video_sources s
+        JOIN manim_projects p ON p.id = s.project_id
+        WHERE s.merged_video_id = $1
+        ORDER BY s.position`
+	if err := db.Conn(ctx).Select(&sources, query, mergedVideoID); err != nil {
+		log.Errorf("Error finding sources of merged video '%s': %v", mergedVideoID.String(), err)
+		return nil, fmt.Errorf("error finding merged video sources: %w", err)
+	}
+	for i := range sources {
+		if err := decryptProject(&sources[i].ManimProject); err != nil {
+			return nil, err
+		}
+	}
+	return sources, nil
+}
+
+// CreateMergedVideo records a merged video owned by userID as merging, or puts a failed one back
+// to merging for a retry, keeping its original owner. The write is idempotent, so it is retried
+// once on a transient connection error.
+func CreateMergedVideo(ctx context.Context, id uuid.UUID, userID uuid.NullUUID) error {
+	query := `
+        INSERT INTO merged_videos (id, user_id, r2_url, status) VALUES ($1, $2, '', $3)
+        ON CONFLICT (id) DO UPDATE SET status = EXCLUDED.status, error = NULL,
+            user_id = COALESCE(merged_videos.user_id, EXCLUDED.user_id)`
+	err := db.RetryTransient(ctx, "CreateMergedVideo", func() error {
+		_, err := db.Conn(ctx).Exec(query, id, userID, MergedVideoMerging)
+		return err
+	})
+	if err != nil {
+		log.Errorf("Error creating merged video '%s': %v", id.String(), err)
+		return fmt.Errorf("error creating merged video: %w", db.TranslateError(err))
+	}
+	return nil
+}
+
+// FinishMergedVideo records the outcome of a merge: the video URL when completed, the reason when
+// failed. Returns sql.ErrNoRows if the merged video doesn't exist or isn't merging, so a repeated
+// callback can't overwrite the outcome.
+func FinishMergedVideo(ctx context.Context, id uuid.UUID, status, r2URL, errorMessage string) error {
+	query := `
+        UPDATE merged_videos SET status = $1, r2_url = CASE WHEN $2 = '' THEN r2_url ELSE $2 END, error = $3
+        WHERE id = $4 AND status = $5`
+	errorValue := sql.NullString{String: errorMessage, Valid: errorMessage != ""}
+	result, err := db.Conn(ctx).Exec(query, status, r2URL, errorValue, id, MergedVideoMerging)
+	if err != nil {
+		log.Errorf("Error finishing merged video '%s': %v", id.String(), err)
+		return fmt.Errorf("error finishing merged video: %w", db.TranslateError(err))
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
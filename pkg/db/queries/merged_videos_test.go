@@ -0,0 +1,136 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// fakeExecDriver is a database connector that records executed statements and fails the
+// first ones with the queued errors.
+type fakeExecDriver struct {
+	execs []fakeExec
+	errs  []error
+}
+
+type fakeExec struct {
+	query string
+	args  []driver.NamedValue
+}
+
+func (d *fakeExecDriver) Connect(context.Context) (driver.Conn, error) {
+	return &fakeExecConn{d: d}, nil
+}
+func (d *fakeExecDriver) Driver() driver.Driver { return nil }
+
+type fakeExecConn struct{ d *fakeExecDriver }
+
+func (c *fakeExecConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *fakeExecConn) Close() error                        { return nil }
+func (c *fakeExecConn) Begin() (driver.Tx, error)           { return fakeExecTx{}, nil }
+
+func (c *fakeExecConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.d.execs = append(c.d.execs, fakeExec{query: query, args: args})
+	if len(c.d.errs) > 0 {
+		err := c.d.errs[0]
+		c.d.errs = c.d.errs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type fakeExecTx struct{}
+
+func (fakeExecTx) Commit() error   { return nil }
+func (fakeExecTx) Rollback() error { return nil }
+
+// withFakeExecDB points db.DB at d for the duration of the test.
+func withFakeExecDB(t *testing.T, d *fakeExecDriver) {
+	t.Helper()
+	previous := db.DB
+	db.DB = sqlx.NewDb(sql.OpenDB(d), "postgres")
+	t.Cleanup(func() {
+		db.DB.Close()
+		db.DB = previous
+	})
+}
+
+func TestCreateMergedVideoReinsertsSameID(t *testing.T) {
+	d := &fakeExecDriver{}
+	withFakeExecDB(t, d)
+	id := uuid.New()
+	owner := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+
+	// The first call creates the row; a retry of a failed merge re-inserts the same ID
+	for i := 0; i < 2; i++ {
+		if err := CreateMergedVideo(context.Background(), id, owner); err != nil {
+			t.Fatalf("CreateMergedVideo call %d: %v", i+1, err)
+		}
+	}
+	if len(d.execs) != 2 {
+		t.Fatalf("executed %d statements, want 2", len(d.execs))
+	}
+	for _, exec := range d.execs {
+		if !strings.Contains(exec.query, "ON CONFLICT (id) DO UPDATE") {
+			t.Errorf("query %q does not upsert on id", exec.query)
+		}
+		if exec.args[0].Value != id.String() || exec.args[2].Value != MergedVideoMerging {
+			t.Errorf("args = %v, want id %s and status %q", exec.args, id, MergedVideoMerging)
+		}
+	}
+}
+
+func TestCreateMergedVideoRetry(t *testing.T) {
+	errConnLost := &pq.Error{Code: "08006"} // connection_failure
+	errUnique := &pq.Error{Code: "23505"}   // unique_violation
+
+	tests := []struct {
+		name      string
+		errs      []error
+		inTx      bool
+		wantExecs int
+		wantErr   error
+	}{
+		{"success", nil, false, 1, nil},
+		{"transient error retried", []error{errConnLost}, false, 2, nil},
+		{"transient error twice", []error{errConnLost, errConnLost}, false, 2, errConnLost},
+		{"query error not retried", []error{errUnique}, false, 1, db.ErrDuplicate},
+		{"no retry inside a transaction", []error{errConnLost}, true, 1, errConnLost},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &fakeExecDriver{errs: tt.errs}
+			withFakeExecDB(t, d)
+			ctx := context.Background()
+			if tt.inTx {
+				tx, err := db.DB.Beginx()
+				if err != nil {
+					t.Fatalf("Beginx: %v", err)
+				}
+				defer tx.Rollback()
+				ctx = db.WithTx(ctx, tx)
+			}
+
+			err := CreateMergedVideo(ctx, uuid.New(), uuid.NullUUID{})
+			if len(d.execs) != tt.wantExecs {
+				t.Errorf("executed %d statements, want %d", len(d.execs), tt.wantExecs)
+			}
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("CreateMergedVideo error = %v, want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateMergedVideo error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
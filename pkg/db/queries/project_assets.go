@@ -0,0 +1,42 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const projectAssetColumns = `id, project_id, filename, object_key, content_type, size_bytes, created_at`
+
+// UpsertProjectAsset records an uploaded asset. Uploading a file with the same name again replaces
+// the previous record, matching the overwritten object in storage.
+func UpsertProjectAsset(ctx context.Context, asset *db.ProjectAsset) (*db.ProjectAsset, error) {
+	saved := &db.ProjectAsset{}
+	query := `
+        INSERT INTO project_assets (project_id, filename, object_key, content_type, size_bytes)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (project_id, filename) DO UPDATE
+            SET object_key = EXCLUDED.object_key, content_type = EXCLUDED.content_type,
+                size_bytes = EXCLUDED.size_bytes, created_at = CURRENT_TIMESTAMP
+        RETURNING ` + projectAssetColumns
+	err := db.Conn(ctx).Get(saved, query, asset.ProjectID, asset.Filename, asset.ObjectKey, asset.ContentType, asset.SizeBytes)
+	if err != nil {
+		log.Errorf("Error saving asset '%s' for project '%s': %v", asset.Filename, asset.ProjectID.String(), err)
+		return nil, fmt.Errorf("error saving project asset: %w", db.TranslateError(err))
+	}
+	return saved, nil
+}
+
+// FindProjectAssets returns the project's assets ordered by filename.
+func FindProjectAssets(ctx context.Context, projectID uuid.UUID) ([]db.ProjectAsset, error) {
+	var assets []db.ProjectAsset
+	query := `SELECT ` + projectAssetColumns + ` FROM project_assets WHERE project_id = $1 ORDER BY filename`
+	if err := db.Conn(ctx).Select(&assets, query, projectID); err != nil {
+		log.Errorf("Error finding assets for project '%s': %v", projectID.String(), err)
+		return nil, fmt.Errorf("error finding project assets: %w", err)
+	}
+	return assets, nil
+}
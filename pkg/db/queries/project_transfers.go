@@ -0,0 +1,57 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// TransferredProject is a project received through a transfer, with who sent it and when.
+type TransferredProject struct {
+	FromUserID    uuid.NullUUID  `db:"from_user_id"`
+	FromUserEmail sql.NullString `db:"from_user_email"`
+	TransferredAt time.Time      `db:"transferred_at"`
+	db.ManimProject
+}
+
+// RecordProjectTransfer records that a project was transferred between users.
+func RecordProjectTransfer(ctx context.Context, projectID, fromUserID, toUserID uuid.UUID) error {
+	query := `INSERT INTO project_transfers (project_id, from_user_id, to_user_id) VALUES ($1, $2, $3)`
+	if _, err := db.Conn(ctx).Exec(query, projectID, fromUserID, toUserID); err != nil {
+		log.Errorf("Error recording transfer of project '%s': %v", projectID.String(), err)
+		return fmt.Errorf("error recording project transfer: %w", db.TranslateError(err))
+	}
+	return nil
+}
+
+// FindProjectsTransferredToUser lists the projects transferred to the user that they still own,
+// most recently transferred first. A project received more than once is listed once, with its
+// latest transfer.
+func FindProjectsTransferredToUser(ctx context.Context, userID uuid.UUID) ([]TransferredProject, error) {
+	var projects []TransferredProject
+	query := `
+        SELECT * FROM (
+            SELECT DISTINCT ON (t.project_id) t.from_user_id, u.email AS from_user_email, t.transferred_at, ` + prefixedManimProjectColumns("p") + `
+            FROM project_transfers t
+            JOIN manim_projects p ON p.id = t.project_id AND p.user_id = t.to_user_id AND p.deleted_at IS NULL
+            LEFT JOIN users u ON u.id = t.from_user_id
+            WHERE t.to_user_id = $1
+            ORDER BY t.project_id, t.transferred_at DESC
+        ) latest
+        ORDER BY transferred_at DESC`
+	if err := db.Conn(ctx).Select(&projects, query, userID); err != nil {
+		log.Errorf("Error finding projects transferred to user '%s': %v", userID.String(), err)
+		return nil, fmt.Errorf("error finding transferred projects: %w", err)
+	}
+	for i := range projects {
+		if err := decryptProject(&projects[i].ManimProject); err != nil {
+			return nil, err
+		}
+	}
+	return projects, nil
+}
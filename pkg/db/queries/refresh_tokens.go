@@ -0,0 +1,68 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const refreshTokenColumns = `id, user_id, token_hash, created_at, expires_at, revoked_at`
+
+// CreateRefreshToken inserts a new refresh token and fills in its generated fields.
+func CreateRefreshToken(ctx context.Context, token *db.RefreshToken) (*db.RefreshToken, error) {
+	query := `
+        INSERT INTO refresh_tokens (user_id, token_hash, expires_at)
+        VALUES (:user_id, :token_hash, :expires_at)
+        RETURNING ` + refreshTokenColumns
+
+	rows, err := db.Conn(ctx).NamedQuery(query, token)
+	if err != nil {
+		log.Errorf("Error creating refresh token for user '%s': %v", token.UserID.String(), err)
+		return nil, fmt.Errorf("error creating refresh token: %w", db.TranslateError(err))
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.StructScan(token); err != nil {
+			log.Errorf("Error scanning refresh token after creation: %v", err)
+			return nil, fmt.Errorf("error scanning created refresh token: %w", err)
+		}
+	} else {
+		return nil, fmt.Errorf("no rows returned after refresh token creation")
+	}
+	return token, nil
+}
+
+// ConsumeRefreshToken revokes the unexpired, unrevoked refresh token with the given hash and
+// returns it. Returns nil, nil if there is no such token, so each token can be consumed once.
+func ConsumeRefreshToken(ctx context.Context, tokenHash string) (*db.RefreshToken, error) {
+	token := &db.RefreshToken{}
+	query := `
+        UPDATE refresh_tokens SET revoked_at = NOW()
+        WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > NOW()
+        RETURNING ` + refreshTokenColumns
+	err := db.Conn(ctx).Get(token, query, tokenHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Errorf("Error consuming refresh token: %v", err)
+		return nil, fmt.Errorf("error consuming refresh token: %w", db.TranslateError(err))
+	}
+	return token, nil
+}
+
+// RevokeUserRefreshTokens revokes every unrevoked refresh token of the user, e.g. after a password
+// change, returning how many were revoked.
+func RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result, err := db.Conn(ctx).Exec(`UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, userID)
+	if err != nil {
+		log.Errorf("Error revoking refresh tokens of user '%s': %v", userID.String(), err)
+		return 0, fmt.Errorf("error revoking refresh tokens: %w", db.TranslateError(err))
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,236 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// Render job statuses.
+const (
+	RenderJobRunning   = "running"
+	RenderJobCompleted = "completed"
+	RenderJobFailed    = "failed"
+)
+
+const renderJobColumns = `id, project_id, user_id, status, error, started_at, finished_at, input_tokens, output_tokens`
+
+// CreateRenderJob records the start of a render attempt.
+func CreateRenderJob(ctx context.Context, projectID, userID uuid.UUID) (*db.RenderJob, error) {
+	job := &db.RenderJob{}
+	query := `INSERT INTO render_jobs (project_id, user_id, status) VALUES ($1, $2, $3) RETURNING ` + renderJobColumns
+	if err := db.Conn(ctx).Get(job, query, projectID, userID, RenderJobRunning); err != nil {
+		log.Errorf("Error creating render job for project '%s': %v", projectID.String(), err)
+		return nil, fmt.Errorf("error creating render job: %w", db.TranslateError(err))
+	}
+	return job, nil
+}
+
+// FindLatestRenderJob returns the project's most recent render job, or nil, nil if it has never been rendered.
+func FindLatestRenderJob(ctx context.Context, projectID uuid.UUID) (*db.RenderJob, error) {
+	job := &db.RenderJob{}
+	query := `SELECT ` + renderJobColumns + ` FROM render_jobs WHERE project_id = $1 ORDER BY started_at DESC LIMIT 1`
+	if err := db.Conn(ctx).Get(job, query, projectID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Errorf("Error finding latest render job for project '%s': %v", projectID.String(), err)
+		return nil, fmt.Errorf("error finding latest render job: %w", err)
+	}
+	return job, nil
+}
+
+// StreamRenderJobsByUser iterates over every render job the user started, oldest first, one row at a
+// time. Iteration stops at the first error returned by fn.
+func StreamRenderJobsByUser(ctx context.Context, userID uuid.UUID, fn func(*db.RenderJob) error) error {
+	query := `SELECT ` + renderJobColumns + ` FROM render_jobs WHERE user_id = $1 ORDER BY started_at ASC`
+	rows, err := db.Conn(ctx).Queryx(query, userID)
+	if err != nil {
+		log.Errorf("Error streaming render jobs for user '%s': %v", userID.String(), err)
+		return fmt.Errorf("error streaming render jobs by user: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var job db.RenderJob
+		if err := rows.StructScan(&job); err != nil {
+			log.Errorf("Error scanning render job while streaming for user '%s': %v", userID.String(), err)
+			return fmt.Errorf("error scanning streamed render job: %w", err)
+		}
+		if err := fn(&job); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// FindRenderJobByID returns one of the project's render jobs, or nil, nil if it doesn't exist.
+func FindRenderJobByID(ctx context.Context, jobID, projectID uuid.UUID) (*db.RenderJob, error) {
+	job := &db.RenderJob{}
+	query := `SELECT ` + renderJobColumns + ` FROM render_jobs WHERE id = $1 AND project_id = $2`
+	if err := db.Conn(ctx).Get(job, query, jobID, projectID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Errorf("Error finding render job '%s': %v", jobID.String(), err)
+		return nil, fmt.Errorf("error finding render job: %w", err)
+	}
+	return job, nil
+}
+
+// SetLatestRenderJobUsage stores Gemini token usage on the project's most recent running job.
+// Like FinishLatestRenderJob it's a no-op when the project has no running job.
+func SetLatestRenderJobUsage(ctx context.Context, projectID uuid.UUID, inputTokens, outputTokens int64) error {
+	query := `
+        UPDATE render_jobs SET input_tokens = $1, output_tokens = $2
+        WHERE id = (
+            SELECT id FROM render_jobs WHERE project_id = $3 AND status = $4
+            ORDER BY started_at DESC LIMIT 1
+        )`
+	if _, err := db.Conn(ctx).Exec(query, inputTokens, outputTokens, projectID, RenderJobRunning); err != nil {
+		log.Errorf("Error recording token usage for project '%s': %v", projectID.String(), err)
+		return fmt.Errorf("error recording token usage: %w", err)
+	}
+	return nil
+}
+
+// SetLatestRenderJobCallbackNonce stores the nonce of the signed callback URL handed to the renderer
+// on the project's most recent running job. Returns sql.ErrNoRows if the project has no running job.
+func SetLatestRenderJobCallbackNonce(ctx context.Context, projectID uuid.UUID, nonce string) error {
+	query := `
+        UPDATE render_jobs SET callback_nonce = $1, callback_used_at = NULL
+        WHERE id = (
+            SELECT id FROM render_jobs WHERE project_id = $2 AND status = $3
+            ORDER BY started_at DESC LIMIT 1
+        )`
+	result, err := db.Conn(ctx).Exec(query, nonce, projectID, RenderJobRunning)
+	if err != nil {
+		log.Errorf("Error storing callback nonce for project '%s': %v", projectID.String(), err)
+		return fmt.Errorf("error storing callback nonce: %w", db.TranslateError(err))
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ConsumeRenderJobCallbackNonce marks the project's render job holding nonce as called back.
+// Returns sql.ErrNoRows if no job of the project holds the nonce or it was already used, so each
+// signed callback URL is accepted once.
+func ConsumeRenderJobCallbackNonce(ctx context.Context, projectID uuid.UUID, nonce string) error {
+	query := `
+        UPDATE render_jobs SET callback_used_at = $1
+        WHERE project_id = $2 AND callback_nonce = $3 AND callback_used_at IS NULL`
+	result, err := db.Conn(ctx).Exec(query, time.Now().UTC(), projectID, nonce)
+	if err != nil {
+		log.Errorf("Error consuming callback nonce for project '%s': %v", projectID.String(), err)
+		return fmt.Errorf("error consuming callback nonce: %w", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// FinishLatestRenderJob marks the project's most recent running job as completed or failed.
+// It's a no-op when the project has no running job (e.g. renders started before jobs were recorded).
+func FinishLatestRenderJob(ctx context.Context, projectID uuid.UUID, status, errorMessage string) error {
+	query := `
+        UPDATE render_jobs SET status = $1, error = $2, finished_at = $3
+        WHERE id = (
+            SELECT id FROM render_jobs WHERE project_id = $4 AND status = $5
+            ORDER BY started_at DESC LIMIT 1
+        )`
+	errorValue := sql.NullString{String: errorMessage, Valid: errorMessage != ""}
+	if _, err := db.Conn(ctx).Exec(query, status, errorValue, time.Now().UTC(), projectID, RenderJobRunning); err != nil {
+		log.Errorf("Error finishing render job for project '%s': %v", projectID.String(), err)
+		return fmt.Errorf("error finishing render job: %w", err)
+	}
+	return nil
+}
+
+// RenderQueueStats describes renders currently in progress across all users.
+type RenderQueueStats struct {
+	Running            int             `db:"running"`
+	AvgDurationSeconds sql.NullFloat64 `db:"avg_duration_seconds"` // Of renders completed in the last day
+}
+
+// FindRenderQueueStats counts running render jobs and averages the duration of recently completed ones.
+func FindRenderQueueStats(ctx context.Context) (*RenderQueueStats, error) {
+	stats := &RenderQueueStats{}
+	query := `
+        SELECT COUNT(*) FILTER (WHERE status = $1) AS running,
+               AVG(EXTRACT(EPOCH FROM finished_at - started_at))
+                   FILTER (WHERE status = $2 AND finished_at >= $3) AS avg_duration_seconds
+        FROM render_jobs
+        WHERE status = $1 OR finished_at >= $3`
+	since := time.Now().UTC().Add(-24 * time.Hour)
+	if err := db.Conn(ctx).Get(stats, query, RenderJobRunning, RenderJobCompleted, since); err != nil {
+		log.Errorf("Error computing render queue stats: %v", err)
+		return nil, fmt.Errorf("error computing render queue stats: %w", err)
+	}
+	return stats, nil
+}
+
+// RenderTimelineBucket holds render counts for one time interval.
+type RenderTimelineBucket struct {
+	Bucket    time.Time `db:"bucket" json:"bucket"`
+	Started   int       `db:"started" json:"started"`
+	Completed int       `db:"completed" json:"completed"`
+	Failed    int       `db:"failed" json:"failed"`
+}
+
+// RenderTimeline counts the user's renders started in [from, to), grouped by interval
+// ("hour", "day" or "week", truncated in UTC). Jobs are attributed to the bucket they started in.
+// Only buckets containing renders are returned.
+func RenderTimeline(ctx context.Context, userID uuid.UUID, interval string, from, to time.Time) ([]RenderTimelineBucket, error) {
+	var buckets []RenderTimelineBucket
+	query := `
+        SELECT date_trunc($2, started_at AT TIME ZONE 'UTC') AS bucket,
+               COUNT(*) AS started,
+               COUNT(*) FILTER (WHERE status = 'completed') AS completed,
+               COUNT(*) FILTER (WHERE status = 'failed') AS failed
+        FROM render_jobs
+        WHERE user_id = $1 AND started_at >= $3 AND started_at < $4
+        GROUP BY bucket
+        ORDER BY bucket`
+	if err := db.Conn(ctx).Select(&buckets, query, userID, interval, from, to); err != nil {
+		log.Errorf("Error computing render timeline for user '%s': %v", userID.String(), err)
+		return nil, fmt.Errorf("error computing render timeline: %w", err)
+	}
+	return buckets, nil
+}
+
+// RenderDurationStats summarizes how long comparable renders took.
+type RenderDurationStats struct {
+	Samples       int             `db:"samples"`
+	MedianSeconds sql.NullFloat64 `db:"median_seconds"`
+}
+
+// FindRenderDurationStats returns the median duration of renders completed since the given time
+// whose project prompt is between minPromptLen and maxPromptLen characters (maxPromptLen <= 0 means
+// unbounded). A nil userID covers every user's renders. Lengths are of the stored prompt, which is
+// ciphertext (and so longer) for rows written with ENCRYPT_SENSITIVE_FIELDS on.
+func FindRenderDurationStats(ctx context.Context, userID *uuid.UUID, minPromptLen, maxPromptLen int, since time.Time) (*RenderDurationStats, error) {
+	stats := &RenderDurationStats{}
+	query := `
+        SELECT COUNT(*) AS samples,
+               percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM j.finished_at - j.started_at)) AS median_seconds
+        FROM render_jobs j
+        JOIN manim_projects p ON p.id = j.project_id
+        WHERE j.status = $1 AND j.finished_at >= $2
+          AND char_length(p.prompt) >= $3 AND ($4 <= 0 OR char_length(p.prompt) < $4)
+          AND ($5::uuid IS NULL OR j.user_id = $5)`
+	if err := db.Conn(ctx).Get(stats, query, RenderJobCompleted, since, minPromptLen, maxPromptLen, userID); err != nil {
+		log.Errorf("Error computing render duration stats: %v", err)
+		return nil, fmt.Errorf("error computing render duration stats: %w", err)
+	}
+	return stats, nil
+}
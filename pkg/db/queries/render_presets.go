@@ -0,0 +1,111 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const renderPresetColumns = `id, user_id, name, quality, format, model, profile, created_at, updated_at`
+
+// CreateRenderPreset inserts a new render preset and fills in its generated fields.
+func CreateRenderPreset(ctx context.Context, preset *db.RenderPreset) (*db.RenderPreset, error) {
+	query := `
+        INSERT INTO render_presets (user_id, name, quality, format, model, profile)
+        VALUES (:user_id, :name, :quality, :format, :model, :profile)
+        RETURNING ` + renderPresetColumns
+
+	rows, err := db.Conn(ctx).NamedQuery(query, preset)
+	if err != nil {
+		log.Errorf("Error creating render preset for user '%s': %v", preset.UserID.String(), err)
+		return nil, fmt.Errorf("error creating render preset: %w", db.TranslateError(err))
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.StructScan(preset); err != nil {
+			log.Errorf("Error scanning render preset after creation: %v", err)
+			return nil, fmt.Errorf("error scanning created render preset: %w", err)
+		}
+	} else {
+		return nil, fmt.Errorf("no rows returned after render preset creation")
+	}
+	return preset, nil
+}
+
+// FindRenderPresetsByUserID lists a user's render presets by name.
+func FindRenderPresetsByUserID(ctx context.Context, userID uuid.UUID) ([]db.RenderPreset, error) {
+	var presets []db.RenderPreset
+	query := `SELECT ` + renderPresetColumns + ` FROM render_presets WHERE user_id = $1 ORDER BY name`
+	if err := db.Conn(ctx).Select(&presets, query, userID); err != nil {
+		log.Errorf("Error listing render presets for user '%s': %v", userID.String(), err)
+		return nil, fmt.Errorf("error listing render presets: %w", err)
+	}
+	return presets, nil
+}
+
+// FindRenderPresetByID retrieves one of the user's presets. Returns nil, nil if the user has no such preset.
+func FindRenderPresetByID(ctx context.Context, id, userID uuid.UUID) (*db.RenderPreset, error) {
+	preset := &db.RenderPreset{}
+	query := `SELECT ` + renderPresetColumns + ` FROM render_presets WHERE id = $1 AND user_id = $2`
+	if err := db.Conn(ctx).Get(preset, query, id, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Errorf("Error finding render preset '%s': %v", id.String(), err)
+		return nil, fmt.Errorf("error finding render preset: %w", err)
+	}
+	return preset, nil
+}
+
+// FindRenderPresetByName retrieves the user's preset with the given name. Returns nil, nil if there is none.
+func FindRenderPresetByName(ctx context.Context, userID uuid.UUID, name string) (*db.RenderPreset, error) {
+	preset := &db.RenderPreset{}
+	query := `SELECT ` + renderPresetColumns + ` FROM render_presets WHERE user_id = $1 AND name = $2`
+	if err := db.Conn(ctx).Get(preset, query, userID, name); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Errorf("Error finding render preset '%s' for user '%s': %v", name, userID.String(), err)
+		return nil, fmt.Errorf("error finding render preset by name: %w", err)
+	}
+	return preset, nil
+}
+
+// UpdateRenderPreset saves a preset's name and options. Returns sql.ErrNoRows if the user has no such preset.
+func UpdateRenderPreset(ctx context.Context, preset *db.RenderPreset) error {
+	preset.UpdatedAt = time.Now().UTC()
+	query := `
+        UPDATE render_presets
+        SET name = :name, quality = :quality, format = :format, model = :model, profile = :profile, updated_at = :updated_at
+        WHERE id = :id AND user_id = :user_id`
+	result, err := db.Conn(ctx).NamedExec(query, preset)
+	if err != nil {
+		log.Errorf("Error updating render preset '%s': %v", preset.ID.String(), err)
+		return fmt.Errorf("error updating render preset: %w", db.TranslateError(err))
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteRenderPreset deletes one of the user's presets. Returns sql.ErrNoRows if the user has no such preset.
+func DeleteRenderPreset(ctx context.Context, id, userID uuid.UUID) error {
+	result, err := db.Conn(ctx).Exec(`DELETE FROM render_presets WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		log.Errorf("Error deleting render preset '%s': %v", id.String(), err)
+		return fmt.Errorf("error deleting render preset: %w", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
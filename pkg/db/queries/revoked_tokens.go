@@ -0,0 +1,43 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	log "github.com/sirupsen/logrus"
+)
+
+// RevokeToken adds a token's jti to the revocation blocklist until expiresAt. Revoking an
+// already revoked token is a no-op.
+func RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	query := `INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING`
+	if _, err := db.Conn(ctx).Exec(query, jti, expiresAt); err != nil {
+		log.Errorf("Error revoking token '%s': %v", jti, err)
+		return fmt.Errorf("error revoking token: %w", db.TranslateError(err))
+	}
+	return nil
+}
+
+// IsTokenRevoked reports whether the token with the given jti has been revoked.
+func IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var revoked bool
+	query := `SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)`
+	if err := db.Conn(ctx).Get(&revoked, query, jti); err != nil {
+		log.Errorf("Error checking revocation of token '%s': %v", jti, err)
+		return false, fmt.Errorf("error checking token revocation: %w", err)
+	}
+	return revoked, nil
+}
+
+// DeleteExpiredRevokedTokens removes blocklist entries for tokens that have expired anyway,
+// returning how many were removed.
+func DeleteExpiredRevokedTokens(ctx context.Context) (int64, error) {
+	result, err := db.Conn(ctx).Exec(`DELETE FROM revoked_tokens WHERE expires_at < NOW()`)
+	if err != nil {
+		log.Errorf("Error deleting expired revoked tokens: %v", err)
+		return 0, fmt.Errorf("error deleting expired revoked tokens: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -1,6 +1,7 @@
 package queries
 
 import (
+	"context"
 	"time"
 	"database/sql"
 	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db" // Import your db package
@@ -10,7 +11,7 @@ import (
 
 // CreateUser inserts a new user into the database.
 // It takes a User struct (without ID, CreatedAt, UpdatedAt) and returns the created User with generated fields.
-func CreateUser(user *db.User) (*db.User, error) {
+func CreateUser(ctx context.Context, user *db.User) (*db.User, error) {
 	// Ensure timestamps are set before insertion if they aren't by the DB default.
 	// However, PostgreSQL's DEFAULT CURRENT_TIMESTAMP handles this well.
 	// We might use NOW() in the query for more explicit control or if DB default is not set.
@@ -22,10 +23,10 @@ func CreateUser(user *db.User) (*db.User, error) {
 
 	// Use NamedExec for queries with named parameters from struct tags.
 	// This executes the query and returns the first row's generated fields into 'user'.
-	rows, err := db.DB.NamedQuery(query, user)
+	rows, err := db.Conn(ctx).NamedQuery(query, user)
 	if err != nil {
 		log.Errorf("Error creating user: %v", err)
-		return nil, err
+		return nil, db.TranslateError(err)
 	}
 	defer rows.Close() // Always close rows after use
 
@@ -46,10 +47,10 @@ func CreateUser(user *db.User) (*db.User, error) {
 }
 
 // FindUserByEmail retrieves a user from the database by their email address.
-func FindUserByEmail(email string) (*db.User, error) {
+func FindUserByEmail(ctx context.Context, email string) (*db.User, error) {
 	user := &db.User{}
 	query := `SELECT id, username, email, password_hash, created_at, updated_at FROM users WHERE email = $1`
-	err := db.DB.Get(user, query, email) // Get is for single row results
+	err := db.Conn(ctx).Get(user, query, email) // Get is for single row results
 	if err != nil {
 		// sql.ErrNoRows is a common error to check for when a record isn't found
 		if err == sql.ErrNoRows {
@@ -62,11 +63,28 @@ func FindUserByEmail(email string) (*db.User, error) {
 	return user, nil
 }
 
+// FindUserByUsername retrieves a user from the database by their username, ignoring case.
+// Returns nil, nil if no user has that username.
+func FindUserByUsername(ctx context.Context, username string) (*db.User, error) {
+	user := &db.User{}
+	query := `SELECT id, username, email, password_hash, created_at, updated_at FROM users WHERE LOWER(username) = LOWER($1)`
+	err := db.Conn(ctx).Get(user, query, username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			log.Debugf("User with username '%s' not found.", username)
+			return nil, nil
+		}
+		log.Errorf("Error finding user by username '%s': %v", username, err)
+		return nil, err
+	}
+	return user, nil
+}
+
 // FindUserByID retrieves a user from the database by their ID.
-func FindUserByID(id uuid.UUID) (*db.User, error) {
+func FindUserByID(ctx context.Context, id uuid.UUID) (*db.User, error) {
 	user := &db.User{}
 	query := `SELECT id, username, email, password_hash, created_at, updated_at FROM users WHERE id = $1`
-	err := db.DB.Get(user, query, id)
+	err := db.Conn(ctx).Get(user, query, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Debugf("User with ID '%s' not found.", id.String())
@@ -80,7 +98,7 @@ func FindUserByID(id uuid.UUID) (*db.User, error) {
 
 // UpdateUser updates an existing user's information in the database.
 // It expects the user struct to have the ID set for the record to update.
-func UpdateUser(user *db.User) error {
+func UpdateUser(ctx context.Context, user *db.User) error {
 	user.UpdatedAt = time.Now().UTC() // Update the timestamp manually before saving
 
 	query := `
@@ -88,10 +106,10 @@ func UpdateUser(user *db.User) error {
 		SET username = :username, email = :email, password_hash = :password_hash, updated_at = :updated_at
 		WHERE id = :id`
 
-	result, err := db.DB.NamedExec(query, user)
+	result, err := db.Conn(ctx).NamedExec(query, user)
 	if err != nil {
 		log.Errorf("Error updating user with ID '%s': %v", user.ID.String(), err)
-		return err
+		return db.TranslateError(err)
 	}
 
 	rowsAffected, _ := result.RowsAffected()
@@ -105,12 +123,12 @@ func UpdateUser(user *db.User) error {
 }
 
 // DeleteUser deletes a user from the database by their ID.
-func DeleteUser(id uuid.UUID) error {
+func DeleteUser(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM users WHERE id = $1`
-	result, err := db.DB.Exec(query, id) // Exec is for queries that don't return rows (INSERT, UPDATE, DELETE)
+	result, err := db.Conn(ctx).Exec(query, id) // Exec is for queries that don't return rows (INSERT, UPDATE, DELETE)
 	if err != nil {
 		log.Errorf("Error deleting user with ID '%s': %v", id.String(), err)
-		return err
+		return db.TranslateError(err)
 	}
 
 	rowsAffected, _ := result.RowsAffected()
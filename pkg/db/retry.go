@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+)
+
+// IsTransient reports whether err looks like a dropped or refused database connection,
+// as opposed to a problem with the query itself, so retrying may succeed.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// Class 08: connection exception; 57P01-57P03: server shutting down or unavailable
+		return pqErr.Code.Class() == "08" || pqErr.Code == "57P01" || pqErr.Code == "57P02" || pqErr.Code == "57P03"
+	}
+	return false
+}
+
+// RetryTransient runs fn and, if it fails with a transient connection error, runs it once more.
+// Inside a transaction there is no retry: a lost connection has already aborted the transaction.
+// fn must be safe to repeat.
+func RetryTransient(ctx context.Context, name string, fn func() error) error {
+	err := fn()
+	if _, inTx := TxFromContext(ctx); inTx || !IsTransient(err) {
+		return err
+	}
+	log.Warnf("%s: Transient database error, retrying once: %v", name, err)
+	return fn()
+}
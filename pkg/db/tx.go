@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Executor is the set of query methods shared by *sqlx.DB and *sqlx.Tx.
+// Query functions run against an Executor so they work the same whether
+// they're called inside a request-scoped transaction or not.
+type Executor interface {
+	Get(dest interface{}, query string, args ...interface{}) error
+	Select(dest interface{}, query string, args ...interface{}) error
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	NamedExec(query string, arg interface{}) (sql.Result, error)
+	NamedQuery(query string, arg interface{}) (*sqlx.Rows, error)
+	Queryx(query string, args ...interface{}) (*sqlx.Rows, error)
+	QueryRowx(query string, args ...interface{}) *sqlx.Row
+	Rebind(query string) string
+}
+
+type txContextKey struct{}
+
+// WithTx returns a copy of ctx carrying the given transaction.
+func WithTx(ctx context.Context, tx *sqlx.Tx) context.Context {
+	return context.WithValue(ctx, txContextKey{}, tx)
+}
+
+// TxFromContext returns the transaction stored in ctx, if any.
+func TxFromContext(ctx context.Context) (*sqlx.Tx, bool) {
+	tx, ok := ctx.Value(txContextKey{}).(*sqlx.Tx)
+	return tx, ok && tx != nil
+}
+
+// Conn returns the transaction stored in ctx when present, otherwise the shared pool.
+func Conn(ctx context.Context) Executor {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return DB
+}
+
+// Savepoint runs fn inside a SAVEPOINT when ctx carries a transaction. If fn fails the
+// transaction is rolled back to the savepoint, undoing only fn's writes and leaving the
+// surrounding transaction usable. Without a transaction in ctx, fn simply runs.
+func Savepoint(ctx context.Context, name string, fn func() error) error {
+	tx, ok := TxFromContext(ctx)
+	if !ok {
+		return fn()
+	}
+	if _, err := tx.Exec("SAVEPOINT " + name); err != nil {
+		return fmt.Errorf("failed to create savepoint %s: %w", name, err)
+	}
+	if err := fn(); err != nil {
+		if _, rbErr := tx.Exec("ROLLBACK TO SAVEPOINT " + name); rbErr != nil {
+			return fmt.Errorf("%w (rollback to savepoint %s failed: %v)", err, name, rbErr)
+		}
+		return err
+	}
+	if _, err := tx.Exec("RELEASE SAVEPOINT " + name); err != nil {
+		return fmt.Errorf("failed to release savepoint %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultAdminPageSize = 50
+	maxAdminPageSize     = 200
+)
+
+// adminPageFromQuery reads ?page= (from 1) and ?page_size= for the admin lists, responding with
+// 400 and returning ok=false when either is invalid.
+func adminPageFromQuery(c *gin.Context) (page, pageSize int, ok bool) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid page. Must be a positive integer", nil)
+		return 0, 0, false
+	}
+	pageSize, err = strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultAdminPageSize)))
+	if err != nil || pageSize < 1 || pageSize > maxAdminPageSize {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid page_size", gin.H{"max_page_size": maxAdminPageSize})
+		return 0, 0, false
+	}
+	return page, pageSize, true
+}
+
+// AdminProjectResponse is a project in the admin list, with its owner's email.
+type AdminProjectResponse struct {
+	ProjectResponse
+	UserEmail string `json:"user_email"`
+}
+
+// ListAllProjects returns projects across all users for support staff, newest first.
+// Filters: ?user_email=, ?status=, ?created_from=, ?created_to= and ?metadata.<key>=<value>.
+// Pagination: ?page= (from 1) and ?page_size= (default 50, max 200).
+func ListAllProjects(c *gin.Context) {
+	projectFilter, err := projectFilterFromQuery(c)
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid filter", err.Error())
+		return
+	}
+
+	page, pageSize, ok := adminPageFromQuery(c)
+	if !ok {
+		return
+	}
+
+	filter := queries.AdminProjectFilter{
+		ProjectFilter: projectFilter,
+		UserEmail:     strings.TrimSpace(c.Query("user_email")),
+		Limit:         pageSize,
+		Offset:        (page - 1) * pageSize,
+	}
+	projects, total, err := queries.FindAllManimProjects(c.Request.Context(), filter)
+	if err != nil {
+		log.Errorf("ListAllProjects: Failed to list projects: %v", err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retrieve projects", nil)
+		return
+	}
+
+	opts := projectResponseOptionsFromQuery(c)
+	responses := make([]AdminProjectResponse, 0, len(projects))
+	for i := range projects {
+		responses = append(responses, AdminProjectResponse{
+			ProjectResponse: opts.render(&projects[i].ManimProject),
+			UserEmail:       projects[i].UserEmail,
+		})
+	}
+
+	utils.ResponseWithSuccess(c, http.StatusOK, "Projects retrieved successfully", gin.H{
+		"projects":  responses,
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+	})
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// AdminUserResponse is a user as returned by the admin endpoints.
+type AdminUserResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	CreatedAt string    `json:"created_at"`
+	UpdatedAt string    `json:"updated_at"`
+}
+
+func newAdminUserResponse(user *queries.AdminUser) AdminUserResponse {
+	return AdminUserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		CreatedAt: user.CreatedAt.Format(http.TimeFormat),
+		UpdatedAt: user.UpdatedAt.Format(http.TimeFormat),
+	}
+}
+
+// ListUsers returns users for support staff, newest first.
+// Filters: ?email= (case-insensitive substring), ?created_from= and ?created_to= (RFC 3339 or YYYY-MM-DD).
+// Pagination: ?page= (from 1) and ?page_size= (default 50, max 200).
+func ListUsers(c *gin.Context) {
+	filter := queries.AdminUserFilter{EmailContains: strings.TrimSpace(c.Query("email"))}
+	var err error
+	if raw := c.Query("created_from"); raw != "" {
+		if filter.CreatedAfter, err = parseQueryTime(raw); err != nil {
+			utils.ResponseWithError(c, http.StatusBadRequest, "Invalid filter", "invalid created_from: use RFC 3339 or YYYY-MM-DD")
+			return
+		}
+	}
+	if raw := c.Query("created_to"); raw != "" {
+		if filter.CreatedBefore, err = parseQueryTime(raw); err != nil {
+			utils.ResponseWithError(c, http.StatusBadRequest, "Invalid filter", "invalid created_to: use RFC 3339 or YYYY-MM-DD")
+			return
+		}
+	}
+
+	page, pageSize, ok := adminPageFromQuery(c)
+	if !ok {
+		return
+	}
+	filter.Limit = pageSize
+	filter.Offset = (page - 1) * pageSize
+
+	users, total, err := queries.FindUsersPaginated(c.Request.Context(), filter)
+	if err != nil {
+		log.Errorf("ListUsers: Failed to list users: %v", err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retrieve users", nil)
+		return
+	}
+
+	responses := make([]AdminUserResponse, 0, len(users))
+	for i := range users {
+		responses = append(responses, newAdminUserResponse(&users[i]))
+	}
+
+	utils.ResponseWithSuccess(c, http.StatusOK, "Users retrieved successfully", gin.H{
+		"users":     responses,
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+	})
+}
+
+// GetUser returns a single user for support staff along with their project and render counts.
+func GetUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid user ID format", nil)
+		return
+	}
+
+	user, summary, err := queries.FindAdminUserByID(c.Request.Context(), userID)
+	if err != nil {
+		log.Errorf("GetUser: Failed to fetch user %s: %v", userID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retrieve user", nil)
+		return
+	}
+	if user == nil {
+		utils.ResponseWithError(c, http.StatusNotFound, "User not found", nil)
+		return
+	}
+
+	utils.ResponseWithSuccess(c, http.StatusOK, "User retrieved successfully", gin.H{
+		"user":    newAdminUserResponse(user),
+		"summary": summary,
+	})
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/middleware"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/services"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxActiveAPIKeys caps how many unrevoked API keys a user may hold.
+const maxActiveAPIKeys = 20
+
+// CreateAPIKeyRequest defines the structure for creating an API key.
+type CreateAPIKeyRequest struct {
+	Name string `json:"name" binding:"required,min=1,max=100"`
+}
+
+// APIKeyResponse describes an API key without its secret.
+type APIKeyResponse struct {
+	ID         uuid.UUID `json:"id"`
+	Name       string    `json:"name"`
+	Prefix     string    `json:"prefix"`
+	CreatedAt  string    `json:"created_at"`
+	LastUsedAt string    `json:"last_used_at,omitempty"`
+	RevokedAt  string    `json:"revoked_at,omitempty"`
+}
+
+// newAPIKeyResponse converts a db.APIKey to an APIKeyResponse.
+func newAPIKeyResponse(key *db.APIKey) APIKeyResponse {
+	resp := APIKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		CreatedAt: key.CreatedAt.Format(http.TimeFormat),
+	}
+	if key.LastUsedAt.Valid {
+		resp.LastUsedAt = key.LastUsedAt.Time.Format(http.TimeFormat)
+	}
+	if key.RevokedAt.Valid {
+		resp.RevokedAt = key.RevokedAt.Time.Format(http.TimeFormat)
+	}
+	return resp
+}
+
+// CreateAPIKey generates a new API key for the authenticated user. The full key is only
+// returned in this response; afterwards only its prefix is shown.
+func CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("CreateAPIKey: Invalid request body: %v", err)
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", utils.BindingErrorDetails(err))
+		return
+	}
+
+	claims, exists := middleware.GetUserClaimsFromContext(c)
+	if !exists {
+		log.Error("CreateAPIKey: User claims not found in context.")
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+		return
+	}
+
+	active, err := queries.CountActiveAPIKeysByUserID(c.Request.Context(), claims.UserID)
+	if err != nil {
+		respondDBError(c, err, "Failed to create API key")
+		return
+	}
+	if active >= maxActiveAPIKeys {
+		utils.ResponseWithError(c, http.StatusConflict, "Maximum number of active API keys reached. Revoke an existing key first.", gin.H{"max_active_keys": maxActiveAPIKeys})
+		return
+	}
+
+	plaintext, prefix, hash, err := services.GenerateAPIKey()
+	if err != nil {
+		log.Errorf("CreateAPIKey: %v", err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to create API key", nil)
+		return
+	}
+	key, err := queries.CreateAPIKey(c.Request.Context(), &db.APIKey{
+		UserID:  claims.UserID,
+		Name:    strings.TrimSpace(req.Name),
+		Prefix:  prefix,
+		KeyHash: hash,
+	})
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to create API key", nil)
+		return
+	}
+
+	log.Infof("API key %s created for user %s.", key.Prefix, claims.UserID.String())
+	utils.ResponseWithSuccess(c, http.StatusCreated, "API key created. Store it now; it won't be shown again.", gin.H{
+		"key":     plaintext,
+		"api_key": newAPIKeyResponse(key),
+	})
+}
+
+// ListAPIKeys lists the authenticated user's API keys (prefixes only).
+func ListAPIKeys(c *gin.Context) {
+	claims, exists := middleware.GetUserClaimsFromContext(c)
+	if !exists {
+		log.Error("ListAPIKeys: User claims not found in context.")
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+		return
+	}
+
+	keys, err := queries.FindAPIKeysByUserID(c.Request.Context(), claims.UserID)
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retrieve API keys", nil)
+		return
+	}
+	responses := make([]APIKeyResponse, len(keys))
+	for i := range keys {
+		responses[i] = newAPIKeyResponse(&keys[i])
+	}
+	utils.ResponseWithSuccess(c, http.StatusOK, "API keys retrieved successfully", responses)
+}
+
+// RevokeAPIKey revokes one of the authenticated user's API keys.
+func RevokeAPIKey(c *gin.Context) {
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid API key ID format", nil)
+		return
+	}
+
+	claims, exists := middleware.GetUserClaimsFromContext(c)
+	if !exists {
+		log.Error("RevokeAPIKey: User claims not found in context.")
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+		return
+	}
+
+	err = queries.RevokeAPIKey(c.Request.Context(), keyID, claims.UserID)
+	if err == sql.ErrNoRows {
+		utils.ResponseWithError(c, http.StatusNotFound, "API key not found", nil)
+		return
+	}
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to revoke API key", nil)
+		return
+	}
+	utils.ResponseWithSuccess(c, http.StatusOK, "API key revoked", nil)
+}
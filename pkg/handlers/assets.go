@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// assetURLTTL is how long presigned asset URLs handed to the renderer stay valid.
+const assetURLTTL = time.Hour
+
+// assetContentTypes lists the asset types Manim can load (ImageMobject, SVGMobject), keyed by extension.
+var assetContentTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".svg":  "image/svg+xml",
+}
+
+// assetFilenamePattern keeps asset names safe to use as object keys and as paths in scripts.
+var assetFilenamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,99}$`)
+
+// RendererAsset tells the renderer where to fetch a file the script refers to by Filename.
+type RendererAsset struct {
+	Filename string `json:"filename"`
+	URL      string `json:"url"`
+}
+
+// AssetResponse describes an uploaded asset.
+type AssetResponse struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	SizeBytes   int64  `json:"size_bytes"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// assetContentMatches checks the uploaded bytes against the type its extension claims, so
+// a renamed executable can't be smuggled in as an image.
+func assetContentMatches(content []byte, contentType string) bool {
+	if contentType == "image/svg+xml" {
+		return bytes.Contains(bytes.ToLower(content[:min(len(content), 1024)]), []byte("<svg"))
+	}
+	return http.DetectContentType(content) == contentType
+}
+
+// UploadProjectAsset stores a multipart-uploaded file ("file" field) in R2 under the project's
+// prefix. Every render of the project passes the renderer a manifest of its assets, so scripts can
+// load them by filename. Only image and SVG files up to ASSET_MAX_BYTES are accepted; uploading
+// a file with an existing name replaces it.
+func (h *Handlers) UploadProjectAsset(c *gin.Context) {
+	if h.Storage == nil {
+		log.Warn("UploadProjectAsset: Storage client is not configured.")
+		utils.ResponseWithError(c, http.StatusNotImplemented, "Asset uploads are not configured on this server", nil)
+		return
+	}
+	project, _, ok := loadOwnedProject(c, "UploadProjectAsset")
+	if !ok {
+		return
+	}
+	if project.Locked {
+		respondProjectLocked(c)
+		return
+	}
+
+	// Leave room for the multipart framing around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(h.Config.AssetMaxBytes)+64*1024)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusBadRequest, "A multipart 'file' field is required", err.Error())
+		return
+	}
+	if fileHeader.Size > int64(h.Config.AssetMaxBytes) {
+		utils.ResponseWithError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("Assets may be at most %d bytes", h.Config.AssetMaxBytes), nil)
+		return
+	}
+
+	filename := path.Base(strings.ReplaceAll(fileHeader.Filename, "\\", "/"))
+	if !assetFilenamePattern.MatchString(filename) {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid asset filename", "Use letters, digits, '.', '_' and '-' only, up to 100 characters.")
+		return
+	}
+	contentType, ok := assetContentTypes[strings.ToLower(path.Ext(filename))]
+	if !ok {
+		utils.ResponseWithError(c, http.StatusUnsupportedMediaType, "Unsupported asset type", gin.H{"allowed": []string{".png", ".jpg", ".jpeg", ".gif", ".svg"}})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		log.Errorf("UploadProjectAsset: Failed to open uploaded file: %v", err)
+		utils.ResponseWithError(c, http.StatusBadRequest, "Failed to read uploaded file", nil)
+		return
+	}
+	defer file.Close()
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		log.Errorf("UploadProjectAsset: Failed to read uploaded file: %v", err)
+		utils.ResponseWithError(c, http.StatusBadRequest, "Failed to read uploaded file", nil)
+		return
+	}
+	if len(content) == 0 || !assetContentMatches(content, contentType) {
+		utils.ResponseWithError(c, http.StatusBadRequest, "File contents don't match its extension", nil)
+		return
+	}
+
+	key := fmt.Sprintf("assets/%s/%s", project.ID.String(), filename)
+	if err := h.Storage.Put(c.Request.Context(), key, content, contentType); err != nil {
+		log.Errorf("UploadProjectAsset: Failed to store asset %s: %v", key, err)
+		utils.ResponseWithError(c, http.StatusBadGateway, "Failed to store asset", nil)
+		return
+	}
+	asset, err := queries.UpsertProjectAsset(c.Request.Context(), &db.ProjectAsset{
+		ProjectID:   project.ID,
+		Filename:    filename,
+		ObjectKey:   key,
+		ContentType: contentType,
+		SizeBytes:   int64(len(content)),
+	})
+	if err != nil {
+		respondDBError(c, err, "Failed to save asset")
+		return
+	}
+
+	log.Infof("Stored asset %s for project %s (%d bytes).", filename, project.ID.String(), len(content))
+	utils.ResponseWithSuccess(c, http.StatusCreated, "Asset uploaded successfully", AssetResponse{
+		Filename:    asset.Filename,
+		ContentType: asset.ContentType,
+		SizeBytes:   asset.SizeBytes,
+		CreatedAt:   asset.CreatedAt.Format(time.RFC3339),
+	})
+}
+
+// assetManifest lists the project's assets with URLs the renderer can download them from:
+// public URLs when the bucket has one, short-lived presigned URLs otherwise.
+func (h *Handlers) assetManifest(ctx context.Context, project *db.ManimProject) ([]RendererAsset, error) {
+	if h.Storage == nil {
+		return nil, nil
+	}
+	assets, err := queries.FindProjectAssets(ctx, project.ID)
+	if err != nil {
+		return nil, err
+	}
+	manifest := make([]RendererAsset, 0, len(assets))
+	for _, asset := range assets {
+		url := h.Storage.PublicURL(asset.ObjectKey)
+		if url == "" {
+			if url, err = h.Storage.PresignGet(asset.ObjectKey, assetURLTTL); err != nil {
+				return nil, err
+			}
+		}
+		manifest = append(manifest, RendererAsset{Filename: asset.Filename, URL: url})
+	}
+	return manifest, nil
+}
@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"os"
 	"strings"
 
 	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db" // For CreateUser function
 	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/middleware"
 	"github.com/ASHISH26940/manim-orchestrator-api/pkg/services"
 	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils" // For common HTTP responses
 	"github.com/gin-gonic/gin"
@@ -35,18 +37,23 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// RefreshRequest is the body of POST /auth/refresh.
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
 func LoginUser(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		log.Debugf("LoginUser: Invalid request body: %v", err)
-		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", utils.BindingErrorDetails(err))
 		return
 	}
 
 	req.Email = strings.ToLower(req.Email)
 
 	// Find the user by email
-	user, err := queries.FindUserByEmail(req.Email)
+	user, err := queries.FindUserByEmail(c.Request.Context(), req.Email)
 	if err != nil {
 		log.Errorf("LoginUser: Error finding user by email: %v", err)
 		utils.ResponseWithError(c, http.StatusInternalServerError, "Login failed", nil) // Generic error for security
@@ -73,19 +80,63 @@ func LoginUser(c *gin.Context) {
 		return
 	}
 
+	refreshToken, err := services.GenerateRefreshToken(c.Request.Context(), user.ID)
+	if err != nil {
+		log.Errorf("LoginUser: Failed to generate refresh token for user %s: %v", user.Email, err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to generate authentication token", nil)
+		return
+	}
+
 	log.Infof("User %s logged in successfully.", user.Email)
-	utils.ResponseWithSuccess(c, http.StatusOK, "Login successful", gin.H{"token": token})
+	utils.ResponseWithSuccess(c, http.StatusOK, "Login successful", gin.H{"token": token, "refresh_token": refreshToken})
+}
+
+// RefreshAccessToken exchanges a refresh token for a new access token. The refresh token is rotated:
+// the one presented is invalidated and a replacement is returned alongside the access token.
+func RefreshAccessToken(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Debugf("RefreshAccessToken: Invalid request body: %v", err)
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", utils.BindingErrorDetails(err))
+		return
+	}
+
+	token, refreshToken, err := services.RefreshSession(c.Request.Context(), req.RefreshToken)
+	if errors.Is(err, services.ErrInvalidRefreshToken) {
+		log.Debug("RefreshAccessToken: Rejected invalid, expired or reused refresh token.")
+		utils.ResponseWithError(c, http.StatusUnauthorized, "Invalid or expired refresh token", nil)
+		return
+	}
+	if err != nil {
+		log.Errorf("RefreshAccessToken: Failed to refresh session: %v", err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to refresh authentication token", nil)
+		return
+	}
+
+	utils.ResponseWithSuccess(c, http.StatusOK, "Token refreshed", gin.H{"token": token, "refresh_token": refreshToken})
 }
 
-func RegisterUser(c *gin.Context) {
+// RegisterUser creates a new user account. When ALLOWED_EMAIL_DOMAINS is configured,
+// only emails from those domains may register.
+func (h *Handlers) RegisterUser(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		log.Debugf("Invalid request body: %v", err)
-		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", utils.BindingErrorDetails(err))
 		return
 	}
 	req.Email = strings.ToLower(req.Email)
-	existingUser, err := queries.FindUserByEmail(req.Email)
+	if !h.Config.IsEmailDomainAllowed(req.Email) {
+		log.Infof("RegisterUser: Registration rejected for disallowed email domain '%s'.", req.Email)
+		utils.ResponseWithError(c, http.StatusForbidden, "Registration is restricted to approved email domains", nil)
+		return
+	}
+	if err := checkPasswordLength(req.Password); err != nil {
+		log.Debugf("RegisterUser: Password for '%s' exceeds %d bytes.", req.Email, maxPasswordBytes)
+		utils.ResponseWithError(c, http.StatusBadRequest, "Password is too long", err.Error())
+		return
+	}
+	existingUser, err := queries.FindUserByEmail(c.Request.Context(), req.Email)
 	if err != nil {
 		log.Errorf("Error finding user by email '%s': %v", req.Email, err)
 		utils.ResponseWithError(c, http.StatusInternalServerError, "Error finding user by email", err.Error())
@@ -96,7 +147,19 @@ func RegisterUser(c *gin.Context) {
 		utils.ResponseWithError(c, http.StatusConflict, "User with email already exists", nil)
 		return
 	}
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	// Usernames differing only in case would be indistinguishable to other users
+	existingUser, err = queries.FindUserByUsername(c.Request.Context(), req.Username)
+	if err != nil {
+		log.Errorf("Error finding user by username '%s': %v", req.Username, err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Error finding user by username", nil)
+		return
+	}
+	if existingUser != nil {
+		log.Debugf("User with username '%s' already exists.", req.Username)
+		utils.ResponseWithError(c, http.StatusConflict, "Username is already taken", nil)
+		return
+	}
+	hashedPassword, err := hashPassword(req.Password)
 	if err != nil {
 		log.Errorf("Error hashing password: %v", err)
 		utils.ResponseWithError(c, http.StatusInternalServerError, "Error hashing password", err.Error())
@@ -106,13 +169,13 @@ func RegisterUser(c *gin.Context) {
 	user := &db.User{
 		Username:     req.Username,
 		Email:        req.Email,
-		PasswordHash: string(hashedPassword),
+		PasswordHash: hashedPassword,
 	}
 
-	createdUser, err := queries.CreateUser(user)
+	createdUser, err := queries.CreateUser(c.Request.Context(), user)
 	if err != nil {
 		log.Errorf("Error creating user: %v", err)
-		utils.ResponseWithError(c, http.StatusInternalServerError, "Error creating user", err.Error())
+		respondDBError(c, err, "Error creating user")
 		return
 	}
 	log.Infof("User with ID '%s' created.", createdUser.ID.String())
@@ -120,6 +183,51 @@ func RegisterUser(c *gin.Context) {
 	utils.ResponseWithSuccess(c, http.StatusCreated, "User created successfully", nil)
 }
 
+// LogoutRequest is the optional body of POST /auth/logout.
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"` // Also invalidated when given
+}
+
+// LogoutUser revokes the access token the request was authenticated with, so it's rejected from
+// now on even though it hasn't expired. A refresh token in the body is invalidated too.
+func LogoutUser(c *gin.Context) {
+	var req LogoutRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", utils.BindingErrorDetails(err))
+			return
+		}
+	}
+
+	claims, exists := middleware.GetUserClaimsFromContext(c)
+	if !exists {
+		log.Error("LogoutUser: User claims not found in context.")
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+		return
+	}
+	if claims.ID == "" {
+		// API keys and tokens issued before jti was added can't be revoked individually
+		utils.ResponseWithError(c, http.StatusBadRequest, "This credential can't be logged out; revoke the API key or wait for the token to expire", nil)
+		return
+	}
+
+	if err := services.RevokeToken(c.Request.Context(), claims); err != nil {
+		log.Errorf("LogoutUser: Failed to revoke token for user %s: %v", claims.UserID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to log out", nil)
+		return
+	}
+	if req.RefreshToken != "" {
+		if err := services.RevokeRefreshToken(c.Request.Context(), req.RefreshToken); err != nil {
+			log.Errorf("LogoutUser: Failed to revoke refresh token for user %s: %v", claims.UserID.String(), err)
+			utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to log out", nil)
+			return
+		}
+	}
+
+	log.Infof("User %s logged out.", claims.UserID.String())
+	utils.ResponseWithSuccess(c, http.StatusOK, "Logged out successfully", nil)
+}
+
 func DeleteUser(c *gin.Context) {
     // --- 1. Extract User Claims from Gin Context (provided by AuthMiddleware) ---
     claimsAny, exists := c.Get("userClaims")
@@ -144,7 +252,7 @@ func DeleteUser(c *gin.Context) {
     log.Infof("DeleteUser: Attempting deletion for user email: '%s', ID: '%s' (from context)", verifiedUserEmail, verifiedUserID)
 
     // Find the user by the VERIFIED email (from the context/token)
-    userToDelete, err := queries.FindUserByEmail(verifiedUserEmail)
+    userToDelete, err := queries.FindUserByEmail(c.Request.Context(), verifiedUserEmail)
     if err != nil {
         log.Errorf("DeleteUser: Error finding user from verified email '%s': %v", verifiedUserEmail, err)
         utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to find user account", nil)
@@ -164,7 +272,7 @@ func DeleteUser(c *gin.Context) {
     // The *only* source of identity for the user now is the JWT token itself.
 
     // --- Proceed with Deletion ---
-    err = queries.DeleteUser(userToDelete.ID)
+    err = queries.DeleteUser(c.Request.Context(), userToDelete.ID)
     if err != nil {
         log.Errorf("DeleteUser: Error deleting user with ID '%s' (email: %s): %v", userToDelete.ID.String(), verifiedUserEmail, err)
         utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to delete user account", nil)
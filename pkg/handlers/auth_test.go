@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/config"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRegisterUserRejectsDisallowedDomain(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handlers{Config: &config.Config{AllowedEmailDomains: []string{"example.com"}}}
+
+	tests := []struct {
+		name  string
+		email string
+	}{
+		{"other domain", "mallory@evil.com"},
+		{"uppercase other domain", "Mallory@EVIL.COM"},
+		{"subdomain", "alice@mail.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/auth/register", h.RegisterUser)
+			body := `{"username":"mallory","email":"` + tt.email + `","password":"correct-horse"}`
+			req := httptest.NewRequest(http.MethodPost, "/auth/register", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusForbidden {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusForbidden, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/google/uuid"
+)
+
+const (
+	autoNameWords    = 6  // Prompt words used for an auto-generated project name
+	autoNameMaxChars = 60 // Length cap before a uniqueness suffix is added
+	autoNameAttempts = 50 // Suffixes tried before giving up on a unique name
+	autoNameFallback = "Untitled animation"
+)
+
+// autoNameFromPrompt derives a project name from the first few words of the prompt, keeping only
+// letters, digits and basic punctuation inside words.
+func autoNameFromPrompt(prompt string) string {
+	words := strings.FieldsFunc(prompt, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '\'')
+	})
+	if len(words) > autoNameWords {
+		words = words[:autoNameWords]
+	}
+	name := strings.Join(words, " ")
+	if runes := []rune(name); len(runes) > autoNameMaxChars {
+		name = strings.TrimSpace(string(runes[:autoNameMaxChars]))
+	}
+	if len([]rune(name)) < 3 {
+		return autoNameFallback
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// uniqueProjectName returns base, or base with a " (n)" suffix, whichever is the first name the
+// user doesn't already have.
+func uniqueProjectName(ctx context.Context, userID uuid.UUID, base string) (string, error) {
+	name := base
+	for n := 2; n <= autoNameAttempts+1; n++ {
+		existing, err := queries.FindManimProjectByNameAndUserID(ctx, name, userID)
+		if err != nil {
+			return "", err
+		}
+		if existing == nil {
+			return name, nil
+		}
+		name = fmt.Sprintf("%s (%d)", base, n)
+	}
+	return "", fmt.Errorf("no unique name found for %q after %d attempts", base, autoNameAttempts)
+}
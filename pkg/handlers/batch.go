@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/middleware"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxBatchCreateItems caps how many projects a single batch-create request may contain.
+const maxBatchCreateItems = 50
+
+// BatchCreateItem is a single project in a batch-create request.
+// Items are validated individually (same rules as CreateProjectRequest) so that one bad
+// item can be reported without rejecting the whole batch.
+type BatchCreateItem struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Prompt      string `json:"prompt"`
+}
+
+// BatchCreateRequest defines the structure for creating many Manim projects at once.
+type BatchCreateRequest struct {
+	Items []BatchCreateItem `json:"items" binding:"required,min=1"`
+}
+
+// BatchCreateResult reports the outcome for one item of a batch-create request.
+type BatchCreateResult struct {
+	Index   int              `json:"index"`
+	Name    string           `json:"name"`
+	Status  string           `json:"status"` // "created", "failed" or "rolled_back"
+	Error   string           `json:"error,omitempty"`
+	Render  string           `json:"render,omitempty"` // With ?render=true: "queued", or "rate_limited" once the render rate limit is used up
+	Project *ProjectResponse `json:"project,omitempty"`
+}
+
+// validateBatchItem applies the CreateProjectRequest rules to a batch item after trimming.
+func validateBatchItem(item BatchCreateItem) string {
+	name := strings.TrimSpace(item.Name)
+	prompt := strings.TrimSpace(item.Prompt)
+	switch {
+	case len(name) < 3 || len(name) > 255:
+		return "name must be between 3 and 255 characters"
+	case len(prompt) < 10:
+		return "prompt must be at least 10 characters"
+	}
+	return ""
+}
+
+// BatchCreateManimProjects creates several projects in one transaction.
+// By default, items that fail validation or conflict with an existing name are reported and
+// skipped while the rest are created (each insert runs in its own savepoint). With ?atomic=true
+// any failure rolls back the whole batch. With ?render=true every created project is queued
+// for rendering once the transaction commits, as long as the caller's render rate limit allows.
+func (h *Handlers) BatchCreateManimProjects(c *gin.Context) {
+	var req BatchCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("BatchCreateManimProjects: Invalid request body: %v", err)
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", utils.BindingErrorDetails(err))
+		return
+	}
+	if len(req.Items) > maxBatchCreateItems {
+		utils.ResponseWithError(c, http.StatusBadRequest, fmt.Sprintf("A batch may contain at most %d items", maxBatchCreateItems), nil)
+		return
+	}
+
+	claims, exists := middleware.GetUserClaimsFromContext(c)
+	if !exists {
+		log.Error("BatchCreateManimProjects: User claims not found in context.")
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+		return
+	}
+
+	atomic := c.Query("atomic") == "true"
+	render := c.Query("render") == "true"
+	if render && !h.Config.RendererEnabled {
+		utils.ResponseWithErrorCode(c, http.StatusNotImplemented, middleware.CodeRendererDisabled, "Rendering is disabled on this deployment. Create the projects without ?render=true.", nil)
+		return
+	}
+
+	tx, err := db.DB.BeginTxx(c.Request.Context(), nil)
+	if err != nil {
+		log.Errorf("BatchCreateManimProjects: Failed to begin transaction: %v", err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to create Manim projects", nil)
+		return
+	}
+	defer tx.Rollback() // No-op once committed
+	ctx := db.WithTx(c.Request.Context(), tx)
+
+	results := make([]BatchCreateResult, len(req.Items))
+	created := make([]*db.ManimProject, 0, len(req.Items))
+	seenNames := make(map[string]bool, len(req.Items))
+
+	for i, item := range req.Items {
+		name := strings.TrimSpace(item.Name)
+		results[i] = BatchCreateResult{Index: i, Name: name, Status: "failed"}
+
+		if msg := validateBatchItem(item); msg != "" {
+			results[i].Error = msg
+		} else if seenNames[name] {
+			results[i].Error = "duplicate name within batch"
+		} else {
+			seenNames[name] = true
+			existing, err := queries.FindManimProjectByNameAndUserID(ctx, name, claims.UserID)
+			if err != nil && err != sql.ErrNoRows {
+				log.Errorf("BatchCreateManimProjects: Database error checking existing project '%s': %v", name, err)
+				results[i].Error = "failed to check project existence"
+			} else if existing != nil {
+				results[i].Error = "project with this name already exists for your account"
+			} else {
+				project := &db.ManimProject{
+					UserID:       claims.UserID,
+					Name:         name,
+					Description:  strings.TrimSpace(item.Description),
+					Prompt:       strings.TrimSpace(item.Prompt),
+					RenderStatus: "pending",
+					VideoURL:     sql.NullString{Valid: false},
+				}
+				err := db.Savepoint(ctx, "batch_item", func() error {
+					_, err := queries.CreateManimProject(ctx, project)
+					return err
+				})
+				if err != nil {
+					log.Errorf("BatchCreateManimProjects: Failed to create project '%s': %v", name, err)
+					results[i].Error = "failed to create project"
+				} else {
+					pr := newProjectResponse(project)
+					results[i].Status = "created"
+					results[i].Project = &pr
+					created = append(created, project)
+				}
+			}
+		}
+
+		if atomic && results[i].Status == "failed" {
+			log.Infof("BatchCreateManimProjects: Atomic batch for user %s aborted at item %d: %s", claims.UserID.String(), i, results[i].Error)
+			for j := 0; j < i; j++ {
+				results[j].Status = "rolled_back"
+				results[j].Project = nil
+			}
+			utils.ResponseWithError(c, http.StatusBadRequest, "Batch creation aborted; no projects were created", results[:i+1])
+			return
+		}
+	}
+
+	if len(created) == 0 {
+		utils.ResponseWithError(c, http.StatusBadRequest, "No projects were created", results)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Errorf("BatchCreateManimProjects: Failed to commit batch for user %s: %v", claims.UserID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to create Manim projects", nil)
+		return
+	}
+
+	if render {
+		// Each render is a paid Gemini generation, charged to the caller's render rate limit.
+		// created holds the projects of the "created" results, in order.
+		next := 0
+		for i := range results {
+			if results[i].Status != "created" {
+				continue
+			}
+			project := created[next]
+			next++
+			if allowed, _ := h.RenderLimiter.Allow(claims.UserID); !allowed {
+				results[i].Render = "rate_limited"
+				continue
+			}
+			results[i].Render = "queued"
+			h.queueRender(project)
+		}
+	}
+
+	log.Infof("BatchCreateManimProjects: Created %d of %d projects for user %s (render=%t).", len(created), len(req.Items), claims.UserID.String(), render)
+	utils.ResponseWithSuccess(c, http.StatusCreated, fmt.Sprintf("Created %d of %d Manim projects", len(created), len(req.Items)), gin.H{
+		"created": len(created),
+		"failed":  len(req.Items) - len(created),
+		"results": results,
+	})
+}
+
+// maxBatchGetIDs caps how many projects a single batch-get request may fetch.
+const maxBatchGetIDs = 100
+
+// BatchGetRequest lists the projects to fetch with POST /api/projects/batch-get.
+type BatchGetRequest struct {
+	IDs []string `json:"ids" binding:"required,min=1"`
+}
+
+// BatchGetResponse carries the caller's projects among the requested IDs. NotFound lists the IDs
+// that are malformed, don't exist or belong to someone else; they aren't told apart so the
+// endpoint can't be used to probe for other users' projects.
+type BatchGetResponse struct {
+	Projects []interface{} `json:"projects"`
+	NotFound []string      `json:"not_found"`
+}
+
+// BatchGetManimProjects returns several of the caller's projects in one query, for clients that
+// cache the list and then need details for a subset. Like the list it accepts ?fields= and ?tz=.
+func (h *Handlers) BatchGetManimProjects(c *gin.Context) {
+	var req BatchGetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("BatchGetManimProjects: Invalid request body: %v", err)
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", utils.BindingErrorDetails(err))
+		return
+	}
+	if len(req.IDs) > maxBatchGetIDs {
+		utils.ResponseWithError(c, http.StatusBadRequest, fmt.Sprintf("A batch may request at most %d projects", maxBatchGetIDs), nil)
+		return
+	}
+	fields, err := projectFieldsFromQuery(c)
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid fields", err.Error())
+		return
+	}
+	if _, err := timezoneFromQuery(c); err != nil {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid tz", err.Error())
+		return
+	}
+
+	claims, exists := middleware.GetUserClaimsFromContext(c)
+	if !exists {
+		log.Error("BatchGetManimProjects: User claims not found in context.")
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+		return
+	}
+
+	response := BatchGetResponse{Projects: []interface{}{}, NotFound: []string{}}
+	ids := make([]uuid.UUID, 0, len(req.IDs))
+	for _, rawID := range req.IDs {
+		id, err := uuid.Parse(rawID)
+		if err != nil {
+			response.NotFound = append(response.NotFound, rawID)
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	projects := []db.ManimProject{}
+	if len(ids) > 0 {
+		projects, err = queries.FindManimProjectsByIDsAndUserID(c.Request.Context(), ids, claims.UserID)
+		if err != nil {
+			log.Errorf("BatchGetManimProjects: Failed to fetch projects for user %s: %v", claims.UserID.String(), err)
+			utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retrieve Manim projects", nil)
+			return
+		}
+	}
+
+	found := make(map[uuid.UUID]bool, len(projects))
+	responseOptions := projectResponseOptionsFromQuery(c)
+	for i := range projects {
+		found[projects[i].ID] = true
+		pr := responseOptions.render(&projects[i])
+		pr.VideoURL = h.Config.RewriteVideoURL(pr.VideoURL)
+		response.Projects = append(response.Projects, sparseProject(pr, fields))
+	}
+	for _, id := range ids {
+		if !found[id] {
+			response.NotFound = append(response.NotFound, id.String())
+		}
+	}
+
+	log.Infof("BatchGetManimProjects: Returned %d of %d requested projects for user %s.", len(projects), len(req.IDs), claims.UserID.String())
+	utils.ResponseWithSuccess(c, http.StatusOK, "Manim projects retrieved successfully", response)
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// Reasons a signed render callback is rejected.
+var (
+	errCallbackSignature = errors.New("render callback signature is missing or invalid")
+	errCallbackExpired   = errors.New("render callback URL has expired")
+	errCallbackReused    = errors.New("render callback nonce was already used or belongs to another render")
+)
+
+// callbackSignature signs the values embedded in a render callback URL.
+func callbackSignature(secret, projectID, nonce string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s.%s.%d", projectID, nonce, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// renderSignatureHeader carries the callback signature the renderer was given with the render.
+const renderSignatureHeader = "X-Render-Signature"
+
+// verifiedCallbackProjectKey is the gin context key RequireRenderCallbackSignature stores the
+// project of a verified render callback under.
+const verifiedCallbackProjectKey = "verifiedCallbackProjectID"
+
+// renderCallbackURL returns the callback URL for a render of the project and the signature the
+// renderer must send back in the X-Render-Signature header. The URL carries the project (t), a
+// one-time nonce stored on the running render job (n) and an expiry (exp); the signature is their
+// HMAC with CALLBACK_SIGNING_SECRET, tying the callback to this render.
+func (h *Handlers) renderCallbackURL(ctx context.Context, projectID uuid.UUID) (string, string, error) {
+	callbackURL := h.callbackURL("/api/projects/render-callback")
+
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", fmt.Errorf("failed to generate callback nonce: %w", err)
+	}
+	nonce := hex.EncodeToString(raw)
+	if err := queries.SetLatestRenderJobCallbackNonce(ctx, projectID, nonce); err != nil {
+		return "", "", fmt.Errorf("failed to store callback nonce: %w", err)
+	}
+
+	expires := time.Now().Add(time.Duration(h.Config.CallbackTTLSeconds) * time.Second).Unix()
+	query := url.Values{}
+	query.Set("t", projectID.String())
+	query.Set("n", nonce)
+	query.Set("exp", strconv.FormatInt(expires, 10))
+	signature := callbackSignature(h.Config.CallbackSigningSecret, projectID.String(), nonce, expires)
+	return callbackURL + "?" + query.Encode(), signature, nil
+}
+
+// RequireRenderCallbackSignature rejects render callbacks whose X-Render-Signature header doesn't
+// match the URL's parameters with 401. It runs ahead of middleware.Transaction, so forged
+// callbacks are turned away without touching the database. The verified project is stored for
+// HandleRenderCallback to match against the body.
+func (h *Handlers) RequireRenderCallbackSignature(c *gin.Context) {
+	projectID, err := h.checkRenderCallbackSignature(c)
+	if err != nil {
+		log.Warnf("RequireRenderCallbackSignature: Rejected render callback for project '%s': %v", c.Query("t"), err)
+		utils.ResponseWithError(c, http.StatusUnauthorized, "Invalid or expired callback signature", nil)
+		c.Abort()
+		return
+	}
+	c.Set(verifiedCallbackProjectKey, projectID)
+	c.Next()
+}
+
+// checkRenderCallbackSignature verifies the X-Render-Signature header of a render callback against
+// the URL's parameters and returns the project it was issued for. Without CALLBACK_SIGNING_SECRET
+// no callback can be verified, so all are rejected.
+func (h *Handlers) checkRenderCallbackSignature(c *gin.Context) (uuid.UUID, error) {
+	secret := h.Config.CallbackSigningSecret
+	if secret == "" {
+		return uuid.Nil, errCallbackSignature
+	}
+
+	projectID, err := uuid.Parse(c.Query("t"))
+	if err != nil {
+		return uuid.Nil, errCallbackSignature
+	}
+	nonce := c.Query("n")
+	expires, err := strconv.ParseInt(c.Query("exp"), 10, 64)
+	if err != nil || nonce == "" {
+		return uuid.Nil, errCallbackSignature
+	}
+	signature := c.GetHeader(renderSignatureHeader)
+	expected := callbackSignature(secret, projectID.String(), nonce, expires)
+	if signature == "" || !hmac.Equal([]byte(signature), []byte(expected)) {
+		return uuid.Nil, errCallbackSignature
+	}
+	if time.Now().Unix() > expires {
+		return uuid.Nil, errCallbackExpired
+	}
+	return projectID, nil
+}
+
+// verifiedCallbackProject reports whether RequireRenderCallbackSignature verified a callback for
+// the project.
+func verifiedCallbackProject(c *gin.Context, projectID uuid.UUID) bool {
+	verified, ok := c.Get(verifiedCallbackProjectKey)
+	return ok && verified == projectID
+}
+
+// consumeRenderCallbackNonce marks the nonce of a verified callback as used, so the same URL
+// can't be replayed. It's consumed in the request's transaction, so a callback that fails later
+// can be retried.
+func (h *Handlers) consumeRenderCallbackNonce(c *gin.Context, projectID uuid.UUID) error {
+	err := queries.ConsumeRenderJobCallbackNonce(c.Request.Context(), projectID, c.Query("n"))
+	if err == sql.ErrNoRows {
+		return errCallbackReused
+	}
+	return err
+}
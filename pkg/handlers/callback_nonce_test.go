@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/config"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestRequireRenderCallbackSignature(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const secret = "test-secret"
+	projectID := uuid.New()
+	future := time.Now().Add(time.Hour).Unix()
+	past := time.Now().Add(-time.Minute).Unix()
+
+	callbackQuery := func(project uuid.UUID, expires int64) string {
+		query := url.Values{}
+		query.Set("t", project.String())
+		query.Set("n", "nonce")
+		query.Set("exp", strconv.FormatInt(expires, 10))
+		return query.Encode()
+	}
+
+	tests := []struct {
+		name       string
+		secret     string
+		query      string
+		header     string
+		wantStatus int
+	}{
+		{"valid signature", secret, callbackQuery(projectID, future), callbackSignature(secret, projectID.String(), "nonce", future), http.StatusOK},
+		{"missing signature", secret, callbackQuery(projectID, future), "", http.StatusUnauthorized},
+		{"signature in query only", secret, callbackQuery(projectID, future) + "&sig=" + callbackSignature(secret, projectID.String(), "nonce", future), "", http.StatusUnauthorized},
+		{"signature for another project", secret, callbackQuery(projectID, future), callbackSignature(secret, uuid.NewString(), "nonce", future), http.StatusUnauthorized},
+		{"wrong secret", secret, callbackQuery(projectID, future), callbackSignature("other", projectID.String(), "nonce", future), http.StatusUnauthorized},
+		{"expired", secret, callbackQuery(projectID, past), callbackSignature(secret, projectID.String(), "nonce", past), http.StatusUnauthorized},
+		{"no signing secret configured", "", callbackQuery(projectID, future), callbackSignature("", projectID.String(), "nonce", future), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handlers{Config: &config.Config{CallbackSigningSecret: tt.secret}}
+			reached := false
+			router := gin.New()
+			router.POST("/callback", h.RequireRenderCallbackSignature, func(c *gin.Context) {
+				reached = true
+				if !verifiedCallbackProject(c, projectID) {
+					t.Error("verified project not stored in the context")
+				}
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/callback?"+tt.query, nil)
+			if tt.header != "" {
+				req.Header.Set(renderSignatureHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if reached != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("next handler reached = %t, want %t", reached, tt.wantStatus == http.StatusOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/middleware"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ChangePasswordRequest is the body of POST /api/change-password. The new password follows the
+// same length rules as registration.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=8,max=100"`
+}
+
+// ChangePassword lets the authenticated user set a new password after confirming the current one.
+// The user's refresh tokens are revoked, so other sessions must log in again once their access
+// tokens expire.
+func ChangePassword(c *gin.Context) {
+	claims, exists := middleware.GetUserClaimsFromContext(c)
+	if !exists {
+		log.Error("ChangePassword: User claims not found in context.")
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Debugf("ChangePassword: Invalid request body: %v", err)
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", utils.BindingErrorDetails(err))
+		return
+	}
+	if err := checkPasswordLength(req.NewPassword); err != nil {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Password is too long", err.Error())
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := queries.FindUserByID(ctx, claims.UserID)
+	if err != nil {
+		log.Errorf("ChangePassword: Failed to fetch user %s: %v", claims.UserID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retrieve user account", nil)
+		return
+	}
+	if user == nil {
+		utils.ResponseWithError(c, http.StatusNotFound, "User account not found", nil)
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)); err != nil {
+		log.Debugf("ChangePassword: Wrong current password for user %s.", user.ID.String())
+		utils.ResponseWithError(c, http.StatusUnauthorized, "Current password is incorrect", nil)
+		return
+	}
+	if req.NewPassword == req.CurrentPassword {
+		utils.ResponseWithError(c, http.StatusBadRequest, "New password must be different from the current password", nil)
+		return
+	}
+
+	hashedPassword, err := hashPassword(req.NewPassword)
+	if err != nil {
+		log.Errorf("ChangePassword: Error hashing password: %v", err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to change password", nil)
+		return
+	}
+	user.PasswordHash = hashedPassword
+
+	if err := queries.UpdateUser(ctx, user); err != nil {
+		log.Errorf("ChangePassword: Failed to update user %s: %v", user.ID.String(), err)
+		respondDBError(c, err, "Failed to change password")
+		return
+	}
+	if _, err := queries.RevokeUserRefreshTokens(ctx, user.ID); err != nil {
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to change password", nil)
+		return
+	}
+
+	log.Infof("ChangePassword: User %s changed their password.", user.ID.String())
+	utils.ResponseWithSuccess(c, http.StatusOK, "Password changed successfully", nil)
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/config"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/middleware"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestCreateManimProjectWithDeletedProjectID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handlers{Config: &config.Config{}}
+	caller := uuid.New()
+	projectID := uuid.New()
+
+	findLive := fakeQuery{match: "WHERE id = $1 AND deleted_at IS NULL"}
+	findDeleted := func(owner uuid.UUID) fakeQuery {
+		return fakeQuery{
+			match:   "WHERE id = $1 AND deleted_at IS NOT NULL",
+			columns: []string{"id", "user_id", "name", "prompt", "render_status"},
+			rows:    [][]driver.Value{{projectID.String(), owner.String(), "Deleted project", "Draw a circle", "completed"}},
+		}
+	}
+	notDeleted := fakeQuery{match: "WHERE id = $1 AND deleted_at IS NOT NULL"}
+
+	tests := []struct {
+		name     string
+		script   []fakeQuery
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "caller's deleted project",
+			script:   []fakeQuery{findLive, findDeleted(caller)},
+			wantCode: http.StatusConflict,
+			wantBody: "/restore",
+		},
+		{
+			name:     "another user's deleted project",
+			script:   []fakeQuery{findLive, findDeleted(uuid.New())},
+			wantCode: http.StatusConflict,
+			wantBody: "already in use",
+		},
+		{
+			name: "deleted between the check and the insert",
+			script: []fakeQuery{
+				findLive, notDeleted,
+				{match: "FROM manim_projects WHERE name = "},
+				{match: "ON CONFLICT (id) DO NOTHING", columns: []string{"id", "created_at", "updated_at", "auto_reconcile"}},
+				findLive, findDeleted(caller),
+			},
+			wantCode: http.StatusConflict,
+			wantBody: "/restore",
+		},
+		{
+			name: "conflict without any project",
+			script: []fakeQuery{
+				findLive, notDeleted,
+				{match: "FROM manim_projects WHERE name = "},
+				{match: "ON CONFLICT (id) DO NOTHING", columns: []string{"id", "created_at", "updated_at", "auto_reconcile"}},
+				findLive, notDeleted,
+			},
+			wantCode: http.StatusInternalServerError,
+			wantBody: "Failed to create project",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withFakeQueryDB(t, tt.script...)
+			router := gin.New()
+			router.POST("/api/projects", func(c *gin.Context) {
+				c.Set(middleware.UserClaimsContextKey, &services.Claims{UserID: caller})
+			}, h.CreateManimProject)
+			body, _ := json.Marshal(CreateProjectRequest{ID: projectID.String(), Name: "My project", Prompt: "Draw a blue circle"})
+			rec := postJSON(router, "/api/projects", string(body))
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %q", rec.Body.String(), tt.wantBody)
+			}
+			if tt.wantCode == http.StatusConflict && !strings.Contains(rec.Body.String(), CodeDuplicate) {
+				t.Errorf("body = %s, want code %s", rec.Body.String(), CodeDuplicate)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Machine-readable codes for writes rejected by a database constraint.
+const (
+	CodeDuplicate        = "duplicate"
+	CodeInvalidReference = "invalid_reference"
+	CodeInvalidValue     = "invalid_value"
+)
+
+// respondDBError writes the response for a failed database write. Constraint violations are caused
+// by the request, so they become 409 (duplicate) or 400 (bad reference or value); anything else is
+// a 500 with fallbackMessage. Callers log the error themselves.
+func respondDBError(c *gin.Context, err error, fallbackMessage string) {
+	switch {
+	case errors.Is(err, db.ErrDuplicate):
+		utils.ResponseWithErrorCode(c, http.StatusConflict, CodeDuplicate, "A record with the same unique value already exists", nil)
+	case errors.Is(err, db.ErrForeignKey):
+		utils.ResponseWithErrorCode(c, http.StatusBadRequest, CodeInvalidReference, "The request references a record that does not exist", nil)
+	case errors.Is(err, db.ErrNotNull), errors.Is(err, db.ErrCheck), errors.Is(err, db.ErrInvalidInput):
+		utils.ResponseWithErrorCode(c, http.StatusBadRequest, CodeInvalidValue, "The request contains an invalid value", nil)
+	default:
+		utils.ResponseWithError(c, http.StatusInternalServerError, fallbackMessage, nil)
+	}
+}
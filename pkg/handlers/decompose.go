@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/llm"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// renderStatusDecomposed marks a project whose prompt was split into child projects, one per scene,
+// while those scenes render. It becomes "completed" once every scene has completed, or
+// "failed: scene_failed" once every scene has finished and at least one failed.
+const renderStatusDecomposed = "decomposed"
+
+// sceneFailedStatus is the status of a decomposed project with a failed scene.
+const sceneFailedStatus = "failed: scene_failed"
+
+// isComplexityFailure reports whether a failed render looks like the prompt was too much for a
+// single scene: Gemini returned no usable or complete scene, or the renderer rejected the script as too large.
+func isComplexityFailure(project *db.ManimProject, rerr *renderError) bool {
+	if errors.Is(rerr.Err, llm.ErrMissingSceneClass) || errors.Is(rerr.Err, llm.ErrTruncatedOutput) || llm.IsEmptyResponse(rerr.Err) {
+		return true
+	}
+	return project.RenderStatus == "failed: renderer_status_413"
+}
+
+// decomposeProject splits the project's prompt into scenes, creates a child project for each and
+// queues them for rendering, marking the parent as decomposed. It returns the children, or nil
+// when the prompt couldn't be split into at least two scenes; the parent's status is then left
+// as it was.
+func (h *Handlers) decomposeProject(ctx context.Context, project *db.ManimProject) []*db.ManimProject {
+	prompts, err := h.LLMClient.DecomposePrompt(ctx, project.Prompt)
+	if err != nil {
+		log.Errorf("decomposeProject: Failed to decompose prompt of project %s: %v", project.ID.String(), err)
+		return nil
+	}
+	if len(prompts) < 2 {
+		log.Infof("decomposeProject: Prompt of project %s did not split into multiple scenes.", project.ID.String())
+		return nil
+	}
+
+	tx, err := db.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Errorf("decomposeProject: Failed to begin transaction: %v", err)
+		return nil
+	}
+	defer tx.Rollback() // No-op once committed
+	txCtx := db.WithTx(ctx, tx)
+
+	children := make([]*db.ManimProject, 0, len(prompts))
+	for i, prompt := range prompts {
+		name, err := uniqueProjectName(txCtx, project.UserID, fmt.Sprintf("%s - scene %d", project.Name, i+1))
+		if err != nil {
+			log.Errorf("decomposeProject: Failed to name scene %d of project %s: %v", i+1, project.ID.String(), err)
+			return nil
+		}
+		child := &db.ManimProject{
+			UserID:          project.UserID,
+			Name:            name,
+			Description:     project.Description,
+			Prompt:          prompt,
+			RenderStatus:    "pending",
+			ParentProjectID: sql.NullString{String: project.ID.String(), Valid: true},
+			Model:           project.Model,
+			Resolution:      project.Resolution,
+			FPS:             project.FPS,
+		}
+		if _, err := queries.CreateManimProject(txCtx, child); err != nil {
+			log.Errorf("decomposeProject: Failed to create scene %d of project %s: %v", i+1, project.ID.String(), err)
+			return nil
+		}
+		children = append(children, child)
+	}
+
+	previousStatus := project.RenderStatus
+	project.RenderStatus = renderStatusDecomposed
+	if err := queries.UpdateManimProject(txCtx, project); err != nil {
+		log.Errorf("decomposeProject: Failed to mark project %s as decomposed: %v", project.ID.String(), err)
+		project.RenderStatus = previousStatus
+		return nil
+	}
+	if err := tx.Commit(); err != nil {
+		log.Errorf("decomposeProject: Failed to commit decomposition of project %s: %v", project.ID.String(), err)
+		project.RenderStatus = previousStatus
+		return nil
+	}
+
+	for _, child := range children {
+		h.queueRender(child)
+	}
+	log.Infof("Project %s decomposed into %d child projects.", project.ID.String(), len(children))
+	return children
+}
+
+// respondDecomposed answers a render request that was turned into one render per scene.
+func respondDecomposed(c *gin.Context, projectID uuid.UUID, children []*db.ManimProject, message string) {
+	scenes := make([]ProjectResponse, len(children))
+	for i, child := range children {
+		scenes[i] = newProjectResponse(child)
+	}
+	utils.ResponseWithSuccessCode(c, http.StatusAccepted, CodeRenderDecomposed, "Rendering the prompt as multiple scenes", gin.H{
+		"project_id": projectID.String(),
+		"status":     renderStatusDecomposed,
+		"message":    message,
+		"children":   scenes,
+	})
+}
+
+// aggregateSceneStatus derives a decomposed project's status from its scenes: decomposed while
+// any is still pending or rendering, then completed if all completed, otherwise scene_failed.
+func aggregateSceneStatus(children []db.ManimProject) string {
+	failed := false
+	for _, child := range children {
+		switch {
+		case child.RenderStatus == "completed":
+		case strings.HasPrefix(child.RenderStatus, "failed"):
+			failed = true
+		default:
+			return renderStatusDecomposed
+		}
+	}
+	if failed {
+		return sceneFailedStatus
+	}
+	return "completed"
+}
+
+// updateDecomposedParent recomputes the status of the project that child is a scene of, after
+// child's render finished. Parents that weren't decomposed (e.g. of style variants) are left
+// alone. The parent row is locked so concurrent scene callbacks each see the others' results.
+func updateDecomposedParent(ctx context.Context, child *db.ManimProject) error {
+	if !child.ParentProjectID.Valid {
+		return nil
+	}
+	parentID, err := uuid.Parse(child.ParentProjectID.String)
+	if err != nil {
+		return fmt.Errorf("invalid parent project ID %q: %w", child.ParentProjectID.String, err)
+	}
+
+	if _, inTx := db.TxFromContext(ctx); !inTx {
+		tx, err := db.DB.BeginTxx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback() // No-op once committed
+		if err := updateDecomposedParent(db.WithTx(ctx, tx), child); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	parent, err := queries.FindManimProjectByIDForUpdate(ctx, parentID)
+	if err != nil || parent == nil {
+		return err
+	}
+	if parent.RenderStatus != renderStatusDecomposed && parent.RenderStatus != sceneFailedStatus {
+		return nil
+	}
+	children, err := queries.FindManimProjectsByParentID(ctx, parentID)
+	if err != nil {
+		return err
+	}
+	status := aggregateSceneStatus(children)
+	if status == parent.RenderStatus {
+		return nil
+	}
+	parent.RenderStatus = status
+	if err := queries.UpdateManimProject(ctx, parent); err != nil {
+		return err
+	}
+	log.Infof("Decomposed project %s is now %q.", parentID.String(), status)
+	return nil
+}
+
+// GetProjectChildren lists the sub-projects of one of the caller's projects, oldest first: the
+// scenes of a decomposed project and any style variants. Like the list it accepts ?tz=.
+func (h *Handlers) GetProjectChildren(c *gin.Context) {
+	if _, err := timezoneFromQuery(c); err != nil {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid tz", err.Error())
+		return
+	}
+	project, _, ok := loadOwnedProject(c, "GetProjectChildren")
+	if !ok {
+		return
+	}
+
+	children, err := queries.FindManimProjectsByParentID(c.Request.Context(), project.ID)
+	if err != nil {
+		log.Errorf("GetProjectChildren: Failed to fetch children of project %s: %v", project.ID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retrieve child projects", nil)
+		return
+	}
+
+	responseOptions := projectResponseOptionsFromQuery(c)
+	responses := make([]ProjectResponse, len(children))
+	for i := range children {
+		responses[i] = responseOptions.render(&children[i])
+		responses[i].VideoURL = h.Config.RewriteVideoURL(responses[i].VideoURL)
+	}
+	utils.ResponseWithSuccess(c, http.StatusOK, "Child projects retrieved successfully", gin.H{
+		"project_id":    project.ID.String(),
+		"render_status": project.RenderStatus,
+		"children":      responses,
+	})
+}
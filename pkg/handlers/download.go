@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/storage"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetProjectDownloadURL returns a short-lived presigned URL for the project's rendered video,
+// so videos in private buckets can be downloaded without exposing a long-lived public link.
+func (h *Handlers) GetProjectDownloadURL(c *gin.Context) {
+	if h.Storage == nil {
+		log.Warn("GetProjectDownloadURL: Storage client is not configured.")
+		utils.ResponseWithError(c, http.StatusNotImplemented, "Direct downloads are not configured on this server", nil)
+		return
+	}
+
+	project, claims, ok := loadOwnedProject(c, "GetProjectDownloadURL")
+	if !ok {
+		return
+	}
+	if !project.VideoURL.Valid || project.VideoURL.String == "" {
+		utils.ResponseWithError(c, http.StatusNotFound, "This project has no rendered video", nil)
+		return
+	}
+
+	key, err := h.Storage.KeyFromURL(project.VideoURL.String)
+	if err != nil {
+		log.Errorf("GetProjectDownloadURL: Cannot derive object key for project %s: %v", project.ID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to create download URL", nil)
+		return
+	}
+	ttl := time.Duration(h.Config.DownloadURLTTLSeconds) * time.Second
+	downloadURL, err := h.Storage.PresignGet(key, ttl)
+	if err != nil {
+		log.Errorf("GetProjectDownloadURL: Failed to presign %s for project %s: %v", key, project.ID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to create download URL", nil)
+		return
+	}
+
+	log.Infof("Issued download URL for project %s to user %s.", project.ID.String(), claims.UserID.String())
+	utils.ResponseWithSuccess(c, http.StatusOK, "Download URL created", gin.H{
+		"download_url": downloadURL,
+		"expires_at":   time.Now().Add(ttl).UTC().Format(http.TimeFormat),
+	})
+}
+
+// HeadProjectDownload answers HEAD on the download endpoint with the rendered video's
+// Content-Length and Content-Type, read from the R2 object's own HEAD, so link checkers can
+// confirm a video exists without fetching it. Like every HEAD response it has no body.
+func (h *Handlers) HeadProjectDownload(c *gin.Context) {
+	if h.Storage == nil {
+		c.Status(http.StatusNotImplemented)
+		return
+	}
+	project, _, ok := loadOwnedProject(c, "HeadProjectDownload")
+	if !ok {
+		return
+	}
+	if !project.VideoURL.Valid || project.VideoURL.String == "" {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	key, err := h.Storage.KeyFromURL(project.VideoURL.String)
+	if err != nil {
+		log.Errorf("HeadProjectDownload: Cannot derive object key for project %s: %v", project.ID.String(), err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	info, err := h.Storage.Head(c.Request.Context(), key)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		log.Warnf("HeadProjectDownload: Video object %s of project %s is missing.", key, project.ID.String())
+		c.Status(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Errorf("HeadProjectDownload: Failed to check video object %s of project %s: %v", key, project.ID.String(), err)
+		c.Status(http.StatusBadGateway)
+		return
+	}
+
+	c.Header("Content-Length", strconv.FormatInt(info.Size, 10))
+	if info.ContentType != "" {
+		c.Header("Content-Type", info.ContentType)
+	}
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetEffectivePrompt returns the fully assembled prompt that generate-render would send to
+// Gemini for the project, without calling Gemini.
+func (h *Handlers) GetEffectivePrompt(c *gin.Context) {
+	project, _, ok := loadOwnedProject(c, "GetEffectivePrompt")
+	if !ok {
+		return
+	}
+
+	effectivePrompt := h.LLMClient.EffectivePrompt(project.Prompt)
+	log.Debugf("GetEffectivePrompt: Assembled prompt for project %s (%d bytes).", project.ID.String(), len(effectivePrompt))
+	utils.ResponseWithSuccess(c, http.StatusOK, "Effective prompt assembled", gin.H{
+		"project_id":       project.ID.String(),
+		"user_prompt":      project.Prompt,
+		"effective_prompt": effectivePrompt,
+	})
+}
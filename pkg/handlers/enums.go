@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Project render statuses. Failed renders are stored as "failed: <reason>".
+var renderStatusValues = []string{"pending", "generating", "rendering", "completed", "failed", renderStatusDecomposed}
+
+// renderFailureReasons are the reasons the API itself records after "failed: ". Renderer
+// failures add "renderer_status_<code>" and the renderer may report its own statuses.
+var renderFailureReasons = []string{
+	"code_gen_error", CodeLLMUnavailable, CodeTruncatedOutput, "renderer_req_error", "renderer_comm_error",
+	"missing_video_url", "render_timeout", "cancelled", "scene_failed",
+}
+
+// stringSet builds a lookup set from a list of allowed values.
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+// GetEnums lists the enumerable values the API recognizes, so clients don't have to hard-code them.
+func GetEnums(c *gin.Context) {
+	utils.ResponseWithSuccess(c, http.StatusOK, "Enums retrieved successfully", gin.H{
+		"render_statuses":        renderStatusValues,
+		"render_failure_reasons": renderFailureReasons,
+		"render_job_statuses":    []string{queries.RenderJobRunning, queries.RenderJobCompleted, queries.RenderJobFailed},
+		"merge_job_statuses":     []string{queries.MergeJobRunning, queries.MergeJobCompleted, queries.MergeJobFailed},
+		"qualities":              presetQualityValues,
+		"formats":                presetFormatValues,
+		"resolutions":            []string{"480p", "720p", "1080p"},
+		"frame_rates":            []int{30, 60},
+		"roles":                  []string{"user", "admin"},
+		"timeline_intervals":     []string{"hour", "day", "week"},
+	})
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Machine-readable codes for missing and forbidden resources.
+const (
+	CodeNotFound  = "not_found"
+	CodeForbidden = "forbidden"
+)
+
+// Errors for requests naming a project the caller can't use. respondForError maps them to
+// 400, 404 and 403 respectively.
+var (
+	errInvalidProjectID = errors.New("invalid project ID format")
+	errProjectNotFound  = errors.New("manim project not found")
+	errNotProjectOwner  = errors.New("project belongs to another user")
+)
+
+// findOwnedProject loads the project with the given ID, returning errInvalidProjectID,
+// errProjectNotFound or errNotProjectOwner (wrapped with details for the log) when the caller
+// can't use it.
+func findOwnedProject(ctx context.Context, projectIDParam string, userID uuid.UUID) (*db.ManimProject, error) {
+	projectID, err := uuid.Parse(projectIDParam)
+	if err != nil {
+		return nil, fmt.Errorf("%w: '%s'", errInvalidProjectID, projectIDParam)
+	}
+	project, err := queries.FindManimProjectByID(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch project %s: %w", projectID.String(), err)
+	}
+	if project == nil {
+		return nil, fmt.Errorf("%w: %s", errProjectNotFound, projectID.String())
+	}
+	if project.UserID != userID {
+		return nil, fmt.Errorf("%w: user %s attempted to access project %s owned by %s",
+			errNotProjectOwner, userID.String(), projectID.String(), project.UserID.String())
+	}
+	return project, nil
+}
+
+// isClientError reports whether respondForError would answer err with a 4xx, i.e. the request
+// rather than the server is at fault.
+func isClientError(err error) bool {
+	return errors.Is(err, errInvalidProjectID) || errors.Is(err, errProjectNotFound) ||
+		errors.Is(err, errNotProjectOwner) || errors.Is(err, sql.ErrNoRows) ||
+		errors.Is(err, queries.ErrProjectLocked) || db.IsConstraintError(err)
+}
+
+// respondForError writes the response for an error from loading or writing a project, so every
+// handler answers the same failure with the same status, code and message. Anything it doesn't
+// recognize is a 500. Callers log the error themselves.
+func respondForError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errInvalidProjectID):
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid project ID format", nil)
+	case errors.Is(err, errProjectNotFound), errors.Is(err, sql.ErrNoRows):
+		utils.ResponseWithErrorCode(c, http.StatusNotFound, CodeNotFound, "Manim project not found", nil)
+	case errors.Is(err, errNotProjectOwner):
+		utils.ResponseWithErrorCode(c, http.StatusForbidden, CodeForbidden, "You do not have permission to access this project", nil)
+	case errors.Is(err, queries.ErrProjectLocked):
+		respondProjectLocked(c)
+	default:
+		respondDBError(c, err, "An internal error occurred")
+	}
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+)
+
+// projectListETag builds a weak ETag for a project list from the user's project set version and
+// the query string (filters and response options change the body too).
+func projectListETag(version *queries.ProjectSetVersion, rawQuery string) string {
+	var lastUpdated int64
+	if version.LastUpdated.Valid {
+		lastUpdated = version.LastUpdated.Time.UnixNano()
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%d:%s", version.Count, lastUpdated, rawQuery)))
+	return `W/"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header matches etag, using weak comparison.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
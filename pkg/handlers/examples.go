@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/middleware"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// ExamplePrompt is a curated prompt known to render well, shown to new users as a starting point.
+type ExamplePrompt struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Prompt      string   `json:"prompt"`
+	Features    []string `json:"features"` // Manim features the example exercises
+}
+
+// examplePrompts are served by GET /api/examples. The first two are the examples baked into the
+// code-generation template in pkg/llm.
+var examplePrompts = []ExamplePrompt{
+	{
+		ID:          "square",
+		Title:       "Red square",
+		Description: "The simplest possible scene: one shape fading in.",
+		Prompt:      "create a square",
+		Features:    []string{"shapes", "fade"},
+	},
+	{
+		ID:          "flower",
+		Title:       "Flower from circles",
+		Description: "Builds a drawing from many shapes with staggered animations.",
+		Prompt:      "Create a flower using circles. It should have a yellow center and pink petals. Also, add a green stem and a leaf.",
+		Features:    []string{"shapes", "groups", "lagged animations", "colors"},
+	},
+	{
+		ID:          "pythagoras",
+		Title:       "Pythagorean theorem",
+		Description: "A right triangle with squares on each side and the equation written out.",
+		Prompt:      "Draw a right triangle with sides labeled a, b and c. Grow a blue square on each leg and a red square on the hypotenuse, then write the equation a^2 + b^2 = c^2 below it.",
+		Features:    []string{"shapes", "labels", "LaTeX"},
+	},
+	{
+		ID:          "sine-wave",
+		Title:       "Sine wave on axes",
+		Description: "Plots a function on coordinate axes and traces it with a moving dot.",
+		Prompt:      "Show x and y axes, plot y = sin(x) from -2π to 2π in yellow, then move a white dot along the curve from left to right.",
+		Features:    []string{"axes", "graphs", "path animation"},
+	},
+	{
+		ID:          "transform",
+		Title:       "Shape morphing",
+		Description: "Smoothly transforms one shape into another.",
+		Prompt:      "Create a blue circle, transform it into a green square, then into an orange triangle, pausing for one second after each change.",
+		Features:    []string{"shapes", "transforms"},
+	},
+	{
+		ID:          "title-card",
+		Title:       "Title card",
+		Description: "Animated text, useful as an intro or outro for a longer video.",
+		Prompt:      "Write the text 'Linear Algebra' in large white letters, underline it with a yellow line drawn from left to right, then fade both out.",
+		Features:    []string{"text", "lines", "fade"},
+	},
+	{
+		ID:          "vector-addition",
+		Title:       "Vector addition",
+		Description: "Two arrows added tip to tail on a number plane.",
+		Prompt:      "On a number plane, draw a red arrow from the origin to (2, 1) and a blue arrow from (2, 1) to (3, 3). Then draw their sum as a green arrow from the origin to (3, 3).",
+		Features:    []string{"number plane", "vectors", "colors"},
+	},
+}
+
+// findExamplePrompt returns the example with the given ID, or nil.
+func findExamplePrompt(id string) *ExamplePrompt {
+	for i := range examplePrompts {
+		if examplePrompts[i].ID == id {
+			return &examplePrompts[i]
+		}
+	}
+	return nil
+}
+
+// GetExamples lists curated example prompts for a prompt gallery.
+func GetExamples(c *gin.Context) {
+	utils.ResponseWithSuccess(c, http.StatusOK, "Examples retrieved successfully", examplePrompts)
+}
+
+// CreateProjectFromExample creates a project for the caller from an example prompt, named after
+// the example (with a numeric suffix if the caller already has a project by that name).
+func CreateProjectFromExample(c *gin.Context) {
+	example := findExamplePrompt(c.Param("id"))
+	if example == nil {
+		utils.ResponseWithError(c, http.StatusNotFound, "Example not found", nil)
+		return
+	}
+
+	claims, exists := middleware.GetUserClaimsFromContext(c)
+	if !exists {
+		log.Error("CreateProjectFromExample: User claims not found in context.")
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+		return
+	}
+
+	name, err := uniqueProjectName(c.Request.Context(), claims.UserID, example.Title)
+	if err != nil {
+		log.Errorf("CreateProjectFromExample: Failed to name project: %v", err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to generate a project name", nil)
+		return
+	}
+	project, err := queries.CreateManimProject(c.Request.Context(), &db.ManimProject{
+		UserID:       claims.UserID,
+		Name:         name,
+		Description:  example.Description,
+		Prompt:       example.Prompt,
+		RenderStatus: "pending",
+		VideoURL:     sql.NullString{Valid: false},
+	})
+	if err != nil {
+		log.Errorf("CreateProjectFromExample: Failed to create project from example '%s': %v", example.ID, err)
+		respondForError(c, err)
+		return
+	}
+
+	log.Infof("Project %s created from example '%s' for user %s.", project.ID.String(), example.ID, claims.UserID.String())
+	utils.ResponseWithSuccess(c, http.StatusCreated, "Manim project created successfully", projectResponseOptionsFromQuery(c).render(project))
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/middleware"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// exportFlushEvery controls how many entries are written between flushes of the export stream.
+const exportFlushEvery = 50
+
+// ExportProfile is the user profile section of a data export. The password hash is never included.
+type ExportProfile struct {
+	ID        string `json:"id"`
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// ExportProject is a single project entry in a data export.
+type ExportProject struct {
+	ProjectResponse
+	ParentProjectID string `json:"parent_project_id,omitempty"`
+	Code            string `json:"code,omitempty"` // The Manim script last generated for the project
+}
+
+// ExportMergedVideo is a single merged video entry in a data export.
+type ExportMergedVideo struct {
+	ID        string `json:"id"`
+	VideoURL  string `json:"video_url"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// ExportRenderJob is a single render attempt in a data export.
+type ExportRenderJob struct {
+	ID           string `json:"id"`
+	ProjectID    string `json:"project_id"`
+	Status       string `json:"status"`
+	Error        string `json:"error,omitempty"`
+	StartedAt    string `json:"started_at"`
+	FinishedAt   string `json:"finished_at,omitempty"`
+	InputTokens  int64  `json:"input_tokens,omitempty"`
+	OutputTokens int64  `json:"output_tokens,omitempty"`
+}
+
+// exportSection writes the entries of one JSON array of the export, flushing every exportFlushEvery.
+type exportSection struct {
+	w     gin.ResponseWriter
+	count int
+}
+
+// write appends v to the array.
+func (s *exportSection) write(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if s.count > 0 {
+		if _, err := s.w.Write([]byte(",")); err != nil {
+			return err
+		}
+	}
+	if _, err := s.w.Write(data); err != nil {
+		return err
+	}
+	s.count++
+	if s.count%exportFlushEvery == 0 {
+		s.w.Flush()
+	}
+	return nil
+}
+
+// ExportUserData streams everything stored about the authenticated user as a single JSON document:
+// their profile, every project with its generated code, their merged videos and their render history.
+// Entries are written one at a time so memory use stays flat regardless of how much the user has.
+func ExportUserData(c *gin.Context) {
+	claims, exists := middleware.GetUserClaimsFromContext(c)
+	if !exists {
+		log.Error("ExportUserData: User claims not found in context.")
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+		return
+	}
+
+	// Load the profile before streaming so lookup failures still produce a proper error response.
+	ctx := c.Request.Context()
+	user, err := queries.FindUserByID(ctx, claims.UserID)
+	if err != nil {
+		log.Errorf("ExportUserData: Failed to fetch user %s: %v", claims.UserID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to export user data", nil)
+		return
+	}
+	if user == nil {
+		log.Warnf("ExportUserData: User %s from token not found in DB.", claims.UserID.String())
+		utils.ResponseWithError(c, http.StatusNotFound, "User account not found", nil)
+		return
+	}
+
+	profile, _ := json.Marshal(ExportProfile{
+		ID:        user.ID.String(),
+		Username:  user.Username,
+		Email:     user.Email,
+		CreatedAt: user.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt: user.UpdatedAt.UTC().Format(time.RFC3339),
+	})
+
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="manim-export-%s.json"`, user.ID.String()))
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	fmt.Fprintf(w, `{"exported_at":%q,"profile":%s,"projects":[`, time.Now().UTC().Format(time.RFC3339), profile)
+
+	// Headers are already sent once streaming starts, so on error the best we can do is stop and
+	// leave the document truncated (invalid JSON), which clients will detect.
+	projects := &exportSection{w: w}
+	err = queries.StreamManimProjectsWithCodeByUserID(ctx, user.ID, func(project *db.ManimProject, code string) error {
+		entry := ExportProject{ProjectResponse: newProjectResponse(project), Code: code}
+		if project.ParentProjectID.Valid {
+			entry.ParentProjectID = project.ParentProjectID.String
+		}
+		return projects.write(entry)
+	})
+	if err != nil {
+		log.Errorf("ExportUserData: Export for user %s aborted after %d projects: %v", user.ID.String(), projects.count, err)
+		return
+	}
+
+	w.Write([]byte(`],"merged_videos":[`))
+	mergedVideos := &exportSection{w: w}
+	err = queries.StreamMergedVideosByUser(ctx, user.ID, func(merged *db.MergedVideo) error {
+		return mergedVideos.write(ExportMergedVideo{
+			ID:        merged.ID.String(),
+			VideoURL:  merged.R2URL,
+			Status:    merged.Status,
+			Error:     merged.Error.String,
+			CreatedAt: merged.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	})
+	if err != nil {
+		log.Errorf("ExportUserData: Export for user %s aborted after %d merged videos: %v", user.ID.String(), mergedVideos.count, err)
+		return
+	}
+
+	w.Write([]byte(`],"render_jobs":[`))
+	renderJobs := &exportSection{w: w}
+	err = queries.StreamRenderJobsByUser(ctx, user.ID, func(job *db.RenderJob) error {
+		entry := ExportRenderJob{
+			ID:           job.ID.String(),
+			ProjectID:    job.ProjectID.String(),
+			Status:       job.Status,
+			Error:        job.Error.String,
+			StartedAt:    job.StartedAt.UTC().Format(time.RFC3339),
+			InputTokens:  job.InputTokens.Int64,
+			OutputTokens: job.OutputTokens.Int64,
+		}
+		if job.FinishedAt.Valid {
+			entry.FinishedAt = job.FinishedAt.Time.UTC().Format(time.RFC3339)
+		}
+		return renderJobs.write(entry)
+	})
+	if err != nil {
+		log.Errorf("ExportUserData: Export for user %s aborted after %d render jobs: %v", user.ID.String(), renderJobs.count, err)
+		return
+	}
+
+	w.Write([]byte("]}"))
+	w.Flush()
+	log.Infof("ExportUserData: Exported profile, %d projects, %d merged videos and %d render jobs for user %s.",
+		projects.count, mergedVideos.count, renderJobs.count, user.ID.String())
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/middleware"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// csvFlushEvery controls how many rows are buffered before flushing to the client.
+const csvFlushEvery = 100
+
+// ExportProjectsCSV streams the caller's projects as a CSV file, one row per project, accepting
+// the same filters as the project list. Rows are written as they're read from the database.
+func (h *Handlers) ExportProjectsCSV(c *gin.Context) {
+	claims, exists := middleware.GetUserClaimsFromContext(c)
+	if !exists {
+		log.Error("ExportProjectsCSV: User claims not found in context.")
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+		return
+	}
+
+	filter, err := projectFilterFromQuery(c)
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid filter", err.Error())
+		return
+	}
+
+	filename := fmt.Sprintf("projects-%s.csv", time.Now().UTC().Format("20060102"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"id", "name", "status", "created_at", "video_url"})
+
+	count := 0
+	err = queries.StreamManimProjectsByUserID(c.Request.Context(), claims.UserID, filter, func(project *db.ManimProject) error {
+		videoURL := ""
+		if project.VideoURL.Valid {
+			videoURL = h.Config.RewriteVideoURL(project.VideoURL.String)
+		}
+		if err := w.Write([]string{
+			project.ID.String(),
+			csvSafe(project.Name),
+			project.RenderStatus,
+			project.CreatedAt.UTC().Format(time.RFC3339),
+			videoURL,
+		}); err != nil {
+			return err
+		}
+		count++
+		if count%csvFlushEvery == 0 {
+			w.Flush()
+			c.Writer.Flush()
+		}
+		return w.Error()
+	})
+	w.Flush()
+	if err != nil {
+		// Headers are already sent, so the best we can do is stop and log; the file will be truncated.
+		log.Errorf("ExportProjectsCSV: Export for user %s aborted after %d rows: %v", claims.UserID.String(), count, err)
+		return
+	}
+	log.Infof("ExportProjectsCSV: Exported %d projects for user %s.", count, claims.UserID.String())
+}
+
+// csvSafe neutralizes user-controlled cells that spreadsheet apps would evaluate as formulas.
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/jmoiron/sqlx"
+)
+
+// fakeQuery is one scripted answer of fakeQueryDriver: the statement must contain match, and
+// the query returns columns and rows (none for an empty result).
+type fakeQuery struct {
+	match   string
+	columns []string
+	rows    [][]driver.Value
+}
+
+// fakeQueryDriver is a database connector that answers queries from a script, in order, and
+// fails the test on any statement it didn't expect.
+type fakeQueryDriver struct {
+	t      *testing.T
+	script []fakeQuery
+}
+
+func (d *fakeQueryDriver) Connect(context.Context) (driver.Conn, error) {
+	return &fakeQueryConn{d: d}, nil
+}
+func (d *fakeQueryDriver) Driver() driver.Driver { return nil }
+
+// next pops the scripted answer for query.
+func (d *fakeQueryDriver) next(query string) (fakeQuery, error) {
+	if len(d.script) == 0 {
+		d.t.Errorf("unexpected statement: %s", query)
+		return fakeQuery{}, errors.New("unexpected statement")
+	}
+	answer := d.script[0]
+	d.script = d.script[1:]
+	if !strings.Contains(query, answer.match) {
+		d.t.Errorf("statement %q does not contain %q", query, answer.match)
+		return fakeQuery{}, errors.New("unexpected statement")
+	}
+	return answer, nil
+}
+
+type fakeQueryConn struct{ d *fakeQueryDriver }
+
+func (c *fakeQueryConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *fakeQueryConn) Close() error                        { return nil }
+func (c *fakeQueryConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c *fakeQueryConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	answer, err := c.d.next(query)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{columns: answer.columns, rows: answer.rows}, nil
+}
+
+func (c *fakeQueryConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if _, err := c.d.next(query); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// withFakeQueryDB points db.DB at a driver answering script for the duration of the test, and
+// checks that every scripted statement ran.
+func withFakeQueryDB(t *testing.T, script ...fakeQuery) {
+	t.Helper()
+	d := &fakeQueryDriver{t: t, script: script}
+	previous := db.DB
+	db.DB = sqlx.NewDb(sql.OpenDB(d), "postgres")
+	t.Cleanup(func() {
+		db.DB.Close()
+		db.DB = previous
+		if len(d.script) > 0 {
+			t.Errorf("%d scripted statements never ran, next: %q", len(d.script), d.script[0].match)
+		}
+	})
+}
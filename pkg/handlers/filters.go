@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/gin-gonic/gin"
+)
+
+// projectFilterFromQuery builds the project list filter shared by the list and export endpoints:
+// ?status=, ?created_from=, ?created_to= (RFC 3339 or YYYY-MM-DD) and ?metadata.<key>=<value>.
+func projectFilterFromQuery(c *gin.Context) (queries.ProjectFilter, error) {
+	var filter queries.ProjectFilter
+
+	metadataFilters, err := metadataFiltersFromQuery(c.Request.URL.Query())
+	if err != nil {
+		return filter, err
+	}
+	filter.Metadata = metadataFilters
+	filter.Status = c.Query("status")
+
+	if raw := c.Query("created_from"); raw != "" {
+		if filter.CreatedAfter, err = parseQueryTime(raw); err != nil {
+			return filter, fmt.Errorf("invalid created_from %q: use RFC 3339 or YYYY-MM-DD", raw)
+		}
+	}
+	if raw := c.Query("created_to"); raw != "" {
+		if filter.CreatedBefore, err = parseQueryTime(raw); err != nil {
+			return filter, fmt.Errorf("invalid created_to %q: use RFC 3339 or YYYY-MM-DD", raw)
+		}
+	}
+	return filter, nil
+}
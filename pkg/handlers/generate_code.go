@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/llm"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// mimePython is the media type clients can Accept to get the bare script from generate-code.
+const mimePython = "text/x-python"
+
+// GenerateProjectCode asks Gemini for Manim code for the project's prompt and returns it without
+// rendering. This is the main workflow when RENDERER_ENABLED=false. An optional {"preset": "<name>"}
+// body selects the Gemini model from one of the caller's render presets. With Accept: text/x-python
+// the script is returned as-is instead of in the JSON envelope.
+func (h *Handlers) GenerateProjectCode(c *gin.Context) {
+	project, claims, ok := loadOwnedProject(c, "GenerateProjectCode")
+	if !ok {
+		return
+	}
+	if strings.TrimSpace(project.Prompt) == "" {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Project prompt is empty. Please update the project with a valid prompt.", nil)
+		return
+	}
+
+	var req TriggerRenderRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", utils.BindingErrorDetails(err))
+		return
+	}
+	model := project.Model
+	if req.Preset != "" {
+		preset, err := queries.FindRenderPresetByName(c.Request.Context(), claims.UserID, req.Preset)
+		if err != nil {
+			utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to load render preset", nil)
+			return
+		}
+		if preset == nil {
+			utils.ResponseWithError(c, http.StatusNotFound, "Render preset not found", gin.H{"preset": req.Preset})
+			return
+		}
+		if preset.Model != "" {
+			model = preset.Model
+		}
+	}
+
+	code, err := h.LLMClient.GenerateManimCodeWithModel(c.Request.Context(), project.Prompt, model)
+	if err != nil {
+		if c.Request.Context().Err() != nil {
+			log.Infof("GenerateProjectCode: Request for project %s cancelled by the client.", project.ID.String())
+			return
+		}
+		if errors.Is(err, llm.ErrLLMUnavailable) {
+			utils.ResponseWithErrorCode(c, http.StatusServiceUnavailable, CodeLLMUnavailable, llmUnavailableMessage, nil)
+			return
+		}
+		if errors.Is(err, llm.ErrTruncatedOutput) {
+			utils.ResponseWithErrorCode(c, http.StatusBadGateway, CodeTruncatedOutput, "Generated Manim code was incomplete. Try simplifying the prompt.", nil)
+			return
+		}
+		log.Errorf("GenerateProjectCode: Failed to generate Manim code for project %s: %v", project.ID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to generate Manim code", nil)
+		return
+	}
+	log.Infof("GenerateProjectCode: Generated %d bytes of Manim code for project %s.", len(code), project.ID.String())
+
+	if c.NegotiateFormat(gin.MIMEJSON, mimePython) == mimePython {
+		c.Data(http.StatusOK, mimePython+"; charset=utf-8", []byte(code))
+		return
+	}
+	utils.ResponseWithSuccess(c, http.StatusOK, "Manim code generated successfully", gin.H{
+		"project_id": project.ID.String(),
+		"code":       code,
+	})
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/llm"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// GenerateProjectCodeStream is GenerateProjectCode over Server-Sent Events, for long prompts. Each
+// chunk of Gemini's output is sent as a "token" event as it arrives, followed by a "done" event
+// with the cleaned code, or an "error" event with a code and message if generation fails.
+func (h *Handlers) GenerateProjectCodeStream(c *gin.Context) {
+	project, _, ok := loadOwnedProject(c, "GenerateProjectCodeStream")
+	if !ok {
+		return
+	}
+	if strings.TrimSpace(project.Prompt) == "" {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Project prompt is empty. Please update the project with a valid prompt.", nil)
+		return
+	}
+
+	stream, err := h.LLMClient.GenerateManimCodeStreamWithModel(c.Request.Context(), project.Prompt, project.Model)
+	if err != nil {
+		if errors.Is(err, llm.ErrLLMUnavailable) {
+			utils.ResponseWithErrorCode(c, http.StatusServiceUnavailable, CodeLLMUnavailable, llmUnavailableMessage, nil)
+			return
+		}
+		log.Errorf("GenerateProjectCodeStream: Failed to start Manim code generation for project %s: %v", project.ID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to generate Manim code", nil)
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // Keep reverse proxies from holding back events
+	c.Stream(func(w io.Writer) bool {
+		token, ok := <-stream.Tokens
+		if !ok {
+			return false
+		}
+		c.SSEvent("token", token)
+		return true
+	})
+
+	code, err := stream.Result()
+	if c.Request.Context().Err() != nil {
+		log.Infof("GenerateProjectCodeStream: Request for project %s cancelled by the client.", project.ID.String())
+		return
+	}
+	if err != nil {
+		errCode, message := "code_gen_error", "Failed to generate Manim code"
+		if errors.Is(err, llm.ErrTruncatedOutput) {
+			errCode, message = CodeTruncatedOutput, "Generated Manim code was incomplete. Try simplifying the prompt."
+		} else {
+			log.Errorf("GenerateProjectCodeStream: Failed to generate Manim code for project %s: %v", project.ID.String(), err)
+		}
+		c.SSEvent("error", gin.H{"code": errCode, "message": message})
+		c.Writer.Flush()
+		return
+	}
+
+	log.Infof("GenerateProjectCodeStream: Streamed %d bytes of Manim code for project %s.", len(code), project.ID.String())
+	c.SSEvent("done", gin.H{
+		"project_id": project.ID.String(),
+		"code":       code,
+	})
+	c.Writer.Flush()
+}
@@ -5,10 +5,12 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// HealthCheck is the liveness check: it only confirms the process is up. Dependencies are
+// checked by Readiness.
 func HealthCheck(c *gin.Context){
 	log.Info("Health check endpoint hit")
 	c.JSON(http.StatusOK,gin.H{
 		"status":  "ok",
 		"message": "Manim Orchestrator API is running",
 	})
-}
\ No newline at end of file
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// errInvalidInlineVideo marks inline video payloads the renderer should not retry as-is.
+var errInvalidInlineVideo = errors.New("invalid inline video")
+
+// inlineVideoExtensions lists the video types accepted inline, keyed by MIME type.
+var inlineVideoExtensions = map[string]string{
+	"video/mp4":  ".mp4",
+	"video/webm": ".webm",
+}
+
+// renderCallbackKey is the gin context key StoreInlineRenderVideo stores the parsed render
+// callback under.
+const renderCallbackKey = "renderCallback"
+
+// StoreInlineRenderVideo parses a render callback and, when it carries a completed render's video
+// inline, uploads the video to R2 and points the callback's video_url at it. It runs between
+// RequireRenderCallbackSignature and middleware.Transaction, so an upload of up to
+// INLINE_VIDEO_MAX_BYTES doesn't hold a database transaction open. If the callback isn't saved
+// after all, the uploaded object is deleted again.
+func (h *Handlers) StoreInlineRenderVideo(c *gin.Context) {
+	if h.Config.RendererReturnsInline {
+		// Inline videos arrive base64-encoded (4/3 overhead) inside the JSON body.
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(h.Config.InlineVideoMaxBytes)/3*4+64*1024)
+	}
+	var callback RenderCallbackRequest
+	if err := c.ShouldBindJSON(&callback); err != nil {
+		log.Errorf("StoreInlineRenderVideo: Invalid callback request body: %v", err)
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid callback request body", utils.BindingErrorDetails(err))
+		c.Abort()
+		return
+	}
+
+	// Callbacks for another project than the signature's are rejected by HandleRenderCallback
+	projectID, err := uuid.Parse(callback.ProjectID)
+	if callback.VideoData == "" || err != nil || !verifiedCallbackProject(c, projectID) {
+		c.Set(renderCallbackKey, &callback)
+		c.Next()
+		return
+	}
+	if !h.Config.RendererReturnsInline || h.Storage == nil {
+		log.Warnf("StoreInlineRenderVideo: Inline video data received for project %s but RENDERER_RETURNS_INLINE is disabled.", projectID.String())
+		utils.ResponseWithError(c, http.StatusBadRequest, "Inline video data is not accepted by this deployment", nil)
+		c.Abort()
+		return
+	}
+	if callback.Status != "completed" {
+		callback.VideoData = ""
+		c.Set(renderCallbackKey, &callback)
+		c.Next()
+		return
+	}
+
+	key, err := h.storeInlineVideo(c.Request.Context(), projectID, callback.VideoData, callback.VideoContentType)
+	if errors.Is(err, errInvalidInlineVideo) {
+		log.Warnf("StoreInlineRenderVideo: Rejected inline video for project %s: %v", projectID.String(), err)
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid inline video data", err.Error())
+		c.Abort()
+		return
+	}
+	if err != nil {
+		log.Errorf("StoreInlineRenderVideo: Failed to store inline video for project %s: %v", projectID.String(), err)
+		utils.ResponseWithError(c, http.StatusBadGateway, "Failed to store rendered video", nil)
+		c.Abort()
+		return
+	}
+	callback.VideoData = "" // Let the decoded copy be collected while the handler runs
+	callback.VideoURL = h.Storage.PublicURL(key)
+	c.Set(renderCallbackKey, &callback)
+
+	c.Next()
+
+	if status := c.Writer.Status(); status < 200 || status >= 300 {
+		// Detached from the request, which may have been cancelled
+		ctx := context.WithoutCancel(c.Request.Context())
+		if err := h.Storage.Delete(ctx, key); err != nil {
+			log.Errorf("StoreInlineRenderVideo: Failed to delete inline video %s of unsaved callback for project %s: %v", key, projectID.String(), err)
+			return
+		}
+		log.Infof("StoreInlineRenderVideo: Deleted inline video %s; the callback for project %s was not saved (status %d).", key, projectID.String(), status)
+	}
+}
+
+// storeInlineVideo decodes a base64 video sent in a render callback, uploads it to R2 and
+// returns its object key. Used when the renderer can't upload to storage itself.
+func (h *Handlers) storeInlineVideo(ctx context.Context, projectID uuid.UUID, videoData, contentType string) (string, error) {
+	if contentType == "" {
+		contentType = "video/mp4"
+	}
+	ext, ok := inlineVideoExtensions[strings.ToLower(contentType)]
+	if !ok {
+		return "", fmt.Errorf("%w: unsupported content type %q", errInvalidInlineVideo, contentType)
+	}
+	video, err := base64.StdEncoding.DecodeString(videoData)
+	if err != nil {
+		return "", fmt.Errorf("%w: video_data is not valid base64", errInvalidInlineVideo)
+	}
+	if len(video) == 0 || len(video) > h.Config.InlineVideoMaxBytes {
+		return "", fmt.Errorf("%w: video must be between 1 and %d bytes", errInvalidInlineVideo, h.Config.InlineVideoMaxBytes)
+	}
+
+	// A fresh key per render keeps CDN caches from serving a previous version.
+	key := fmt.Sprintf("videos/%s/%d%s", projectID.String(), time.Now().Unix(), ext)
+	if err := h.Storage.Put(ctx, key, video, strings.ToLower(contentType)); err != nil {
+		return "", err
+	}
+	log.Infof("Stored inline video for project %s at %s (%d bytes).", projectID.String(), key, len(video))
+	return key, nil
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/config"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestStoreInlineRenderVideo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	projectID := uuid.New()
+	video := base64.StdEncoding.EncodeToString([]byte("fake mp4 bytes"))
+
+	tests := []struct {
+		name        string
+		status      string // Callback status
+		handlerCode int    // Status the rest of the chain responds with
+		wantCode    int
+		wantMethods []string // Requests the object store receives, in order
+	}{
+		{"saved callback keeps the upload", "completed", http.StatusOK, http.StatusOK, []string{http.MethodPut}},
+		{"unsaved callback deletes the upload", "completed", http.StatusInternalServerError, http.StatusInternalServerError, []string{http.MethodPut, http.MethodDelete}},
+		{"failed render uploads nothing", "failed", http.StatusOK, http.StatusOK, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var methods []string
+			var keys []string
+			store := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				methods = append(methods, r.Method)
+				keys = append(keys, r.URL.Path)
+				mu.Unlock()
+				if r.Method == http.MethodDelete {
+					w.WriteHeader(http.StatusNoContent)
+				}
+			}))
+			defer store.Close()
+			client, err := storage.NewClient(storage.Config{
+				Endpoint:        store.URL,
+				Bucket:          "videos",
+				AccessKeyID:     "key",
+				SecretAccessKey: "secret",
+				PublicBaseURL:   "https://cdn.example.com",
+			})
+			if err != nil {
+				t.Fatalf("NewClient: %v", err)
+			}
+			h := &Handlers{
+				Config:  &config.Config{RendererReturnsInline: true, InlineVideoMaxBytes: 1024},
+				Storage: client,
+			}
+
+			var seen *RenderCallbackRequest
+			router := gin.New()
+			router.POST("/callback", func(c *gin.Context) {
+				c.Set(verifiedCallbackProjectKey, projectID)
+			}, h.StoreInlineRenderVideo, func(c *gin.Context) {
+				parsed, _ := c.Get(renderCallbackKey)
+				seen, _ = parsed.(*RenderCallbackRequest)
+				c.Status(tt.handlerCode)
+			})
+
+			body, _ := json.Marshal(RenderCallbackRequest{ProjectID: projectID.String(), Status: tt.status, VideoData: video})
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/callback", strings.NewReader(string(body))))
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if seen == nil {
+				t.Fatal("handler did not receive the parsed callback")
+			}
+			if seen.VideoData != "" {
+				t.Error("handler received the inline video bytes")
+			}
+			if strings.Join(methods, ",") != strings.Join(tt.wantMethods, ",") {
+				t.Fatalf("object store requests = %v, want %v", methods, tt.wantMethods)
+			}
+			if len(keys) > 0 {
+				if !strings.HasPrefix(keys[0], "/videos/videos/"+projectID.String()+"/") {
+					t.Errorf("uploaded to %s, want a key under videos/%s/", keys[0], projectID)
+				}
+				if seen.VideoURL != "https://cdn.example.com"+strings.TrimPrefix(keys[0], "/videos") {
+					t.Errorf("VideoURL = %q, want the public URL of %s", seen.VideoURL, keys[0])
+				}
+				for _, key := range keys[1:] {
+					if key != keys[0] {
+						t.Errorf("deleted %s, want the uploaded %s", key, keys[0])
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/services"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// IntrospectRequest is the body of POST /auth/introspect, as JSON or a form (like OAuth2).
+type IntrospectRequest struct {
+	Token string `json:"token" form:"token" binding:"required"`
+}
+
+// IntrospectResponse mirrors an OAuth2 token introspection response (RFC 7662). Only Active is
+// set for invalid or expired tokens.
+type IntrospectResponse struct {
+	Active   bool      `json:"active"`
+	UserID   uuid.UUID `json:"user_id,omitempty"`
+	Email    string    `json:"email,omitempty"`
+	Username string    `json:"username,omitempty"`
+	Subject  string    `json:"sub,omitempty"`
+	Issuer   string    `json:"iss,omitempty"`
+	IssuedAt int64     `json:"iat,omitempty"`
+	Expiry   int64     `json:"exp,omitempty"`
+}
+
+// IntrospectToken lets sibling services validate a user's token without holding the JWT secret.
+// An invalid token is not an error: the response is 200 with active=false.
+func IntrospectToken(c *gin.Context) {
+	var req IntrospectRequest
+	if err := c.ShouldBind(&req); err != nil {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", utils.BindingErrorDetails(err))
+		return
+	}
+
+	claims, err := services.ValidateToken(c.Request.Context(), req.Token)
+	if errors.Is(err, services.ErrRevocationCheck) {
+		log.Errorf("IntrospectToken: Failed to validate token: %v", err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to introspect token", nil)
+		return
+	}
+	if err != nil {
+		log.Debugf("IntrospectToken: Token is not active: %v", err)
+		utils.ResponseWithSuccess(c, http.StatusOK, "Token introspected", IntrospectResponse{Active: false})
+		return
+	}
+
+	resp := IntrospectResponse{
+		Active:   true,
+		UserID:   claims.UserID,
+		Email:    claims.Email,
+		Username: claims.Username,
+		Subject:  claims.Subject,
+		Issuer:   claims.Issuer,
+	}
+	if claims.IssuedAt != nil {
+		resp.IssuedAt = claims.IssuedAt.Unix()
+	}
+	if claims.ExpiresAt != nil {
+		resp.Expiry = claims.ExpiresAt.Unix()
+	}
+	utils.ResponseWithSuccess(c, http.StatusOK, "Token introspected", resp)
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// findCallbackProject returns the project a render callback is for. A render can finish after
+// its project was deleted, as happens when it's deleted while rendering; the soft-deleted row
+// still takes the result, so restoring the project brings back its video. Its output is removed
+// from the bucket when the project is purged. Returns nil, nil if the project no longer exists.
+func findCallbackProject(ctx context.Context, projectID uuid.UUID) (*db.ManimProject, error) {
+	project, err := queries.FindManimProjectByID(ctx, projectID)
+	if err != nil || project != nil {
+		return project, err
+	}
+	project, err = queries.FindDeletedManimProjectByID(ctx, projectID)
+	if project != nil {
+		log.Infof("HandleRenderCallback: Render of project %s finished after the project was deleted; keeping its output in case it's restored.", projectID.String())
+	}
+	return project, err
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// CodeProjectLocked is returned with 423 when a locked project is edited, deleted or rendered.
+const CodeProjectLocked = "project_locked"
+
+// respondProjectLocked writes the 423 response for an operation refused on a locked project.
+func respondProjectLocked(c *gin.Context) {
+	utils.ResponseWithErrorCode(c, http.StatusLocked, CodeProjectLocked, "Project is locked. Unlock it before making changes.", nil)
+}
+
+// LockProject marks one of the caller's projects read-only. It stays viewable and downloadable.
+func LockProject(c *gin.Context) {
+	setProjectLocked(c, "LockProject", true)
+}
+
+// UnlockProject makes a locked project editable again.
+func UnlockProject(c *gin.Context) {
+	setProjectLocked(c, "UnlockProject", false)
+}
+
+func setProjectLocked(c *gin.Context, handlerName string, locked bool) {
+	project, claims, ok := loadOwnedProject(c, handlerName)
+	if !ok {
+		return
+	}
+
+	if err := queries.SetManimProjectLocked(c.Request.Context(), project.ID, claims.UserID, locked); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.ResponseWithError(c, http.StatusNotFound, "Manim project not found", nil)
+			return
+		}
+		log.Errorf("%s: Failed to update project %s: %v", handlerName, project.ID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to update project", nil)
+		return
+	}
+	log.Infof("%s: Project %s locked=%t by user %s.", handlerName, project.ID.String(), locked, claims.UserID.String())
+
+	message := "Project unlocked"
+	if locked {
+		message = "Project locked"
+	}
+	utils.ResponseWithSuccess(c, http.StatusOK, message, gin.H{"id": project.ID, "locked": locked})
+}
@@ -1,14 +1,16 @@
 package handlers
 
 import (
-	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"math"
 	"net/http"
 	"net/url"
-	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,6 +19,8 @@ import (
 	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
 	"github.com/ASHISH26940/manim-orchestrator-api/pkg/llm"
 	"github.com/ASHISH26940/manim-orchestrator-api/pkg/middleware"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/services"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/storage"
 	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -25,24 +29,52 @@ import (
 
 
 type Handlers struct {
-	Config    *config.Config
-	LLMClient *llm.Service
+	Config         *config.Config
+	LLMClient      *llm.Service
+	Storage        *storage.Client             // nil unless R2 credentials are configured
+	RendererInfo   *services.RendererInfoCache // nil when the renderer is disabled
+	RendererHealth *services.RendererHealth    // nil when the renderer is disabled
+
+	// RenderLimiter caps paid Gemini generations per user (RENDER_RATE_LIMIT_PER_HOUR). It is shared
+	// by every route that generates code; bulk routes take one token per project they queue.
+	RenderLimiter *middleware.RateLimiter
+
+	renderSlots chan struct{} // Bounds the number of background renders in flight
+	queueCache  renderQueueCache
 }
 // --- Request/Response Structs ---// Handlers struct to hold dependencies
 
 
 // NewHandlers creates a new instance of Handlers
-func NewHandlers(cfg *config.Config, llmClient *llm.Service) *Handlers {
+func NewHandlers(cfg *config.Config, llmClient *llm.Service, storageClient *storage.Client, rendererInfo *services.RendererInfoCache, rendererHealth *services.RendererHealth) *Handlers {
 	return &Handlers{
-		Config:    cfg,
-		LLMClient: llmClient,
+		Config:         cfg,
+		LLMClient:      llmClient,
+		Storage:        storageClient,
+		RendererInfo:   rendererInfo,
+		RendererHealth: rendererHealth,
+		RenderLimiter:  middleware.NewRateLimiter(cfg.RenderRateLimitPerHour, time.Hour),
+		renderSlots:    make(chan struct{}, cfg.RenderConcurrency),
 	}
 }
 
+
 type RendererRequest struct {
-	ProjectID     string `json:"project_id"`
-	ScriptContent string `json:"script_content"`
-	CallbackURL   string `json:"callback_url"`
+	ProjectID         string          `json:"project_id"`
+	ScriptContent     string          `json:"script_content"`
+	CallbackURL       string          `json:"callback_url"`
+	Quality           string          `json:"quality,omitempty"` // From a render preset; renderer default when empty
+	Format            string          `json:"format,omitempty"`
+	Profile           string          `json:"profile,omitempty"`
+	Resolution        string          `json:"resolution"` // The project's output settings, e.g. 720p at 30 fps
+	FPS               int             `json:"fps"`
+	Assets            []RendererAsset `json:"assets,omitempty"`             // Uploaded files the script may load by filename
+	CallbackSignature string          `json:"callback_signature,omitempty"` // To send back as X-Render-Signature
+}
+
+// TriggerRenderRequest is the optional body of POST /api/projects/:id/generate-render.
+type TriggerRenderRequest struct {
+	Preset string `json:"preset"` // Name of one of the caller's render presets
 }
 
 // RenderCallbackRequest defines the expected structure of the POST request from the Python renderer to our callback endpoint.
@@ -52,15 +84,23 @@ type RenderCallbackRequest struct {
 	VideoURL     string `json:"video_url"` // Will be the R2 public URL on success, "N/A" or empty on failure
 	Message      string `json:"message"` // General message from renderer
 	ErrorDetails string `json:"error_details"` // Optional, for specific error info
+	VideoData        string `json:"video_data"`         // Base64 video bytes, sent instead of video_url by renderers that can't upload (RENDERER_RETURNS_INLINE)
+	VideoContentType string `json:"video_content_type"` // MIME type of video_data, defaults to video/mp4
+	ThumbnailURL     string `json:"thumbnail_url"`      // Optional preview frame uploaded alongside the video
 }
 
 
 
 // CreateProjectRequest defines the structure for creating a new Manim project.
 type CreateProjectRequest struct {
-	Name        string `json:"name" binding:"required,min=3,max=255"`
+	ID          string `json:"id" binding:"omitempty,uuid"` // Optional client-chosen ID; retrying with the same ID returns the original project
+	Name        string `json:"name" binding:"omitempty,min=3,max=255"` // Optional when AUTO_NAME_PROJECTS is on
 	Description string `json:"description"`
 	Prompt      string `json:"prompt" binding:"required,min=10"` // Prompt for Manim code generation
+	Metadata    json.RawMessage `json:"metadata"` // Optional client-owned JSON object
+	Model       string `json:"model"` // Optional Gemini model, e.g. gemini-1.5-pro for complex prompts
+	Resolution  string `json:"resolution" binding:"omitempty,oneof=480p 720p 1080p"` // Defaults to 720p
+	FPS         int    `json:"fps" binding:"omitempty,oneof=30 60"`                  // Defaults to 30
 }
 
 // UpdateProjectRequest defines the structure for updating an existing Manim project.
@@ -68,6 +108,9 @@ type UpdateProjectRequest struct {
 	Name        *string `json:"name" binding:"omitempty,min=3,max=255"` // Pointers to allow partial updates
 	Description *string `json:"description"`
 	Prompt      *string `json:"prompt" binding:"omitempty,min=10"`
+	Metadata    json.RawMessage `json:"metadata"` // Replaces the stored metadata when present; null clears it
+	Resolution  *string `json:"resolution" binding:"omitempty,oneof=480p 720p 1080p"`
+	FPS         *int    `json:"fps" binding:"omitempty,oneof=30 60"`
 	// RenderStatus and VideoURL will be updated internally by the orchestrator, not directly by user via this endpoint
 }
 
@@ -80,29 +123,126 @@ type ProjectResponse struct {
 	Prompt       string    `json:"prompt"`
 	RenderStatus string    `json:"render_status"`
 	VideoURL     string    `json:"video_url"`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty"`
+	AutoReconcile bool     `json:"auto_reconcile"`
+	Locked       bool      `json:"locked"`
+	Notes        string    `json:"notes"`
+	Model        string    `json:"model,omitempty"` // Empty when the project uses the default model
+	Resolution   string    `json:"resolution"`
+	FPS          int       `json:"fps"`
+	Metadata     json.RawMessage `json:"metadata"`
 	CreatedAt    string    `json:"created_at"` // Using string for formatted timestamp
 	UpdatedAt    string    `json:"updated_at"`
+	CreatedAgo   string    `json:"created_ago,omitempty"` // Only with ?humanize=true
+	UpdatedAgo   string    `json:"updated_ago,omitempty"` // Only with ?humanize=true
 }
 
 
 // Request payload structure for merging videos
 type MergeVideoRequest struct {
-	IDs []string `json:"ids"` // List of video IDs (likely UUID strings) to merge
+	IDs   []string    `json:"ids"`             // List of video IDs (likely UUID strings) to merge
+	Clips []MergeClip `json:"clips,omitempty"` // Alternative to IDs: ordered clips with optional trim points
+}
+
+// MergeClip is one clip of a merge, optionally trimmed to [Start, End) seconds of the source video.
+type MergeClip struct {
+	ID    string   `json:"id"`
+	Start *float64 `json:"start,omitempty"`
+	End   *float64 `json:"end,omitempty"`
+}
+
+// validateMergeClips checks trim ranges: start must be >= 0 and end greater than start. Clip
+// durations aren't recorded yet, so ranges past the end of a clip are left to the renderer to reject.
+func validateMergeClips(clips []MergeClip) error {
+	for i, clip := range clips {
+		if clip.Start != nil && *clip.Start < 0 {
+			return fmt.Errorf("clip %d (%s): start must not be negative", i, clip.ID)
+		}
+		if clip.End != nil && *clip.End <= 0 {
+			return fmt.Errorf("clip %d (%s): end must be positive", i, clip.ID)
+		}
+		if clip.Start != nil && clip.End != nil && *clip.End <= *clip.Start {
+			return fmt.Errorf("clip %d (%s): end must be greater than start", i, clip.ID)
+		}
+	}
+	return nil
+}
+
+// mergeClipsDuration sums the trimmed lengths of the clips. The total is only known when every
+// clip has both a start and an end, since untrimmed clip durations aren't recorded.
+func mergeClipsDuration(clips []MergeClip) (float64, bool) {
+	if len(clips) == 0 {
+		return 0, false
+	}
+	var total float64
+	for _, clip := range clips {
+		if clip.Start == nil || clip.End == nil {
+			return 0, false
+		}
+		total += *clip.End - *clip.Start
+	}
+	return total, true
 }
 
 // Response payload structure from the Python renderer
 type PythonMergeResponse struct {
-	Message        string `json:"message"`
-	MergedVideoID  string `json:"merged_video_id"`  // The UUID of the merged video
-	MergedVideoURL string `json:"merged_video_url"` // The R2 URL from Python
-	Error          string `json:"error"`             // Python might send an 'error' field
+	Message        string  `json:"message"`
+	MergedVideoID  string  `json:"merged_video_id"`  // The UUID of the merged video
+	MergedVideoURL string  `json:"merged_video_url"` // The R2 URL from Python
+	Duration       float64 `json:"duration"`         // Length of the merged video in seconds, if the renderer reports it
+	Error          string  `json:"error"`            // Python might send an 'error' field
+}
+
+// MergeSourceStatus reports whether one requested ID made it into a merge. The merge route isn't
+// authenticated, so nothing more is said about the project: a reason or render status would let
+// anyone probe which project IDs exist.
+type MergeSourceStatus struct {
+	ID       string `json:"id"`
+	Included bool   `json:"included"` // The clip was sent to the renderer for merging
 }
 
 // Final response structure for frontend
 type MergedVideoResponse struct {
-	Message        string `json:"message"`
-	MergedVideoID  string `json:"merged_video_id"`
-	MergedVideoURL string `json:"merged_video_url"` // This will be the transformed R2 URL sent to frontend
+	Message              string              `json:"message"`
+	MergedVideoID        string              `json:"merged_video_id"`
+	MergedVideoURL       string              `json:"merged_video_url"` // This will be the transformed R2 URL sent to frontend; empty while merging
+	Status               string              `json:"status"`           // merging or completed
+	TotalDurationSeconds *float64            `json:"total_duration_seconds,omitempty"`
+	Sources              []MergeSourceStatus `json:"sources"`
+	MergeJobID           string              `json:"merge_job_id,omitempty"` // For POST /api/merge/:jobId/retry
+}
+
+// collectMergeSources looks up every requested ID and reports whether it can be merged: only
+// projects with a completed render and a video URL are included. It returns the per-ID statuses
+// along with the IDs that are included.
+func collectMergeSources(ctx context.Context, ids []string) ([]MergeSourceStatus, []string, error) {
+	sources := make([]MergeSourceStatus, len(ids))
+	var included []string
+	for i, rawID := range ids {
+		sources[i] = MergeSourceStatus{ID: rawID}
+
+		videoID, err := uuid.Parse(rawID)
+		if err != nil {
+			log.WithContext(ctx).Debugf("collectMergeSources: Excluding '%s': invalid ID format.", rawID)
+			continue
+		}
+		project, err := queries.FindManimProjectByID(ctx, videoID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if project == nil {
+			log.WithContext(ctx).Debugf("collectMergeSources: Excluding %s: not found.", rawID)
+			continue
+		}
+		if project.RenderStatus != "completed" || !project.VideoURL.Valid || project.VideoURL.String == "" {
+			log.WithContext(ctx).Debugf("collectMergeSources: Excluding %s: render not completed (%s).", rawID, project.RenderStatus)
+			continue
+		}
+
+		sources[i].Included = true
+		included = append(included, rawID)
+	}
+	return sources, included, nil
 }
 
 
@@ -120,19 +260,110 @@ func newProjectResponse(project *db.ManimProject) ProjectResponse {
 		Prompt:       project.Prompt,
 		RenderStatus: project.RenderStatus,
 		VideoURL:     videoURL,
-		CreatedAt:    project.CreatedAt.Format(http.TimeFormat), // Standard HTTP time format
-		UpdatedAt:    project.UpdatedAt.Format(http.TimeFormat),
+		ThumbnailURL: project.ThumbnailURL.String,
+		AutoReconcile: project.AutoReconcile,
+		Locked:       project.Locked,
+		Notes:        project.Notes,
+		Model:        project.Model,
+		Resolution:   project.Resolution,
+		FPS:          project.FPS,
+		Metadata:     metadataResponse(project.Metadata),
+		CreatedAt:    project.CreatedAt.UTC().Format(http.TimeFormat), // Standard HTTP time format, which is always GMT
+		UpdatedAt:    project.UpdatedAt.UTC().Format(http.TimeFormat),
 	}
 }
 
+// isUsableVideoURL reports whether a renderer-supplied video URL is an absolute http(s) URL.
+// The renderer sends "N/A" or an empty string when there's no video.
+func isUsableVideoURL(videoURL string) bool {
+	if videoURL == "" || videoURL == "N/A" {
+		return false
+	}
+	u, err := url.Parse(videoURL)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
+
+// applyRenderCallback sets the project's render status and video URL from a renderer callback.
+// A completed status must come with a usable URL, so render_status alone tells clients whether a
+// video exists; otherwise the project is marked "failed: missing_video_url".
+func applyRenderCallback(project *db.ManimProject, callback *RenderCallbackRequest) {
+	project.RenderStatus = callback.Status
+	if callback.Status == "completed" {
+		if isUsableVideoURL(callback.VideoURL) {
+			project.VideoURL = sql.NullString{String: callback.VideoURL, Valid: true}
+			log.Infof("Project %s render completed. Video URL: %s", project.ID.String(), callback.VideoURL)
+			if callback.ThumbnailURL != "" {
+				project.ThumbnailURL = sql.NullString{String: callback.ThumbnailURL, Valid: true}
+			}
+		} else {
+			project.VideoURL = sql.NullString{Valid: false}
+			project.RenderStatus = "failed: missing_video_url"
+			log.Warnf("Project %s reported completed without a valid video URL (%q); marking as failed.", project.ID.String(), callback.VideoURL)
+		}
+	} else {
+		// Clear URL on failure/non-completed status
+		project.VideoURL = sql.NullString{Valid: false}
+		log.Errorf("Project %s rendering failed with status: %s. Details: %s", project.ID.String(), callback.Status, callback.ErrorDetails)
+	}
+}
+
+// loadOwnedProject resolves the project named by the ":id" path parameter and checks that it
+// belongs to the authenticated user. On failure it writes the error response and returns ok=false.
+// handlerName prefixes log lines so they read like the rest of the handler's.
+func loadOwnedProject(c *gin.Context, handlerName string) (*db.ManimProject, *services.Claims, bool) {
+	claims, exists := middleware.GetUserClaimsFromContext(c)
+	if !exists {
+		log.Errorf("%s: User claims not found in context.", handlerName)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+		return nil, nil, false
+	}
+
+	project, err := findOwnedProject(c.Request.Context(), c.Param("id"), claims.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, errNotProjectOwner):
+			log.Warnf("%s: %v", handlerName, err)
+		case isClientError(err):
+			log.Debugf("%s: %v", handlerName, err)
+		default:
+			log.Errorf("%s: %v", handlerName, err)
+		}
+		respondForError(c, err)
+		return nil, nil, false
+	}
+	return project, claims, true
+}
+
 // --- API Handlers ---
 
+// Success codes of the create and render handlers, returned in the response's "code" field.
+const (
+	CodeProjectCreated   = "PROJECT_CREATED"
+	CodeProjectExists    = "PROJECT_EXISTS"    // Create with a client-supplied ID the caller already used
+	CodeRenderInitiated  = "RENDER_INITIATED"  // Code generated and handed to the renderer
+	CodeRenderDecomposed = "RENDER_DECOMPOSED" // Rendering as one child project per scene
+)
+
 // CreateManimProject handles the creation of a new Manim project.
-func CreateManimProject(c *gin.Context) {
+// Without a name, one is derived from the prompt when AUTO_NAME_PROJECTS is enabled; an empty
+// description falls back to DEFAULT_PROJECT_DESCRIPTION.
+func (h *Handlers) CreateManimProject(c *gin.Context) {
 	var req CreateProjectRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		log.Warnf("CreateManimProject: Invalid request body: %v", err)
-		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", utils.BindingErrorDetails(err))
+		return
+	}
+
+	metadata, err := normalizeMetadata(req.Metadata)
+	if err != nil {
+		log.Warnf("CreateManimProject: Invalid metadata: %v", err)
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid metadata", err.Error())
+		return
+	}
+	req.Model = strings.ToLower(strings.TrimSpace(req.Model))
+	if req.Model != "" && !presetModelPattern.MatchString(req.Model) {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid model. Must be a Gemini model name such as gemini-1.5-pro", nil)
 		return
 	}
 
@@ -143,8 +374,35 @@ func CreateManimProject(c *gin.Context) {
 		return
 	}
 
+	// A retried create with a client-supplied ID returns the project the first attempt created
+	var clientID uuid.UUID
+	if req.ID != "" {
+		clientID = uuid.MustParse(req.ID) // Validated by the binding
+		if respondExistingProject(c, clientID, claims.UserID) {
+			return
+		}
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		if !h.Config.AutoNameProjects {
+			utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", "name is required")
+			return
+		}
+		req.Name, err = uniqueProjectName(c.Request.Context(), claims.UserID, autoNameFromPrompt(req.Prompt))
+		if err != nil {
+			log.Errorf("CreateManimProject: Failed to auto-name project: %v", err)
+			utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to generate a project name", nil)
+			return
+		}
+		log.Debugf("CreateManimProject: Auto-named project '%s' for user %s.", req.Name, claims.UserID.String())
+	}
+	if strings.TrimSpace(req.Description) == "" {
+		req.Description = h.Config.DefaultProjectDescription
+	}
+
 	// Check if a project with the same name already exists for this user
-	existingProject, err := queries.FindManimProjectByNameAndUserID(req.Name, claims.UserID)
+	existingProject, err := queries.FindManimProjectByNameAndUserID(c.Request.Context(), req.Name, claims.UserID)
 	if err != nil && err != sql.ErrNoRows {
 		log.Errorf("CreateManimProject: Database error checking existing project: %v", err)
 		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to check project existence", nil)
@@ -163,21 +421,82 @@ func CreateManimProject(c *gin.Context) {
 		Prompt:      strings.TrimSpace(req.Prompt),
 		RenderStatus: "pending", // Default status for new projects
 		VideoURL:    sql.NullString{Valid: false},        // No video URL initially
+		Metadata:    metadata,
+		Model:       req.Model,
+		Resolution:  req.Resolution,
+		FPS:         req.FPS,
+	}
+
+	var createdProject *db.ManimProject
+	if clientID != uuid.Nil {
+		project.ID = clientID
+		createdProject, err = queries.CreateManimProjectWithID(c.Request.Context(), project)
+		if err == nil && createdProject == nil {
+			// Lost a race with a concurrent attempt using the same ID
+			if !respondExistingProject(c, clientID, claims.UserID) {
+				log.Errorf("CreateManimProject: Insert of project %s conflicted but no project has that ID.", clientID.String())
+				utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to create project", nil)
+			}
+			return
+		}
+	} else {
+		createdProject, err = queries.CreateManimProject(c.Request.Context(), project)
 	}
-
-	createdProject, err := queries.CreateManimProject(project)
 	if err != nil {
 		log.Errorf("CreateManimProject: Failed to create project in DB: %v", err)
-		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to create Manim project", nil)
+		respondForError(c, err)
 		return
 	}
 
 	log.Infof("Manim project '%s' created successfully for user %s. ID: %s", createdProject.Name, claims.UserID.String(), createdProject.ID.String())
-	utils.ResponseWithSuccess(c, http.StatusCreated, "Manim project created successfully", newProjectResponse(createdProject))
+	utils.ResponseWithSuccessCode(c, http.StatusCreated, CodeProjectCreated, "Manim project created successfully", projectResponseOptionsFromQuery(c).render(createdProject))
+}
+
+// respondExistingProject responds for a create whose client-supplied ID is already taken: 200 with
+// the project when the caller owns it, 409 when another user does or when the project is deleted
+// (it can be restored instead). It returns false, writing nothing, when no project has the ID.
+func respondExistingProject(c *gin.Context, projectID, userID uuid.UUID) bool {
+	existing, err := queries.FindManimProjectByID(c.Request.Context(), projectID)
+	if err != nil {
+		log.Errorf("CreateManimProject: Failed to look up project %s: %v", projectID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to check project existence", nil)
+		return true
+	}
+	if existing == nil {
+		// A soft-deleted project still holds its ID until it is purged
+		deleted, err := queries.FindDeletedManimProjectByID(c.Request.Context(), projectID)
+		if err != nil {
+			log.Errorf("CreateManimProject: Failed to look up deleted project %s: %v", projectID.String(), err)
+			utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to check project existence", nil)
+			return true
+		}
+		if deleted == nil {
+			return false
+		}
+		if deleted.UserID != userID {
+			log.Warnf("CreateManimProject: User %s supplied ID %s of a deleted project owned by another user.", userID.String(), projectID.String())
+			utils.ResponseWithErrorCode(c, http.StatusConflict, CodeDuplicate, "Project ID is already in use", nil)
+			return true
+		}
+		log.Infof("CreateManimProject: Project %s of user %s is deleted; not recreating it.", projectID.String(), userID.String())
+		utils.ResponseWithErrorCode(c, http.StatusConflict, CodeDuplicate, "Project ID belongs to a deleted project; restore it with POST /api/projects/"+projectID.String()+"/restore", nil)
+		return true
+	}
+	if existing.UserID != userID {
+		log.Warnf("CreateManimProject: User %s supplied ID %s of a project owned by another user.", userID.String(), projectID.String())
+		utils.ResponseWithErrorCode(c, http.StatusConflict, CodeDuplicate, "Project ID is already in use", nil)
+		return true
+	}
+	log.Infof("CreateManimProject: Project %s already exists for user %s; returning it.", projectID.String(), userID.String())
+	utils.ResponseWithSuccessCode(c, http.StatusOK, CodeProjectExists, "Manim project already exists", projectResponseOptionsFromQuery(c).render(existing))
+	return true
 }
 
 // GetUserManimProjects handles fetching all Manim projects for the authenticated user.
-func GetUserManimProjects(c *gin.Context) {
+// Responses carry a weak ETag; a matching If-None-Match gets 304 without loading the projects.
+// ?fields=id,name,render_status restricts each project to the listed fields, and ?tz=<IANA zone>
+// formats timestamps in that zone instead of UTC.
+func (h *Handlers) GetUserManimProjects(c *gin.Context) {
 	claims, exists := middleware.GetUserClaimsFromContext(c)
 	if !exists {
 		log.Error("GetUserManimProjects: User claims not found in context.")
@@ -185,7 +504,37 @@ func GetUserManimProjects(c *gin.Context) {
 		return
 	}
 
-	projects, err := queries.FindManimProjectsByUserID(claims.UserID)
+	filter, err := projectFilterFromQuery(c)
+	if err != nil {
+		log.Warnf("GetUserManimProjects: Invalid filter: %v", err)
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid filter", err.Error())
+		return
+	}
+	fields, err := projectFieldsFromQuery(c)
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid fields", err.Error())
+		return
+	}
+	if _, err := timezoneFromQuery(c); err != nil {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid tz", err.Error())
+		return
+	}
+
+	version, err := queries.FindProjectSetVersion(c.Request.Context(), claims.UserID)
+	if err != nil {
+		log.Errorf("GetUserManimProjects: Failed to compute list version for user %s: %v", claims.UserID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retrieve Manim projects", nil)
+		return
+	}
+	etag := projectListETag(version, c.Request.URL.RawQuery)
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	projects, err := queries.FindManimProjectsByUserID(c.Request.Context(), claims.UserID, filter)
 	if err != nil {
 		log.Errorf("GetUserManimProjects: Failed to fetch projects for user %s: %v", claims.UserID.String(), err)
 		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retrieve Manim projects", nil)
@@ -193,23 +542,15 @@ func GetUserManimProjects(c *gin.Context) {
 	}
 
 	// Convert db.ManimProject slice to ProjectResponse slice
-	projectResponses := make([]ProjectResponse, len(projects))
+	responseOptions := projectResponseOptionsFromQuery(c)
+	projectResponses := make([]interface{}, len(projects))
 	for i, p := range projects {
-		pr := newProjectResponse(&p) // Create the initial response object
-
-		// --- URL TRANSFORMATION LOGIC ---
-		// Check if VideoURL exists and contains the old domain
-		if pr.VideoURL != "" && strings.Contains(pr.VideoURL, "41eca3477bd94f0eb869bef997e35147.r2.dev") {
-			pr.VideoURL = strings.Replace(
-				pr.VideoURL,
-				"https://41eca3477bd94f0eb869bef997e35147.r2.dev",
-				"https://pub-b0b0ca8b1fc2487b82486c56d37c2667.r2.dev",
-				1, // Only replace the first occurrence (the domain prefix)
-			)
-		}
-		// --- END URL TRANSFORMATION LOGIC ---
+		pr := responseOptions.render(&p) // Create the initial response object
+
+		// Serve videos from the public R2 domain when one is configured
+		pr.VideoURL = h.Config.RewriteVideoURL(pr.VideoURL)
 
-		projectResponses[i] = pr
+		projectResponses[i] = sparseProject(pr, fields) // Only the ?fields= requested, if any
 	}
 
 	log.Infof("Found %d projects for user %s.", len(projects), claims.UserID.String())
@@ -217,86 +558,45 @@ func GetUserManimProjects(c *gin.Context) {
 }
 
 // GetManimProjectByID handles fetching a single Manim project by its ID, ensuring ownership.
+// Like the list, it accepts ?fields=id,name,... to return only those fields, and ?tz=.
 func GetManimProjectByID(c *gin.Context) {
-	projectIDParam := c.Param("id") // Get ID from URL path
-	projectID, err := uuid.Parse(projectIDParam)
+	fields, err := projectFieldsFromQuery(c)
 	if err != nil {
-		log.Warnf("GetManimProjectByID: Invalid project ID format '%s': %v", projectIDParam, err)
-		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid project ID format", nil)
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid fields", err.Error())
 		return
 	}
-
-	claims, exists := middleware.GetUserClaimsFromContext(c)
-	if !exists {
-		log.Error("GetManimProjectByID: User claims not found in context.")
-		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+	if _, err := timezoneFromQuery(c); err != nil {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid tz", err.Error())
 		return
 	}
 
-	project, err := queries.FindManimProjectByID(projectID)
-	if err != nil {
-		log.Errorf("GetManimProjectByID: Failed to fetch project %s: %v", projectID.String(), err)
-		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retrieve Manim project", nil)
-		return
-	}
-	if project == nil {
-		log.Debugf("GetManimProjectByID: Project with ID %s not found.", projectID.String())
-		utils.ResponseWithError(c, http.StatusNotFound, "Manim project not found", nil)
-		return
-	}
-
-	// IMPORTANT: Ensure the retrieved project belongs to the authenticated user
-	if project.UserID != claims.UserID {
-		log.Warnf("GetManimProjectByID: User %s attempted to access project %s owned by %s.", claims.UserID.String(), projectID.String(), project.UserID.String())
-		utils.ResponseWithError(c, http.StatusForbidden, "You do not have permission to access this project", nil)
+	project, claims, ok := loadOwnedProject(c, "GetManimProjectByID")
+	if !ok {
 		return
 	}
+	projectID := project.ID
 
 	log.Infof("Retrieved project %s for user %s.", projectID.String(), claims.UserID.String())
-	utils.ResponseWithSuccess(c, http.StatusOK, "Manim project retrieved successfully", newProjectResponse(project))
+	utils.ResponseWithSuccess(c, http.StatusOK, "Manim project retrieved successfully", sparseProject(projectResponseOptionsFromQuery(c).render(project), fields))
 }
 
 // UpdateManimProject handles updating an existing Manim project, ensuring ownership.
 func UpdateManimProject(c *gin.Context) {
-	projectIDParam := c.Param("id")
-	projectID, err := uuid.Parse(projectIDParam)
-	if err != nil {
-		log.Warnf("UpdateManimProject: Invalid project ID format '%s': %v", projectIDParam, err)
-		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid project ID format", nil)
-		return
-	}
-
 	var req UpdateProjectRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		log.Warnf("UpdateManimProject: Invalid request body: %v", err)
-		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", err.Error())
-		return
-	}
-
-	claims, exists := middleware.GetUserClaimsFromContext(c)
-	if !exists {
-		log.Error("UpdateManimProject: User claims not found in context.")
-		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", utils.BindingErrorDetails(err))
 		return
 	}
 
 	// Fetch the existing project to get current values and ensure ownership
-	existingProject, err := queries.FindManimProjectByID(projectID)
-	if err != nil {
-		log.Errorf("UpdateManimProject: Database error fetching project %s: %v", projectID.String(), err)
-		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to check project existence", nil)
-		return
-	}
-	if existingProject == nil {
-		log.Debugf("UpdateManimProject: Project with ID %s not found.", projectID.String())
-		utils.ResponseWithError(c, http.StatusNotFound, "Manim project not found", nil)
+	existingProject, claims, ok := loadOwnedProject(c, "UpdateManimProject")
+	if !ok {
 		return
 	}
-
-	// IMPORTANT: Ensure the project belongs to the authenticated user
-	if existingProject.UserID != claims.UserID {
-		log.Warnf("UpdateManimProject: User %s attempted to update project %s owned by %s.", claims.UserID.String(), projectID.String(), existingProject.UserID.String())
-		utils.ResponseWithError(c, http.StatusForbidden, "You do not have permission to modify this project", nil)
+	projectID := existingProject.ID
+	if existingProject.Locked {
+		respondForError(c, queries.ErrProjectLocked)
 		return
 	}
 
@@ -304,7 +604,7 @@ func UpdateManimProject(c *gin.Context) {
 	if req.Name != nil {
 		// Check for name conflict if name is being updated
 		if strings.TrimSpace(*req.Name) != existingProject.Name { // Only check if name is actually changing
-			conflictProject, err := queries.FindManimProjectByNameAndUserID(strings.TrimSpace(*req.Name), claims.UserID)
+			conflictProject, err := queries.FindManimProjectByNameAndUserID(c.Request.Context(), strings.TrimSpace(*req.Name), claims.UserID)
 			if err != nil && err != sql.ErrNoRows {
 				log.Errorf("UpdateManimProject: Database error checking name conflict: %v", err)
 				utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to check name conflict", nil)
@@ -324,21 +624,31 @@ func UpdateManimProject(c *gin.Context) {
 	if req.Prompt != nil {
 		existingProject.Prompt = strings.TrimSpace(*req.Prompt)
 	}
-
-	err = queries.UpdateManimProject(existingProject)
-	if err != nil {
-		if err == sql.ErrNoRows { // This would imply a race condition where it was deleted after fetching, unlikely if ownership is checked
-			log.Warnf("UpdateManimProject: Project %s disappeared during update process.", projectID.String())
-			utils.ResponseWithError(c, http.StatusNotFound, "Manim project not found for update", nil)
+	if req.Metadata != nil {
+		metadata, err := normalizeMetadata(req.Metadata)
+		if err != nil {
+			log.Warnf("UpdateManimProject: Invalid metadata for project %s: %v", projectID.String(), err)
+			utils.ResponseWithError(c, http.StatusBadRequest, "Invalid metadata", err.Error())
 			return
 		}
+		existingProject.Metadata = metadata
+	}
+	if req.Resolution != nil {
+		existingProject.Resolution = *req.Resolution
+	}
+	if req.FPS != nil {
+		existingProject.FPS = *req.FPS
+	}
+
+	// sql.ErrNoRows here means the project was deleted after it was fetched
+	if err := queries.UpdateManimProject(c.Request.Context(), existingProject); err != nil {
 		log.Errorf("UpdateManimProject: Failed to update project %s in DB: %v", projectID.String(), err)
-		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to update Manim project", nil)
+		respondForError(c, err)
 		return
 	}
 
 	log.Infof("Manim project %s updated successfully for user %s.", projectID.String(), claims.UserID.String())
-	utils.ResponseWithSuccess(c, http.StatusOK, "Manim project updated successfully", newProjectResponse(existingProject))
+	utils.ResponseWithSuccess(c, http.StatusOK, "Manim project updated successfully", projectResponseOptionsFromQuery(c).render(existingProject))
 }
 
 // DeleteManimProject handles deleting an existing Manim project, ensuring ownership.
@@ -347,7 +657,7 @@ func DeleteManimProject(c *gin.Context) {
 	projectID, err := uuid.Parse(projectIDParam)
 	if err != nil {
 		log.Warnf("DeleteManimProject: Invalid project ID format '%s': %v", projectIDParam, err)
-		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid project ID format", nil)
+		respondForError(c, errInvalidProjectID)
 		return
 	}
 
@@ -360,15 +670,14 @@ func DeleteManimProject(c *gin.Context) {
 
 	// No need to fetch the project first, as the queries.DeleteManimProject function
 	// already includes the user_id in its WHERE clause to enforce ownership.
-	err = queries.DeleteManimProject(projectID, claims.UserID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			log.Debugf("DeleteManimProject: Project with ID %s not found or not owned by user %s.", projectID.String(), claims.UserID.String())
-			utils.ResponseWithError(c, http.StatusNotFound, "Manim project not found or you do not have permission to delete it", nil)
-			return
+	// sql.ErrNoRows covers both a missing project and one owned by someone else
+	if err := queries.DeleteManimProject(c.Request.Context(), projectID, claims.UserID); err != nil {
+		if isClientError(err) {
+			log.Debugf("DeleteManimProject: Project %s not deleted for user %s: %v", projectID.String(), claims.UserID.String(), err)
+		} else {
+			log.Errorf("DeleteManimProject: Failed to delete project %s for user %s: %v", projectID.String(), claims.UserID.String(), err)
 		}
-		log.Errorf("DeleteManimProject: Failed to delete project %s for user %s: %v", projectID.String(), claims.UserID.String(), err)
-		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to delete Manim project", nil)
+		respondForError(c, err)
 		return
 	}
 
@@ -386,36 +695,14 @@ type RendererResponse struct {
 
 // --- REVERTED/UPDATED: TriggerManimGenerationAndRender Handler ---
 func (h *Handlers) TriggerManimGenerationAndRender(c *gin.Context) {
-	projectIDParam := c.Param("id")
-	projectID, err := uuid.Parse(projectIDParam)
-	if err != nil {
-		log.Warnf("TriggerManimGenerationAndRender: Invalid project ID format '%s': %v", projectIDParam, err)
-		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid project ID format", nil)
-		return
-	}
-
-	claims, exists := middleware.GetUserClaimsFromContext(c)
-	if !exists {
-		log.Error("TriggerManimGenerationAndRender: User claims not found in context.")
-		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
-		return
-	}
-
 	// 1. Fetch the project and check ownership
-	project, err := queries.FindManimProjectByID(projectID)
-	if err != nil {
-		log.Errorf("TriggerManimGenerationAndRender: Failed to fetch project %s: %v", projectID.String(), err)
-		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retrieve Manim project", nil)
-		return
-	}
-	if project == nil {
-		log.Debugf("TriggerManimGenerationAndRender: Project with ID %s not found.", projectID.String())
-		utils.ResponseWithError(c, http.StatusNotFound, "Manim project not found", nil)
+	project, claims, ok := loadOwnedProject(c, "TriggerManimGenerationAndRender")
+	if !ok {
 		return
 	}
-	if project.UserID != claims.UserID {
-		log.Warnf("TriggerManimGenerationAndRender: User %s attempted to trigger render for project %s owned by %s.", claims.UserID.String(), projectID.String(), project.UserID.String())
-		utils.ResponseWithError(c, http.StatusForbidden, "You do not have permission to trigger rendering for this project", nil)
+	projectID := project.ID
+	if project.Locked {
+		respondForError(c, queries.ErrProjectLocked)
 		return
 	}
 
@@ -426,99 +713,61 @@ func (h *Handlers) TriggerManimGenerationAndRender(c *gin.Context) {
 		return
 	}
 
-	// 2. Update project status to indicate generation is in progress
-	project.RenderStatus = "generating"
-	err = queries.UpdateManimProject(project) // Update the status in DB
-	if err != nil {
-		log.Errorf("TriggerManimGenerationAndRender: Failed to update project %s status to 'generating': %v", projectID.String(), err)
-		// Continue as this is a best effort update, but log it
+	// Throttle rapid re-renders of the same project
+	if remaining := h.renderCooldownRemaining(project, claims.UserID); remaining > 0 {
+		seconds := int(math.Ceil(remaining.Seconds()))
+		log.Infof("TriggerManimGenerationAndRender: Project %s is in render cooldown for another %ds.", projectID.String(), seconds)
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		utils.ResponseWithError(c, http.StatusTooManyRequests, "This project was rendered recently. Please wait before rendering it again.", gin.H{"retry_after_seconds": seconds})
+		return
 	}
-	log.Infof("Project %s status updated to 'generating'.", projectID.String())
-
-
-	// --- Start of LLM Generation & Renderer Trigger ---
 
-	// 3. Generate Manim code using LLM
-	generatedManimCode, err := h.LLMClient.GenerateManimCode(project.Prompt)
-	if err != nil {
-		log.Errorf("TriggerManimGenerationAndRender: Failed to generate Manim code for project %s: %v", projectID.String(), err)
-		project.RenderStatus = "failed: code_gen_error"
-		queries.UpdateManimProject(project) // Best effort update
-		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to generate Manim code", nil)
-		return
-	}
-	log.Infof("Manim code generated for project %s. Length: %d", projectID.String(), len(generatedManimCode))
-
-    orchestratorPublicHost := os.Getenv("RENDER_EXTERNAL_HOSTNAME")
-    var callbackURL string
-
-    if orchestratorPublicHost == "" {
-        // Fallback for local development if RENDER_EXTERNAL_HOSTNAME isn't set.
-        // This scenario means you're likely NOT on Render.com.
-        log.Warn("RENDER_EXTERNAL_HOSTNAME not set. Assuming local development or non-Render environment.")
-        // For local testing, ensure your h.Config.Host is set to 'localhost' or '127.0.0.1' and use http.
-        // Example: If h.Config.Host is "localhost" and h.Config.Port is "8000"
-        callbackURL = fmt.Sprintf("http://%s:%s/api/projects/render-callback", h.Config.Host, h.Config.Port)
-        log.Infof("Using local/fallback callback URL: %s", callbackURL)
-    } else {
-        // For Render.com, services are always accessible via HTTPS on their public domain (port 443).
-        // Do NOT include the internal application port (like :8000) in the public URL.
-        callbackURL = "https://manim-orchestrator-api.onrender.com/api/projects/render-callback"
-        log.Infof("Using public Render.com callback URL: %s", callbackURL)
-    }
-
-	log.Infof("%s",callbackURL)
-
-	rendererReqBody := RendererRequest{
-		ProjectID:     project.ID.String(),
-		ScriptContent: generatedManimCode,
-		CallbackURL:   callbackURL,
-	}
-	log.Debugf("%s",rendererReqBody)
-
-	jsonBody, _ := json.Marshal(rendererReqBody)
-	
-	client := &http.Client{Timeout: 10 * time.Second} // Shorter timeout for initial request, as rendering is async
-	rendererURL := fmt.Sprintf("%s/render", h.Config.ManimRendererURL) // ManimRendererURL from config
-
-	req, err := http.NewRequest("POST", rendererURL, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		log.Errorf("TriggerManimGenerationAndRender: Failed to create request to renderer: %v", err)
-		project.RenderStatus = "failed: renderer_req_error"
-		queries.UpdateManimProject(project)
-		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to prepare render request", nil)
+	// Expand the requested preset, if any
+	var req TriggerRenderRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", utils.BindingErrorDetails(err))
 		return
 	}
-	req.Header.Set("Content-Type", "application/json")
+	var opts renderOptions
+	if req.Preset != "" {
+		preset, err := queries.FindRenderPresetByName(c.Request.Context(), claims.UserID, req.Preset)
+		if err != nil {
+			utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to load render preset", nil)
+			return
+		}
+		if preset == nil {
+			utils.ResponseWithError(c, http.StatusNotFound, "Render preset not found", gin.H{"preset": req.Preset})
+			return
+		}
+		opts = renderOptionsFromPreset(preset)
+	}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Errorf("TriggerManimGenerationAndRender: Failed to send request to renderer %s: %v", rendererURL, err)
-		project.RenderStatus = "failed: renderer_comm_error"
-		queries.UpdateManimProject(project)
-		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to connect to Manim renderer", nil)
-		return
+	// ?decompose=true renders the prompt as one child project per scene from the start. A prompt
+	// that doesn't split into several scenes is rendered whole.
+	if c.Query("decompose") == "true" {
+		if children := h.decomposeProject(c.Request.Context(), project); children != nil {
+			respondDecomposed(c, projectID, children, fmt.Sprintf("The prompt was split into %d scenes, each rendering as a child project.", len(children)))
+			return
+		}
+		log.Infof("TriggerManimGenerationAndRender: Rendering project %s as a single scene.", projectID.String())
 	}
-	defer resp.Body.Close()
 
-	// The renderer will respond immediately with 202 Accepted
-	if resp.StatusCode != http.StatusAccepted { // Expected 202
-		var errorResp map[string]string
-		json.NewDecoder(resp.Body).Decode(&errorResp)
-		errMsg := errorResp["error"]
-		if errMsg == "" {
-			errMsg = "Unknown error from renderer."
+	// 2-4. Generate the Manim code and hand it to the renderer
+	if rerr := h.startRender(c.Request.Context(), project, opts); rerr != nil {
+		// Retry prompts that look too complex for one scene as several smaller ones
+		if h.Config.AutoDecomposeOnFailure && isComplexityFailure(project, rerr) {
+			if children := h.decomposeProject(c.Request.Context(), project); children != nil {
+				respondDecomposed(c, projectID, children, fmt.Sprintf("The render failed, so the prompt was split into %d scenes, each rendering as a child project.", len(children)))
+				return
+			}
 		}
-		log.Errorf("TriggerManimGenerationAndRender: Renderer returned unexpected status %d: %s", resp.StatusCode, errMsg)
-		project.RenderStatus = fmt.Sprintf("failed: renderer_status_%d", resp.StatusCode)
-		queries.UpdateManimProject(project)
-		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to start Manim rendering process", errMsg)
+		respondRenderError(c, rerr)
 		return
 	}
 
 	// 5. Respond immediately to the client that rendering has started (asynchronous)
 	log.Infof("Manim rendering process initiated for project %s. Renderer returned 202 Accepted.", projectID.String())
-	utils.ResponseWithSuccess(c, http.StatusAccepted, "Manim rendering process initiated", gin.H{
+	utils.ResponseWithSuccessCode(c, http.StatusAccepted, CodeRenderInitiated, "Manim rendering process initiated", gin.H{
 		"project_id": projectID.String(),
 		"status":     "rendering_initiated",
 		"message":    "Manim rendering is in progress. The video URL will be updated via callback.",
@@ -530,12 +779,14 @@ func (h *Handlers) TriggerManimGenerationAndRender(c *gin.Context) {
 // --- NEW: HandleRenderCallback Handler ---
 // This endpoint receives the result of the Manim rendering from the Python service.
 func (h *Handlers) HandleRenderCallback(c *gin.Context) {
-	var callback RenderCallbackRequest // Use the struct defined above
-	if err := c.ShouldBindJSON(&callback); err != nil {
-		log.Errorf("HandleRenderCallback: Invalid callback request body: %v", err)
-		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid callback request body", err.Error())
+	// StoreInlineRenderVideo parsed the body and uploaded any inline video
+	parsed, ok := c.Get(renderCallbackKey)
+	if !ok {
+		log.Error("HandleRenderCallback: Route is missing the StoreInlineRenderVideo middleware.")
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to process callback", nil)
 		return
 	}
+	callback := parsed.(*RenderCallbackRequest)
 
 	projectID, err := uuid.Parse(callback.ProjectID)
 	if err != nil {
@@ -544,48 +795,74 @@ func (h *Handlers) HandleRenderCallback(c *gin.Context) {
 		return
 	}
 
+	// The signature was checked by RequireRenderCallbackSignature; it must be for this project
+	if !verifiedCallbackProject(c, projectID) {
+		log.Warnf("HandleRenderCallback: Rejected callback for project %s: %v", projectID.String(), errCallbackSignature)
+		utils.ResponseWithError(c, http.StatusUnauthorized, "Invalid or expired callback signature", nil)
+		return
+	}
+
 	log.Infof("Received render callback for Project ID: %s, Status: %s, VideoURL: %s",
 		callback.ProjectID, callback.Status, callback.VideoURL)
 
-	project, err := queries.FindManimProjectByID(projectID)
+	project, err := findCallbackProject(c.Request.Context(), projectID)
 	if err != nil {
 		log.Errorf("HandleRenderCallback: Failed to find project %s for callback: %v", projectID.String(), err)
 		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to find project for callback", nil)
 		return
 	}
 	if project == nil {
-		log.Warnf("HandleRenderCallback: Project %s not found for callback. Perhaps already deleted?", projectID.String())
+		log.Warnf("HandleRenderCallback: Project %s not found for callback. Perhaps already purged?", projectID.String())
 		utils.ResponseWithError(c, http.StatusNotFound, "Project not found for callback", nil)
 		return
 	}
 
-	// Update project status based on callback
-	project.RenderStatus = callback.Status
-	if callback.Status == "completed" {
-		// Only set video_url if status is completed and URL is not "N/A"
-		if callback.VideoURL != "" && callback.VideoURL != "N/A" {
-			project.VideoURL = sql.NullString{String: callback.VideoURL, Valid: true}
-			log.Infof("Project %s render completed. Video URL: %s", projectID.String(), callback.VideoURL)
-		} else {
-			project.VideoURL = sql.NullString{Valid: false} // Ensure it's NULL if completed but no URL
-			log.Warnf("Project %s completed, but no valid video URL provided in callback.", projectID.String())
+	// Callbacks are tied to one render and accepted once
+	if err := h.consumeRenderCallbackNonce(c, projectID); err != nil {
+		switch {
+		case errors.Is(err, errCallbackReused):
+			log.Warnf("HandleRenderCallback: Rejected replayed callback for project %s: %v", projectID.String(), err)
+			utils.ResponseWithError(c, http.StatusConflict, "Callback was already processed or belongs to another render", nil)
+		default:
+			log.Errorf("HandleRenderCallback: Failed to verify callback for project %s: %v", projectID.String(), err)
+			utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to verify callback", nil)
 		}
-	} else {
-		// Clear URL on failure/non-completed status
-		project.VideoURL = sql.NullString{Valid: false}
-		log.Errorf("Project %s rendering failed with status: %s. Details: %s", projectID.String(), callback.Status, callback.ErrorDetails)
+		return
 	}
 
+	// Update project status based on callback
+	applyRenderCallback(project, callback)
+
 	// Important: The `updated_at` field will be automatically updated by the DB trigger
 	// when we call queries.UpdateManimProject.
 
-	err = queries.UpdateManimProject(project)
+	err = queries.UpdateManimProject(c.Request.Context(), project)
 	if err != nil {
 		log.Errorf("HandleRenderCallback: Failed to update project %s status and URL after callback: %v", projectID.String(), err)
 		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to update project after rendering callback", nil)
 		return
 	}
 
+	// Close out the render job for usage statistics. A savepoint keeps a failure here from
+	// aborting the request transaction and losing the project update.
+	err = db.Savepoint(c.Request.Context(), "render_job", func() error {
+		if project.RenderStatus == "completed" {
+			return queries.FinishLatestRenderJob(c.Request.Context(), projectID, queries.RenderJobCompleted, "")
+		}
+		return queries.FinishLatestRenderJob(c.Request.Context(), projectID, queries.RenderJobFailed, project.RenderStatus)
+	})
+	if err != nil {
+		log.Errorf("HandleRenderCallback: Failed to record render job outcome for project %s: %v", projectID.String(), err)
+	}
+
+	// A scene of a decomposed project may finish the whole project
+	err = db.Savepoint(c.Request.Context(), "parent_status", func() error {
+		return updateDecomposedParent(c.Request.Context(), project)
+	})
+	if err != nil {
+		log.Errorf("HandleRenderCallback: Failed to update parent of project %s: %v", projectID.String(), err)
+	}
+
 	utils.ResponseWithSuccess(c, http.StatusOK, "Callback processed successfully", nil)
 }
 
@@ -596,163 +873,84 @@ func (h *Handlers) MergeVideosHandler(c *gin.Context) {
 	var req MergeVideoRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		log.Errorf("MergeVideosHandler: Invalid request body: %v", err)
-		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body. 'ids' (list of video IDs) is required.", err.Error())
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body. 'ids' (list of video IDs) is required.", utils.BindingErrorDetails(err))
 		return
 	}
 
+	// Clips carry per-clip trim points; plain IDs merge whole videos
+	if len(req.Clips) > 0 {
+		if len(req.IDs) > 0 {
+			utils.ResponseWithError(c, http.StatusBadRequest, "Provide either 'ids' or 'clips', not both.", nil)
+			return
+		}
+		if err := validateMergeClips(req.Clips); err != nil {
+			utils.ResponseWithError(c, http.StatusBadRequest, "Invalid clip trim range.", err.Error())
+			return
+		}
+		for _, clip := range req.Clips {
+			req.IDs = append(req.IDs, clip.ID)
+		}
+	}
+
 	if len(req.IDs) == 0 {
 		log.Warn("MergeVideosHandler: No video IDs provided for merging.")
 		utils.ResponseWithError(c, http.StatusBadRequest, "No video IDs provided for merging.", nil)
 		return
 	}
-
-	// --- OPTIONAL: OWNERSHIP VALIDATION REMOVED ---
-	// Since there's no user authenticated, you cannot validate ownership against a user ID.
-	// If you still need to ensure videos exist, you'd perform queries.FindManimProjectByID
-	// for each ID without checking `project.UserID` against `claims.UserID`.
-	/*
-		for _, videoIDStr := range req.IDs {
-			videoID, err := uuid.Parse(videoIDStr)
-			if err != nil {
-				log.Warnf("MergeVideosHandler: Invalid video ID format '%s': %v", videoIDStr, err)
-				utils.ResponseWithError(c, http.StatusBadRequest, fmt.Sprintf("Invalid video ID format: %s", videoIDStr), nil)
-				return
-			}
-			// This check for `project.UserID != claims.UserID` is no longer applicable
-			// without `claims`. If you still want to ensure projects exist,
-			// just remove the `claims.UserID` part.
-			project, err := queries.FindManimProjectByID(videoID)
-			if err != nil {
-				log.Errorf("MergeVideosHandler: Failed to fetch video/project %s for existence check: %v", videoID.String(), err)
-				utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to verify video existence", nil)
-				return
-			}
-			if project == nil {
-				log.Warnf("MergeVideosHandler: Video/project %s not found.", videoID.String())
-				utils.ResponseWithError(c, http.StatusNotFound, fmt.Sprintf("Video ID not found: %s", videoID.String()), nil)
-				return
-			}
-		}
-		log.Infof("MergeVideosHandler: Verified existence for %d video IDs.", len(req.IDs))
-	*/
-
-
-	// 2. Get the Python renderer URL for merging from your config
-	pythonMergeRendererURL := h.Config.ManimRendererURL
-	if pythonMergeRendererURL == "" {
-		log.Error("MergeVideosHandler: h.Config.ManimRendererURL is not set. Cannot proceed with merging.")
-		utils.ResponseWithError(c, http.StatusInternalServerError, "Backend configuration error: Python renderer URL for merging not set.", nil)
-		return
-	}
-	log.Infof("MergeVideosHandler: Using Python renderer URL for merging from config: %s", pythonMergeRendererURL)
-
-	// Fetch R2 domain configuration from environment variables (consider moving to h.Config)
-	pythonR2InternalDomain := os.Getenv("PYTHON_R2_INTERNAL_DOMAIN")
-	frontendR2PublicDomain := os.Getenv("FRONTEND_R2_PUBLIC_DOMAIN")
-
-	if pythonR2InternalDomain == "" || frontendR2PublicDomain == "" {
-		log.Warn("MergeVideosHandler: PYTHON_R2_INTERNAL_DOMAIN or FRONTEND_R2_PUBLIC_DOMAIN not set. Merged video URL will not be transformed for frontend display.")
-	}
-
-	// 3. Prepare the request payload to send to the Python renderer
-	payloadBytes, err := json.Marshal(req)
-	if err != nil {
-		log.Errorf("MergeVideosHandler: Failed to marshal payload for Python renderer: %v", err)
-		utils.ResponseWithError(c, http.StatusInternalServerError, "Internal server error preparing merge request.", nil)
+	if h.Config.MaxMergeInputs > 0 && len(req.IDs) > h.Config.MaxMergeInputs {
+		log.Warnf("MergeVideosHandler: Rejected merge of %d videos (limit %d).", len(req.IDs), h.Config.MaxMergeInputs)
+		utils.ResponseWithError(c, http.StatusBadRequest,
+			fmt.Sprintf("Too many videos to merge: at most %d are allowed per merge.", h.Config.MaxMergeInputs),
+			gin.H{"requested": len(req.IDs), "max_merge_inputs": h.Config.MaxMergeInputs})
 		return
 	}
-
-	// Construct the full endpoint for the merge operation on the Python renderer
-	flaskEndpoint := fmt.Sprintf("%s/merge_videos", pythonMergeRendererURL)
-	log.Infof("MergeVideosHandler: Forwarding merge request to Python renderer at: %s with IDs: %v", flaskEndpoint, req.IDs)
-
-	// 4. Make the HTTP POST request to the Python renderer
-	client := &http.Client{Timeout: 60 * time.Second} // Give Python some time to merge
-	resp, err := client.Post(flaskEndpoint, "application/json", bytes.NewBuffer(payloadBytes))
-	if err != nil {
-		log.Errorf("MergeVideosHandler: Failed to connect to Python renderer at %s: %v", flaskEndpoint, err)
-		utils.ResponseWithError(c, http.StatusBadGateway, "Failed to connect to video processing service for merging.", nil)
+	if duration, known := mergeClipsDuration(req.Clips); known && h.Config.MaxMergeDurationSeconds > 0 && duration > float64(h.Config.MaxMergeDurationSeconds) {
+		log.Warnf("MergeVideosHandler: Rejected merge of %.1fs (limit %ds).", duration, h.Config.MaxMergeDurationSeconds)
+		utils.ResponseWithError(c, http.StatusBadRequest,
+			fmt.Sprintf("Merged video would be too long: at most %d seconds are allowed.", h.Config.MaxMergeDurationSeconds),
+			gin.H{"duration_seconds": duration, "max_merge_duration_seconds": h.Config.MaxMergeDurationSeconds})
 		return
 	}
-	defer resp.Body.Close()
 
-	// 5. Read and parse the response from the Python renderer
-	responseBody, err := ioutil.ReadAll(resp.Body)
+	// Check every source exists and has a finished video; only those are sent to the renderer.
+	// Ownership isn't validated here since this route is not authenticated.
+	sources, includedIDs, err := collectMergeSources(c.Request.Context(), req.IDs)
 	if err != nil {
-		log.Errorf("MergeVideosHandler: Failed to read response from Python renderer: %v", err)
-		utils.ResponseWithError(c, http.StatusInternalServerError, "Error reading response from video merging service.", nil)
+		log.Errorf("MergeVideosHandler: Failed to look up merge sources: %v", err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to verify video existence", nil)
 		return
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		log.Errorf("MergeVideosHandler: Python renderer returned status %d with body: %s", resp.StatusCode, string(responseBody))
-		var pythonErrorResp PythonMergeResponse
-		if jsonErr := json.Unmarshal(responseBody, &pythonErrorResp); jsonErr == nil && pythonErrorResp.Error != "" {
-			utils.ResponseWithError(c, resp.StatusCode, pythonErrorResp.Error, nil)
-		} else {
-			utils.ResponseWithError(c, resp.StatusCode, "Video merging service reported an error.", string(responseBody))
-		}
+	if len(includedIDs) == 0 {
+		log.Warnf("MergeVideosHandler: None of the %d requested videos are ready for merging.", len(req.IDs))
+		utils.ResponseWithError(c, http.StatusBadRequest, "None of the requested videos are ready for merging.", gin.H{"sources": sources})
 		return
 	}
+	log.Infof("MergeVideosHandler: %d of %d requested videos are ready for merging.", len(includedIDs), len(req.IDs))
 
-	// 6. Successfully merged - parse Python's success response
-	var pythonSuccessResp PythonMergeResponse
-	if err := json.Unmarshal(responseBody, &pythonSuccessResp); err != nil {
-		log.Errorf("MergeVideosHandler: Failed to unmarshal success response from Python renderer: %v. Body: %s", err, string(responseBody))
-		utils.ResponseWithError(c, http.StatusInternalServerError, "Error parsing successful merge response from Python.", nil)
-		return
-	}
 
-	// --- PERFORM THE URL TRANSFORMATION HERE ---
-	finalURLForFrontend := pythonSuccessResp.MergedVideoURL
-	if pythonSuccessResp.MergedVideoURL != "" && pythonR2InternalDomain != "" && frontendR2PublicDomain != "" {
-		parsedURL, err := url.Parse(pythonSuccessResp.MergedVideoURL)
-		if err != nil {
-			log.Warnf("MergeVideosHandler: Could not parse merged video URL from Python: %s. Error: %v. Skipping transformation.", pythonSuccessResp.MergedVideoURL, err)
-		} else {
-			internalDomain := strings.TrimSuffix(pythonR2InternalDomain, "/")
-			publicDomain := strings.TrimSuffix(frontendR2PublicDomain, "/")
-
-			if strings.EqualFold(parsedURL.Scheme+"://"+parsedURL.Host, internalDomain) {
-				originalURL := pythonSuccessResp.MergedVideoURL
-				finalURLForFrontend = fmt.Sprintf("%s%s", publicDomain, parsedURL.Path)
-				log.Infof("MergeVideosHandler: Transformed URL from %s to %s", originalURL, finalURLForFrontend)
-			} else {
-				log.Warnf("MergeVideosHandler: Merged video URL '%s' does not use expected internal domain '%s'. No transformation applied.", pythonSuccessResp.MergedVideoURL, internalDomain)
+	// 2. Prepare the request payload to send to the Python renderer
+	payload := MergeVideoRequest{IDs: includedIDs}
+	if len(req.Clips) > 0 {
+		// sources[i] describes req.Clips[i]; forward the trims of the clips that made it in
+		for i, source := range sources {
+			if source.Included {
+				payload.Clips = append(payload.Clips, req.Clips[i])
 			}
 		}
-	} else if pythonSuccessResp.MergedVideoURL != "" {
-		log.Warn("MergeVideosHandler: Domain transformation skipped due to missing environment variables. Merged video URL is not transformed.")
 	}
-	// --- END URL TRANSFORMATION ---
 
-	// --- Store the final R2 URL in Neon PostgreSQL using your 'db' package ---
-	if db.DB == nil {
-		log.Error("MergeVideosHandler: Database connection (db.DB) is not initialized.")
-		utils.ResponseWithError(c, http.StatusInternalServerError, "Database connection error.", nil)
+	// 3. Record the merge job so a failed merge can be retried with the same inputs, then hand the
+	// merge to the renderer, which reports the result to POST /api/merge-callback
+	mergedID := uuid.New()
+	job := h.recordMergeJob(c.Request.Context(), payload, mergedID)
+	// The route isn't authenticated, so the merged video has no owner and appears in no user's
+	// merged video list; owning the sources doesn't mean the caller is that user
+	merged, mergeErr := h.submitMerge(c.Request.Context(), mergedID, uuid.NullUUID{}, payload)
+	if mergeErr != nil {
+		utils.ResponseWithError(c, mergeErr.Status, mergeErr.Message, mergeErrorDetails(job, mergeErr))
 		return
 	}
 
-	query := `INSERT INTO merged_videos (id, r2_url) VALUES (:id, :r2_url) ON CONFLICT (id) DO UPDATE SET r2_url = EXCLUDED.r2_url;`
-
-	_, err = db.DB.NamedExec(query, map[string]interface{}{
-		"id":     pythonSuccessResp.MergedVideoID,
-		"r2_url": finalURLForFrontend,
-	})
-	if err != nil {
-		log.Errorf("MergeVideosHandler: Failed to insert/update merged video URL in Neon DB: %v", err)
-		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to record merged video in database.", nil)
-		return
-	}
-	log.Infof("MergeVideosHandler: Successfully stored R2 URL '%s' for ID '%s' in Neon DB.", finalURLForFrontend, pythonSuccessResp.MergedVideoID)
-	// --- END Neon PostgreSQL Storage ---
-
-	// 7. Respond to the frontend with the merged video details
-	log.Infof("MergeVideosHandler: Successfully merged videos. Final URL for frontend: %s", finalURLForFrontend)
-	finalResponse := MergedVideoResponse{
-		Message:        "Videos merged, uploaded to R2, and URL recorded in Neon successfully.",
-		MergedVideoID:  pythonSuccessResp.MergedVideoID,
-		MergedVideoURL: finalURLForFrontend, // This is the transformed R2 URL
-	}
-	utils.ResponseWithSuccess(c, http.StatusOK, "Videos merged and uploaded successfully", finalResponse)
+	// 4. Respond to the frontend with the merged video ID to poll, or the video if it's already done
+	respondMergeSubmitted(c, merged, job, sources)
 }
\ No newline at end of file
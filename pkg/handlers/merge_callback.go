@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// mergeCallbackNonce stands in for the render nonce in merge callback signatures. Merge
+// callbacks can't be replayed anyway: only a merging video accepts an outcome.
+const mergeCallbackNonce = "merge"
+
+// MergeCallbackRequest is the body the renderer posts to /api/merge-callback when a merge finishes.
+type MergeCallbackRequest struct {
+	MergedVideoID  string  `json:"merged_video_id" binding:"required,uuid"`
+	Status         string  `json:"status" binding:"required"` // "completed" or "failed"
+	MergedVideoURL string  `json:"merged_video_url"`          // R2 URL from the renderer on success
+	Duration       float64 `json:"duration"`                  // Length of the merged video in seconds, if known
+	Error          string  `json:"error"`                     // Failure reason
+}
+
+// mergeCallbackURL returns the callback URL for a merge and the signature the renderer must send
+// back in the X-Render-Signature header. The URL carries the merged video (m) and an expiry (exp),
+// signed like render callbacks.
+func (h *Handlers) mergeCallbackURL(mergedID uuid.UUID) (string, string) {
+	callbackURL := h.callbackURL("/api/merge-callback")
+	expires := time.Now().Add(time.Duration(h.Config.CallbackTTLSeconds) * time.Second).Unix()
+	query := url.Values{}
+	query.Set("m", mergedID.String())
+	query.Set("exp", strconv.FormatInt(expires, 10))
+	signature := callbackSignature(h.Config.CallbackSigningSecret, mergedID.String(), mergeCallbackNonce, expires)
+	return callbackURL + "?" + query.Encode(), signature
+}
+
+// checkMergeCallbackSignature verifies the X-Render-Signature header of a merge callback. Without
+// CALLBACK_SIGNING_SECRET no callback can be verified, so all are rejected.
+func (h *Handlers) checkMergeCallbackSignature(c *gin.Context, mergedID uuid.UUID) error {
+	secret := h.Config.CallbackSigningSecret
+	if secret == "" {
+		return errCallbackSignature
+	}
+	expires, err := strconv.ParseInt(c.Query("exp"), 10, 64)
+	if err != nil || c.Query("m") != mergedID.String() {
+		return errCallbackSignature
+	}
+	signature := c.GetHeader(renderSignatureHeader)
+	expected := callbackSignature(secret, mergedID.String(), mergeCallbackNonce, expires)
+	if signature == "" || !hmac.Equal([]byte(signature), []byte(expected)) {
+		return errCallbackSignature
+	}
+	if time.Now().Unix() > expires {
+		return errCallbackExpired
+	}
+	return nil
+}
+
+// HandleMergeCallback receives the outcome of a merge from the renderer and records it on the
+// merged video and its merge job. Only a merge that is still running accepts an outcome.
+func (h *Handlers) HandleMergeCallback(c *gin.Context) {
+	var callback MergeCallbackRequest
+	if err := c.ShouldBindJSON(&callback); err != nil {
+		log.Errorf("HandleMergeCallback: Invalid callback request body: %v", err)
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid callback request body", utils.BindingErrorDetails(err))
+		return
+	}
+	mergedID := uuid.MustParse(callback.MergedVideoID) // Validated by the binding
+
+	if err := h.checkMergeCallbackSignature(c, mergedID); err != nil {
+		log.Warnf("HandleMergeCallback: Rejected callback for merged video %s: %v", mergedID.String(), err)
+		utils.ResponseWithError(c, http.StatusUnauthorized, "Invalid or expired callback signature", nil)
+		return
+	}
+	log.Infof("Received merge callback for merged video %s, Status: %s, URL: %s", mergedID.String(), callback.Status, callback.MergedVideoURL)
+
+	merged, err := queries.FindMergedVideoByID(c.Request.Context(), mergedID)
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to find merged video for callback", nil)
+		return
+	}
+	if merged == nil {
+		utils.ResponseWithError(c, http.StatusNotFound, "Merged video not found for callback", nil)
+		return
+	}
+
+	if callback.Status == queries.MergedVideoCompleted && isUsableVideoURL(callback.MergedVideoURL) {
+		_, err = h.completeMerge(c.Request.Context(), mergedID, callback.MergedVideoURL)
+	} else {
+		reason := callback.Error
+		if reason == "" {
+			reason = "merge ended with status " + strconv.Quote(callback.Status) + " and no usable video URL"
+		}
+		log.Warnf("HandleMergeCallback: Merge %s failed: %s", mergedID.String(), reason)
+		err = queries.FinishMergedVideo(c.Request.Context(), mergedID, queries.MergedVideoFailed, "", reason)
+		if err == nil {
+			if jobErr := queries.FinishMergeJobs(c.Request.Context(), mergedID, queries.MergeJobFailed, reason); jobErr != nil {
+				log.Errorf("HandleMergeCallback: Failed to record failure of merge %s on its job: %v", mergedID.String(), jobErr)
+			}
+		}
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.ResponseWithError(c, http.StatusConflict, "Merge already finished", gin.H{"status": merged.Status})
+		return
+	}
+	if err != nil {
+		log.Errorf("HandleMergeCallback: Failed to record outcome of merge %s: %v", mergedID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to record merge outcome", nil)
+		return
+	}
+	utils.ResponseWithSuccess(c, http.StatusOK, "Merge callback processed successfully", nil)
+}
+
+// respondMergeSubmitted answers a submitted merge: 202 with the merged video ID while the renderer
+// works on it, or 200 with the video when a synchronous renderer already finished it.
+func respondMergeSubmitted(c *gin.Context, merged *PythonMergeResponse, job *db.MergeJob, sources []MergeSourceStatus) {
+	response := MergedVideoResponse{
+		MergedVideoID:  merged.MergedVideoID,
+		MergedVideoURL: merged.MergedVideoURL,
+		Status:         queries.MergedVideoMerging,
+		Sources:        sources,
+	}
+	if job != nil {
+		response.MergeJobID = job.ID.String()
+	}
+	if merged.MergedVideoURL == "" {
+		response.Message = "Merge started. The merged video URL will be set via callback."
+		utils.ResponseWithSuccess(c, http.StatusAccepted, "Video merge initiated", response)
+		return
+	}
+
+	response.Status = queries.MergedVideoCompleted
+	response.Message = "Videos merged, uploaded to R2, and URL recorded in Neon successfully."
+	if merged.Duration > 0 {
+		response.TotalDurationSeconds = &merged.Duration
+	}
+	utils.ResponseWithSuccess(c, http.StatusOK, "Videos merged and uploaded successfully", response)
+}
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/middleware"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// RendererMergeRequest is the merge payload sent to the renderer's /merge_videos endpoint.
+type RendererMergeRequest struct {
+	MergeVideoRequest
+	MergedVideoID     string `json:"merged_video_id"`              // ID the renderer reports back in the merge callback
+	CallbackURL       string `json:"callback_url"`                 // POST /api/merge-callback, signed like render callbacks
+	CallbackSignature string `json:"callback_signature,omitempty"` // To send back as X-Render-Signature
+}
+
+// submitMerge records the merged video as merging, owned by ownerID, and hands the merge to the renderer, which
+// reports the result to POST /api/merge-callback. A renderer that still merges synchronously and
+// answers with the merged video URL has its merge finished straight away; the returned response
+// then carries the URL rewritten for the frontend, otherwise just the merged video ID.
+func (h *Handlers) submitMerge(ctx context.Context, mergedID uuid.UUID, ownerID uuid.NullUUID, payload MergeVideoRequest) (*PythonMergeResponse, *renderError) {
+	pythonMergeRendererURL := h.Config.ManimRendererURL
+	if pythonMergeRendererURL == "" {
+		log.WithContext(ctx).Error("submitMerge: h.Config.ManimRendererURL is not set. Cannot proceed with merging.")
+		return nil, &renderError{Status: http.StatusInternalServerError, Message: "Backend configuration error: Python renderer URL for merging not set.", Err: errors.New("renderer URL not set")}
+	}
+	if db.DB == nil {
+		log.WithContext(ctx).Error("submitMerge: Database connection (db.DB) is not initialized.")
+		return nil, &renderError{Status: http.StatusInternalServerError, Message: "Database connection error.", Err: errors.New("database not initialized")}
+	}
+
+	if err := queries.CreateMergedVideo(ctx, mergedID, ownerID); err != nil {
+		return nil, &renderError{Status: http.StatusInternalServerError, Message: "Failed to record merged video in database.", Err: err}
+	}
+	// Remember which projects go into the merge, for reverse lookups
+	sourceIDs := make([]uuid.UUID, 0, len(payload.IDs))
+	for _, id := range payload.IDs {
+		sourceIDs = append(sourceIDs, uuid.MustParse(id)) // Validated by collectMergeSources
+	}
+	if err := queries.ReplaceMergedVideoSources(ctx, mergedID, sourceIDs); err != nil {
+		log.WithContext(ctx).Errorf("submitMerge: Failed to record sources of merged video %s: %v", mergedID.String(), err)
+	}
+
+	callbackURL, signature := h.mergeCallbackURL(mergedID)
+	payloadBytes, err := json.Marshal(RendererMergeRequest{
+		MergeVideoRequest: payload,
+		MergedVideoID:     mergedID.String(),
+		CallbackURL:       callbackURL,
+		CallbackSignature: signature,
+	})
+	if err != nil {
+		log.WithContext(ctx).Errorf("submitMerge: Failed to marshal payload for Python renderer: %v", err)
+		return nil, h.failMerge(ctx, mergedID, &renderError{Status: http.StatusInternalServerError, Message: "Internal server error preparing merge request.", Err: err})
+	}
+
+	// Construct the full endpoint for the merge operation on the Python renderer
+	flaskEndpoint := fmt.Sprintf("%s/merge_videos", pythonMergeRendererURL)
+	log.WithContext(ctx).Infof("submitMerge: Forwarding merge %s to Python renderer at: %s with IDs: %v", mergedID.String(), flaskEndpoint, payload.IDs)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, flaskEndpoint, bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return nil, h.failMerge(ctx, mergedID, &renderError{Status: http.StatusInternalServerError, Message: "Internal server error preparing merge request.", Err: err})
+	}
+	req.Header.Set("Content-Type", "application/json")
+	middleware.PropagateRequestID(ctx, req)
+	client := &http.Client{Timeout: 10 * time.Second} // The renderer only acknowledges the merge; the result comes by callback
+	resp, err := client.Do(req)
+	if err != nil {
+		log.WithContext(ctx).Errorf("submitMerge: Failed to connect to Python renderer at %s: %v", flaskEndpoint, err)
+		return nil, h.failMerge(ctx, mergedID, &renderError{Status: http.StatusBadGateway, Message: "Failed to connect to video processing service for merging.", Err: err})
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.WithContext(ctx).Errorf("submitMerge: Failed to read response from Python renderer: %v", err)
+		return nil, h.failMerge(ctx, mergedID, &renderError{Status: http.StatusInternalServerError, Message: "Error reading response from video merging service.", Err: err})
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		log.WithContext(ctx).Errorf("submitMerge: Python renderer returned status %d with body: %s", resp.StatusCode, string(responseBody))
+		rendererErr := fmt.Errorf("renderer returned status %d", resp.StatusCode)
+		var pythonErrorResp PythonMergeResponse
+		if jsonErr := json.Unmarshal(responseBody, &pythonErrorResp); jsonErr == nil && pythonErrorResp.Error != "" {
+			return nil, h.failMerge(ctx, mergedID, &renderError{Status: resp.StatusCode, Message: pythonErrorResp.Error, Err: rendererErr})
+		}
+		return nil, h.failMerge(ctx, mergedID, &renderError{Status: resp.StatusCode, Message: "Video merging service reported an error.", Details: string(responseBody), Err: rendererErr})
+	}
+
+	merged := PythonMergeResponse{}
+	if len(bytes.TrimSpace(responseBody)) > 0 {
+		if err := json.Unmarshal(responseBody, &merged); err != nil {
+			log.WithContext(ctx).Warnf("submitMerge: Ignoring unparseable response from Python renderer: %v. Body: %s", err, string(responseBody))
+		}
+	}
+	if merged.MergedVideoID != "" && merged.MergedVideoID != mergedID.String() {
+		log.WithContext(ctx).Warnf("submitMerge: Renderer reported merged video ID %s for merge %s; keeping ours.", merged.MergedVideoID, mergedID.String())
+	}
+	merged.MergedVideoID = mergedID.String()
+	if merged.MergedVideoURL == "" {
+		log.WithContext(ctx).Infof("submitMerge: Renderer accepted merge %s; waiting for its callback.", mergedID.String())
+		return &merged, nil
+	}
+
+	// A synchronous renderer already finished the merge
+	finalURL, err := h.completeMerge(ctx, mergedID, merged.MergedVideoURL)
+	if err != nil {
+		return nil, &renderError{Status: http.StatusInternalServerError, Message: "Failed to record merged video in database.", Err: err}
+	}
+	merged.MergedVideoURL = finalURL
+	return &merged, nil
+}
+
+// mergedVideoURLForFrontend rewrites a merged video URL from the renderer's R2 domain to the public one.
+func (h *Handlers) mergedVideoURLForFrontend(rendererURL string) string {
+	if h.Config.R2InternalDomain == "" || h.Config.R2PublicDomain == "" {
+		log.Warn("PYTHON_R2_INTERNAL_DOMAIN or FRONTEND_R2_PUBLIC_DOMAIN not set. Merged video URL will not be transformed for frontend display.")
+	}
+	finalURL := h.Config.RewriteVideoURL(rendererURL)
+	if finalURL != rendererURL {
+		log.Infof("Transformed merged video URL from %s to %s", rendererURL, finalURL)
+	} else {
+		log.Debugf("Merged video URL '%s' left unchanged (no matching R2 domain rewrite configured).", rendererURL)
+	}
+	return finalURL
+}
+
+// completeMerge records a finished merge on the merged video and its job, returning the video URL
+// as stored for the frontend. Returns sql.ErrNoRows if the merge already finished.
+func (h *Handlers) completeMerge(ctx context.Context, mergedID uuid.UUID, rendererURL string) (string, error) {
+	finalURL := h.mergedVideoURLForFrontend(rendererURL)
+	if err := queries.FinishMergedVideo(ctx, mergedID, queries.MergedVideoCompleted, finalURL, ""); err != nil {
+		return "", err
+	}
+	if err := queries.FinishMergeJobs(ctx, mergedID, queries.MergeJobCompleted, ""); err != nil {
+		log.WithContext(ctx).Errorf("completeMerge: Failed to record completion of merge %s on its job: %v", mergedID.String(), err)
+	}
+	log.WithContext(ctx).Infof("Merged video %s completed: %s", mergedID.String(), finalURL)
+	return finalURL, nil
+}
+
+// failMerge records a failed merge on the merged video and its job and returns mergeErr, so
+// callers can write return nil, h.failMerge(...).
+func (h *Handlers) failMerge(ctx context.Context, mergedID uuid.UUID, mergeErr *renderError) *renderError {
+	if err := queries.FinishMergedVideo(ctx, mergedID, queries.MergedVideoFailed, "", mergeErr.Error()); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.WithContext(ctx).Errorf("failMerge: Failed to record failure of merged video %s: %v", mergedID.String(), err)
+	}
+	if err := queries.FinishMergeJobs(ctx, mergedID, queries.MergeJobFailed, mergeErr.Error()); err != nil {
+		log.WithContext(ctx).Errorf("failMerge: Failed to record failure of merge %s on its job: %v", mergedID.String(), err)
+	}
+	return mergeErr
+}
+
+// recordMergeJob stores the payload of a new merge producing the merged video. Best effort: on
+// failure the merge still runs but can't be retried, and nil is returned.
+func (h *Handlers) recordMergeJob(ctx context.Context, payload MergeVideoRequest, mergedID uuid.UUID) *db.MergeJob {
+	inputs, err := json.Marshal(payload)
+	if err != nil {
+		log.WithContext(ctx).Errorf("recordMergeJob: Failed to marshal merge inputs: %v", err)
+		return nil
+	}
+	job, err := queries.CreateMergeJob(ctx, db.JSONB(inputs), mergedID)
+	if err != nil {
+		log.WithContext(ctx).Errorf("recordMergeJob: Failed to record merge job: %v", err)
+		return nil
+	}
+	return job
+}
+
+// mergeErrorDetails adds the merge job ID to a failed merge's error details so clients can retry it.
+func mergeErrorDetails(job *db.MergeJob, mergeErr *renderError) interface{} {
+	if job == nil {
+		return mergeErr.Details
+	}
+	details := gin.H{"merge_job_id": job.ID}
+	if mergeErr.Details != nil {
+		details["details"] = mergeErr.Details
+	}
+	return details
+}
+
+// RetryMerge resubmits a failed merge job to the renderer with its stored inputs. The caller must
+// own every source project of the merge.
+func (h *Handlers) RetryMerge(c *gin.Context) {
+	jobID, err := uuid.Parse(c.Param("jobId"))
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid merge job ID format", nil)
+		return
+	}
+
+	claims, exists := middleware.GetUserClaimsFromContext(c)
+	if !exists {
+		log.Error("RetryMerge: User claims not found in context.")
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+		return
+	}
+
+	job, err := queries.FindMergeJobByID(c.Request.Context(), jobID)
+	if err != nil {
+		log.Errorf("RetryMerge: Failed to fetch merge job %s: %v", jobID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retrieve merge job", nil)
+		return
+	}
+	if job == nil {
+		utils.ResponseWithError(c, http.StatusNotFound, "Merge job not found", nil)
+		return
+	}
+
+	var payload MergeVideoRequest
+	if err := json.Unmarshal(job.Inputs, &payload); err != nil {
+		log.Errorf("RetryMerge: Stored inputs of merge job %s are invalid: %v", jobID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Merge job inputs are corrupt", nil)
+		return
+	}
+	for _, id := range payload.IDs {
+		projectID, err := uuid.Parse(id)
+		if err != nil {
+			utils.ResponseWithError(c, http.StatusInternalServerError, "Merge job inputs are corrupt", nil)
+			return
+		}
+		project, err := queries.FindManimProjectByID(c.Request.Context(), projectID)
+		if err != nil {
+			log.Errorf("RetryMerge: Failed to fetch source project %s: %v", id, err)
+			utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to verify merge sources", nil)
+			return
+		}
+		if project == nil || project.UserID != claims.UserID {
+			log.Warnf("RetryMerge: User %s attempted to retry merge job %s including project %s they don't own.", claims.UserID.String(), jobID.String(), id)
+			utils.ResponseWithError(c, http.StatusForbidden, "You do not have permission to retry this merge", nil)
+			return
+		}
+	}
+
+	// Retry into the same merged video, so its sources and links stay valid
+	mergedID := job.MergedVideoID.UUID
+	if !job.MergedVideoID.Valid {
+		mergedID = uuid.New()
+	}
+	if err := queries.RestartMergeJob(c.Request.Context(), jobID, mergedID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.ResponseWithError(c, http.StatusConflict, "Only failed merges can be retried", gin.H{"status": job.Status})
+			return
+		}
+		log.Errorf("RetryMerge: Failed to restart merge job %s: %v", jobID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retry merge", nil)
+		return
+	}
+	log.Infof("RetryMerge: User %s retrying merge job %s (attempt %d).", claims.UserID.String(), jobID.String(), job.Attempts+1)
+
+	// Every source was checked to be the caller's above
+	ownerID := uuid.NullUUID{UUID: claims.UserID, Valid: true}
+	merged, mergeErr := h.submitMerge(c.Request.Context(), mergedID, ownerID, payload)
+	if mergeErr != nil {
+		utils.ResponseWithError(c, mergeErr.Status, mergeErr.Message, mergeErrorDetails(job, mergeErr))
+		return
+	}
+	respondMergeSubmitted(c, merged, job, nil)
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCollectMergeSourcesRevealsOnlyInclusion(t *testing.T) {
+	missing, pending, completed := uuid.New(), uuid.New(), uuid.New()
+	columns := []string{"id", "user_id", "render_status", "video_url"}
+	withFakeQueryDB(t,
+		fakeQuery{match: "WHERE id = $1 AND deleted_at IS NULL"},
+		fakeQuery{match: "WHERE id = $1 AND deleted_at IS NULL", columns: columns,
+			rows: [][]driver.Value{{pending.String(), uuid.NewString(), "rendering", nil}}},
+		fakeQuery{match: "WHERE id = $1 AND deleted_at IS NULL", columns: columns,
+			rows: [][]driver.Value{{completed.String(), uuid.NewString(), "completed", "https://videos.example.com/a.mp4"}}},
+	)
+
+	ids := []string{"not-a-uuid", missing.String(), pending.String(), completed.String()}
+	sources, included, err := collectMergeSources(context.Background(), ids)
+	if err != nil {
+		t.Fatalf("collectMergeSources: %v", err)
+	}
+	if len(included) != 1 || included[0] != completed.String() {
+		t.Errorf("included = %v, want only %s", included, completed)
+	}
+
+	got, _ := json.Marshal(sources)
+	want := `[{"id":"not-a-uuid","included":false},{"id":"` + missing.String() + `","included":false},` +
+		`{"id":"` + pending.String() + `","included":false},{"id":"` + completed.String() + `","included":true}]`
+	if string(got) != want {
+		t.Errorf("sources = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/middleware"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// MergedVideoSummary identifies a merged video.
+type MergedVideoSummary struct {
+	ID        uuid.UUID `json:"id"`
+	VideoURL  string    `json:"video_url"`       // Empty until the merge completes
+	Status    string    `json:"status"`          // merging, completed or failed
+	Error     string    `json:"error,omitempty"` // Why the merge failed
+	CreatedAt string    `json:"created_at"`
+}
+
+// newMergedVideoSummary converts a db.MergedVideo, rewriting its URL for the frontend.
+func (h *Handlers) newMergedVideoSummary(merged *db.MergedVideo) MergedVideoSummary {
+	return MergedVideoSummary{
+		ID:        merged.ID,
+		VideoURL:  h.Config.RewriteVideoURL(merged.R2URL),
+		Status:    merged.Status,
+		Error:     merged.Error.String,
+		CreatedAt: merged.CreatedAt.UTC().Format(http.TimeFormat),
+	}
+}
+
+// MergedVideoSourceResponse describes one source project of a merged video.
+type MergedVideoSourceResponse struct {
+	Position     int       `json:"position"`
+	ProjectID    uuid.UUID `json:"project_id"`
+	Name         string    `json:"name"`
+	RenderStatus string    `json:"render_status"`
+	VideoURL     string    `json:"video_url"`
+}
+
+// GetProjectMerges lists the merged videos that include the project as a source, so users can
+// see what depends on a project before deleting it.
+func (h *Handlers) GetProjectMerges(c *gin.Context) {
+	project, _, ok := loadOwnedProject(c, "GetProjectMerges")
+	if !ok {
+		return
+	}
+
+	merged, err := queries.FindMergedVideosByProjectID(c.Request.Context(), project.ID)
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retrieve merged videos", nil)
+		return
+	}
+	summaries := make([]MergedVideoSummary, len(merged))
+	for i := range merged {
+		summaries[i] = h.newMergedVideoSummary(&merged[i])
+	}
+	utils.ResponseWithSuccess(c, http.StatusOK, "Merged videos retrieved successfully", summaries)
+}
+
+// ListMergedVideos lists the caller's merged videos, newest first. Merges of projects from
+// several accounts have no owner and aren't listed.
+func (h *Handlers) ListMergedVideos(c *gin.Context) {
+	claims, exists := middleware.GetUserClaimsFromContext(c)
+	if !exists {
+		log.Error("ListMergedVideos: User claims not found in context.")
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+		return
+	}
+
+	merged, err := queries.FindMergedVideosByUser(c.Request.Context(), claims.UserID)
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retrieve merged videos", nil)
+		return
+	}
+	summaries := make([]MergedVideoSummary, len(merged))
+	for i := range merged {
+		summaries[i] = h.newMergedVideoSummary(&merged[i])
+	}
+	utils.ResponseWithSuccess(c, http.StatusOK, "Merged videos retrieved successfully", summaries)
+}
+
+// GetMergedVideo returns one of the caller's merged videos. Someone else's, or one without an
+// owner, is reported as not found.
+func (h *Handlers) GetMergedVideo(c *gin.Context) {
+	mergedID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid merged video ID format", nil)
+		return
+	}
+	claims, exists := middleware.GetUserClaimsFromContext(c)
+	if !exists {
+		log.Error("GetMergedVideo: User claims not found in context.")
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+		return
+	}
+
+	merged, err := queries.FindMergedVideoByID(c.Request.Context(), mergedID)
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retrieve merged video", nil)
+		return
+	}
+	if merged == nil || !merged.UserID.Valid || merged.UserID.UUID != claims.UserID {
+		utils.ResponseWithError(c, http.StatusNotFound, "Merged video not found", nil)
+		return
+	}
+	utils.ResponseWithSuccess(c, http.StatusOK, "Merged video retrieved successfully", h.newMergedVideoSummary(merged))
+}
+
+// GetMergedVideoSources lists the source projects of a merged video with their current statuses.
+// A merge may combine several users' projects, so only sources owned by the caller are returned,
+// and a merged video with none of the caller's projects is reported as not found.
+func (h *Handlers) GetMergedVideoSources(c *gin.Context) {
+	mergedID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid merged video ID format", nil)
+		return
+	}
+	claims, exists := middleware.GetUserClaimsFromContext(c)
+	if !exists {
+		log.Error("GetMergedVideoSources: User claims not found in context.")
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+		return
+	}
+
+	merged, err := queries.FindMergedVideoByID(c.Request.Context(), mergedID)
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retrieve merged video", nil)
+		return
+	}
+	if merged == nil {
+		utils.ResponseWithError(c, http.StatusNotFound, "Merged video not found", nil)
+		return
+	}
+	sources, err := queries.FindMergedVideoSources(c.Request.Context(), mergedID)
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retrieve merged video sources", nil)
+		return
+	}
+
+	owned := make([]MergedVideoSourceResponse, 0, len(sources))
+	for _, source := range sources {
+		if source.UserID != claims.UserID {
+			continue
+		}
+		entry := MergedVideoSourceResponse{
+			Position:     source.Position,
+			ProjectID:    source.ID,
+			Name:         source.Name,
+			RenderStatus: source.RenderStatus,
+		}
+		if source.VideoURL.Valid {
+			entry.VideoURL = h.Config.RewriteVideoURL(source.VideoURL.String)
+		}
+		owned = append(owned, entry)
+	}
+	if len(owned) == 0 {
+		utils.ResponseWithError(c, http.StatusNotFound, "Merged video not found", nil)
+		return
+	}
+
+	utils.ResponseWithSuccess(c, http.StatusOK, "Merged video sources retrieved successfully", gin.H{
+		"merged_video":  h.newMergedVideoSummary(merged),
+		"sources":       owned,
+		"total_sources": len(sources),
+	})
+}
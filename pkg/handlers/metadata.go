@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+)
+
+const (
+	maxMetadataBytes    = 8 * 1024 // Upper bound on the compacted metadata document
+	maxMetadataFilters  = 10       // Upper bound on metadata.<key> filters per list request
+	metadataQueryPrefix = "metadata."
+)
+
+// normalizeMetadata validates client-supplied metadata and returns it compacted.
+// Metadata must be a JSON object; a JSON null clears it.
+func normalizeMetadata(raw json.RawMessage) (db.JSONB, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return db.JSONB("{}"), nil
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(trimmed, &object); err != nil || object == nil {
+		return nil, errors.New("metadata must be a JSON object")
+	}
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, trimmed); err != nil {
+		return nil, errors.New("metadata must be a JSON object")
+	}
+	if compacted.Len() > maxMetadataBytes {
+		return nil, fmt.Errorf("metadata must not exceed %d bytes", maxMetadataBytes)
+	}
+	return db.JSONB(compacted.Bytes()), nil
+}
+
+// metadataResponse returns the project's metadata ready for embedding in a response.
+func metadataResponse(metadata db.JSONB) json.RawMessage {
+	if len(metadata) == 0 {
+		return json.RawMessage("{}")
+	}
+	return json.RawMessage(metadata)
+}
+
+// metadataFiltersFromQuery collects metadata.<key>=<value> query parameters.
+func metadataFiltersFromQuery(query url.Values) (map[string]string, error) {
+	filters := make(map[string]string)
+	for param, values := range query {
+		if !strings.HasPrefix(param, metadataQueryPrefix) || len(values) == 0 {
+			continue
+		}
+		key := strings.TrimPrefix(param, metadataQueryPrefix)
+		if key == "" {
+			return nil, errors.New("metadata filter key must not be empty")
+		}
+		filters[key] = values[0]
+	}
+	if len(filters) > maxMetadataFilters {
+		return nil, fmt.Errorf("at most %d metadata filters are allowed", maxMetadataFilters)
+	}
+	return filters, nil
+}
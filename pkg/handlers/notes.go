@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxNotesBytes caps the size of a project's notes.
+const maxNotesBytes = 10000
+
+// UpdateNotesRequest is the body of PATCH /api/projects/:id/notes.
+type UpdateNotesRequest struct {
+	Notes *string `json:"notes" binding:"required"` // Replaces the notes; "" clears them
+}
+
+// sanitizeNotes trims the notes and drops control characters other than newlines and tabs.
+func sanitizeNotes(notes string) string {
+	notes = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' || !unicode.IsControl(r) {
+			return r
+		}
+		return -1
+	}, notes)
+	return strings.TrimSpace(strings.ReplaceAll(notes, "\r\n", "\n"))
+}
+
+// UpdateProjectNotes replaces the internal notes of one of the caller's projects.
+func UpdateProjectNotes(c *gin.Context) {
+	project, claims, ok := loadOwnedProject(c, "UpdateProjectNotes")
+	if !ok {
+		return
+	}
+
+	var req UpdateNotesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", utils.BindingErrorDetails(err))
+		return
+	}
+	notes := sanitizeNotes(*req.Notes)
+	if len(notes) > maxNotesBytes {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Notes are too long", gin.H{"max_bytes": maxNotesBytes})
+		return
+	}
+	if project.Locked {
+		respondProjectLocked(c)
+		return
+	}
+
+	if err := queries.SetManimProjectNotes(c.Request.Context(), project.ID, claims.UserID, notes); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.ResponseWithError(c, http.StatusNotFound, "Manim project not found", nil)
+			return
+		}
+		log.Errorf("UpdateProjectNotes: Failed to update notes of project %s: %v", project.ID.String(), err)
+		respondDBError(c, err, "Failed to update project notes")
+		return
+	}
+	log.Infof("UpdateProjectNotes: Notes of project %s updated by user %s (%d bytes).", project.ID.String(), claims.UserID.String(), len(notes))
+
+	utils.ResponseWithSuccess(c, http.StatusOK, "Project notes updated", gin.H{"id": project.ID, "notes": notes})
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// maxPasswordBytes is bcrypt's input limit. bcrypt ignores everything past it, so two passwords
+// sharing their first 72 bytes would hash the same. Rather than pre-hashing (which would change
+// how every existing hash is verified), longer passwords are rejected. Note the limit is in bytes,
+// not characters: multi-byte UTF-8 passwords reach it sooner than the 100 character binding limit.
+const maxPasswordBytes = 72
+
+var errPasswordTooLong = fmt.Errorf("password must be at most %d bytes", maxPasswordBytes)
+
+// checkPasswordLength returns errPasswordTooLong when bcrypt would truncate password. Handlers call
+// it right after binding, before any database work.
+func checkPasswordLength(password string) error {
+	if len(password) > maxPasswordBytes {
+		return errPasswordTooLong
+	}
+	return nil
+}
+
+// hashPassword bcrypt-hashes a new password, returning errPasswordTooLong when bcrypt would truncate it.
+// Every flow that sets a password goes through here so the limit is applied consistently.
+func hashPassword(password string) (string, error) {
+	if err := checkPasswordLength(password); err != nil {
+		return "", err
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
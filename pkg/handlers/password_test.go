@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/config"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/middleware"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Passwords the binding accepts (8 to 100 characters) but bcrypt would truncate.
+var tooLongPasswords = []struct {
+	name     string
+	password string
+}{
+	{"73 ASCII bytes", strings.Repeat("a", 73)},
+	{"30 three-byte characters", strings.Repeat("€", 30)}, // 90 bytes
+}
+
+func TestTooLongPasswordsPassBinding(t *testing.T) {
+	for _, tt := range tooLongPasswords {
+		if n := utf8.RuneCountInString(tt.password); n > 100 {
+			t.Errorf("%s: %d characters exceeds the binding limit", tt.name, n)
+		}
+		if len(tt.password) <= maxPasswordBytes {
+			t.Errorf("%s: %d bytes is within bcrypt's limit", tt.name, len(tt.password))
+		}
+	}
+}
+
+func TestHashPasswordLength(t *testing.T) {
+	if _, err := hashPassword(strings.Repeat("a", maxPasswordBytes)); err != nil {
+		t.Fatalf("hashPassword(72 bytes) error = %v", err)
+	}
+	for _, tt := range tooLongPasswords {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := hashPassword(tt.password); err != errPasswordTooLong {
+				t.Fatalf("hashPassword error = %v, want %v", err, errPasswordTooLong)
+			}
+		})
+	}
+}
+
+func TestRegisterUserRejectsTooLongPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handlers{Config: &config.Config{}}
+	for _, tt := range tooLongPasswords {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/auth/register", h.RegisterUser)
+			body, _ := json.Marshal(RegisterRequest{Username: "alice", Email: "alice@example.com", Password: tt.password})
+			rec := postJSON(router, "/auth/register", string(body))
+			assertPasswordTooLong(t, rec)
+		})
+	}
+}
+
+func TestChangePasswordRejectsTooLongPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tooLongPasswords {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/api/change-password", func(c *gin.Context) {
+				c.Set(middleware.UserClaimsContextKey, &services.Claims{UserID: uuid.New(), Email: "alice@example.com"})
+			}, ChangePassword)
+			body, _ := json.Marshal(ChangePasswordRequest{CurrentPassword: "correct-horse", NewPassword: tt.password})
+			rec := postJSON(router, "/api/change-password", string(body))
+			assertPasswordTooLong(t, rec)
+		})
+	}
+}
+
+func postJSON(router *gin.Engine, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func assertPasswordTooLong(t *testing.T, rec *httptest.ResponseRecorder) {
+	t.Helper()
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusBadRequest, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "Password is too long") {
+		t.Fatalf("body = %s, want the too-long error", rec.Body.String())
+	}
+}
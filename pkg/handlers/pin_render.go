@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// PinRenderRequest is the optional body of POST /api/projects/:id/pin-render.
+type PinRenderRequest struct {
+	Pinned *bool `json:"pinned"` // Defaults to true; false hands the project back to the reconciler
+}
+
+// PinRender pins a project so the stuck-render reconciler never auto-fails it (auto_reconcile = false),
+// for renders that legitimately run past the timeout. Send {"pinned": false} to unpin.
+func PinRender(c *gin.Context) {
+	project, claims, ok := loadOwnedProject(c, "PinRender")
+	if !ok {
+		return
+	}
+
+	var req PinRenderRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", utils.BindingErrorDetails(err))
+		return
+	}
+	pinned := req.Pinned == nil || *req.Pinned
+
+	if err := queries.SetManimProjectAutoReconcile(c.Request.Context(), project.ID, claims.UserID, !pinned); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.ResponseWithError(c, http.StatusNotFound, "Manim project not found", nil)
+			return
+		}
+		log.Errorf("PinRender: Failed to update project %s: %v", project.ID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to update project", nil)
+		return
+	}
+	log.Infof("PinRender: Project %s auto_reconcile set to %t by user %s.", project.ID.String(), !pinned, claims.UserID.String())
+
+	utils.ResponseWithSuccess(c, http.StatusOK, "Project render pin updated", gin.H{
+		"id":             project.ID,
+		"auto_reconcile": !pinned,
+	})
+}
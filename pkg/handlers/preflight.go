@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// preflightAllowedMethods mirrors the methods configured on the CORS middleware.
+const preflightAllowedMethods = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+
+// Preflight answers OPTIONS requests for any path with 204 No Content.
+// Browser preflights carrying an Origin header are normally answered by the CORS
+// middleware before reaching this handler; this catch-all guarantees routes outside
+// the protected group (render callback, merge) and requests relayed by proxies that
+// strip the Origin header still get a well-formed response instead of a 404.
+func Preflight(c *gin.Context) {
+	c.Header("Allow", preflightAllowedMethods)
+	c.Status(http.StatusNoContent)
+}
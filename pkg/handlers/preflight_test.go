@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// newPreflightRouter wires Preflight behind the CORS middleware the way cmd/api does.
+func newPreflightRouter() *gin.Engine {
+	router := gin.New()
+	router.Use(cors.New(cors.Config{
+		AllowOrigins: []string{"https://app.example.org"},
+		AllowMethods: strings.Split(preflightAllowedMethods, ", "),
+		AllowHeaders: []string{"Origin", "Content-Type", "Authorization"},
+	}))
+	router.OPTIONS("/*path", Preflight)
+	router.POST("/api/projects/:id/generate-render", func(c *gin.Context) { c.Status(http.StatusAccepted) })
+	return router
+}
+
+func TestPreflight(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := newPreflightRouter()
+
+	tests := []struct {
+		name        string
+		path        string
+		origin      string
+		wantStatus  int
+		wantAllow   string // Allow header set by Preflight
+		wantMethods string // Access-Control-Allow-Methods set by the CORS middleware
+	}{
+		{"protected route without Origin", "/api/projects/123/generate-render", "", http.StatusNoContent, preflightAllowedMethods, ""},
+		{"callback route without Origin", "/api/projects/render-callback", "", http.StatusNoContent, preflightAllowedMethods, ""},
+		{"protected route with allowed Origin", "/api/projects/123/generate-render", "https://app.example.org", http.StatusNoContent, "", "GET,POST,PUT,PATCH,DELETE,OPTIONS"},
+		{"unknown route with allowed Origin", "/no/such/route", "https://app.example.org", http.StatusNoContent, "", "GET,POST,PUT,PATCH,DELETE,OPTIONS"},
+		{"disallowed Origin", "/api/projects/123/generate-render", "https://evil.example.com", http.StatusForbidden, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, tt.path, nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Methods"); got != tt.wantMethods {
+				t.Errorf("Access-Control-Allow-Methods = %q, want %q", got, tt.wantMethods)
+			}
+			if rec.Body.Len() != 0 {
+				t.Errorf("body = %q, want empty", rec.Body.String())
+			}
+		})
+	}
+}
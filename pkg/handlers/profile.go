@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/middleware"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/services"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// UpdateProfileRequest is the body of PATCH /api/profile. Omitted fields are left unchanged.
+type UpdateProfileRequest struct {
+	Username *string `json:"username" binding:"omitempty,min=3,max=30"`
+	Email    *string `json:"email" binding:"omitempty,email"`
+}
+
+// UpdateProfile lets the authenticated user change their own username and/or email. Both must be
+// unused by other accounts, and a new email must pass the ALLOWED_EMAIL_DOMAINS check. Existing
+// tokens still carry the old values, so a fresh token is returned with the updated profile.
+func (h *Handlers) UpdateProfile(c *gin.Context) {
+	claims, exists := middleware.GetUserClaimsFromContext(c)
+	if !exists {
+		log.Error("UpdateProfile: User claims not found in context.")
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+		return
+	}
+
+	var req UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Debugf("UpdateProfile: Invalid request body: %v", err)
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", utils.BindingErrorDetails(err))
+		return
+	}
+	if req.Username == nil && req.Email == nil {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Nothing to update. Provide a username and/or email", nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := queries.FindUserByID(ctx, claims.UserID)
+	if err != nil {
+		log.Errorf("UpdateProfile: Failed to fetch user %s: %v", claims.UserID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retrieve user account", nil)
+		return
+	}
+	if user == nil {
+		utils.ResponseWithError(c, http.StatusNotFound, "User account not found", nil)
+		return
+	}
+
+	if req.Username != nil {
+		username := strings.TrimSpace(*req.Username)
+		if len(username) < 3 {
+			utils.ResponseWithError(c, http.StatusBadRequest, "Username must be at least 3 characters", nil)
+			return
+		}
+		if username != user.Username {
+			existing, err := queries.FindUserByUsername(ctx, username)
+			if err != nil {
+				utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to update profile", nil)
+				return
+			}
+			if existing != nil && existing.ID != user.ID {
+				utils.ResponseWithError(c, http.StatusConflict, "Username is already taken", nil)
+				return
+			}
+			user.Username = username
+		}
+	}
+
+	if req.Email != nil {
+		email := strings.ToLower(strings.TrimSpace(*req.Email))
+		if email != user.Email {
+			if !h.Config.IsEmailDomainAllowed(email) {
+				utils.ResponseWithError(c, http.StatusForbidden, "Email must belong to an approved domain", nil)
+				return
+			}
+			existing, err := queries.FindUserByEmail(ctx, email)
+			if err != nil {
+				utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to update profile", nil)
+				return
+			}
+			if existing != nil && existing.ID != user.ID {
+				utils.ResponseWithError(c, http.StatusConflict, "User with email already exists", nil)
+				return
+			}
+			user.Email = email
+		}
+	}
+
+	if err := queries.UpdateUser(ctx, user); err != nil {
+		log.Errorf("UpdateProfile: Failed to update user %s: %v", user.ID.String(), err)
+		respondDBError(c, err, "Failed to update profile")
+		return
+	}
+
+	token, err := services.GenerateToken(user.ID, user.Email, user.Username)
+	if err != nil {
+		log.Errorf("UpdateProfile: Failed to generate JWT token for user %s: %v", user.ID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to generate authentication token", nil)
+		return
+	}
+
+	log.Infof("UpdateProfile: User %s updated their profile.", user.ID.String())
+	utils.ResponseWithSuccess(c, http.StatusOK, "Profile updated successfully", gin.H{
+		"user_id":  user.ID,
+		"email":    user.Email,
+		"username": user.Username,
+		"token":    token,
+	})
+}
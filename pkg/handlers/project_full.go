@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// RenderJobResponse describes a single render attempt.
+type RenderJobResponse struct {
+	ID              uuid.UUID `json:"id"`
+	Status          string    `json:"status"`
+	Error           string    `json:"error,omitempty"`
+	StartedAt       string    `json:"started_at"`
+	FinishedAt      string    `json:"finished_at,omitempty"`
+	DurationSeconds float64   `json:"duration_seconds,omitempty"` // Only once finished
+}
+
+func newRenderJobResponse(job *db.RenderJob) *RenderJobResponse {
+	resp := &RenderJobResponse{
+		ID:        job.ID,
+		Status:    job.Status,
+		Error:     job.Error.String,
+		StartedAt: job.StartedAt.Format(http.TimeFormat),
+	}
+	if job.FinishedAt.Valid {
+		resp.FinishedAt = job.FinishedAt.Time.Format(http.TimeFormat)
+		resp.DurationSeconds = job.FinishedAt.Time.Sub(job.StartedAt).Seconds()
+	}
+	return resp
+}
+
+// ChildProjectSummary is a compact view of a sub-project.
+type ChildProjectSummary struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	RenderStatus string    `json:"render_status"`
+}
+
+// ChildrenSummary summarizes a project's sub-projects.
+type ChildrenSummary struct {
+	Count    int                   `json:"count"`
+	ByStatus map[string]int        `json:"by_status"`
+	Items    []ChildProjectSummary `json:"items"`
+}
+
+// ProjectFullResponse bundles everything the project detail page needs in one response.
+type ProjectFullResponse struct {
+	Project   ProjectResponse    `json:"project"`
+	LatestJob *RenderJobResponse `json:"latest_render_job"` // null if never rendered
+	Children  ChildrenSummary    `json:"children"`
+}
+
+// GetManimProjectFull returns a project together with its latest render job and a summary of its
+// sub-projects, saving the detail page separate round-trips.
+func GetManimProjectFull(c *gin.Context) {
+	project, _, ok := loadOwnedProject(c, "GetManimProjectFull")
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	job, err := queries.FindLatestRenderJob(ctx, project.ID)
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retrieve render job", nil)
+		return
+	}
+	children, err := queries.FindManimProjectsByParentID(ctx, project.ID)
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retrieve sub-projects", nil)
+		return
+	}
+
+	resp := ProjectFullResponse{
+		Project: projectResponseOptionsFromQuery(c).render(project),
+		Children: ChildrenSummary{
+			Count:    len(children),
+			ByStatus: make(map[string]int),
+			Items:    make([]ChildProjectSummary, 0, len(children)),
+		},
+	}
+	if job != nil {
+		resp.LatestJob = newRenderJobResponse(job)
+	}
+	for _, child := range children {
+		resp.Children.ByStatus[child.RenderStatus]++
+		resp.Children.Items = append(resp.Children.Items, ChildProjectSummary{
+			ID:           child.ID,
+			Name:         child.Name,
+			RenderStatus: child.RenderStatus,
+		})
+	}
+
+	log.Debugf("GetManimProjectFull: Project %s has %d sub-projects.", project.ID.String(), len(children))
+	utils.ResponseWithSuccess(c, http.StatusOK, "Manim project retrieved successfully", resp)
+}
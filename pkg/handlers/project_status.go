@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Cache lifetimes for GET /api/projects/:id/status. A project still being rendered is polled, so
+// clients are asked to wait a few seconds between checks; once it's settled the answer only
+// changes when the user acts on the project.
+const (
+	statusPollMaxAge    = 5  // Seconds, while pending, generating or rendering
+	statusSettledMaxAge = 60 // Seconds, once completed, failed or decomposed
+)
+
+// ProjectStatusResponse is the small payload clients poll while a render is in progress.
+type ProjectStatusResponse struct {
+	ProjectID    string `json:"project_id"`
+	RenderStatus string `json:"render_status"`
+	VideoURL     string `json:"video_url"`
+	UpdatedAt    string `json:"updated_at"`
+}
+
+// GetProjectStatus returns just a project's render status and video URL, so clients waiting on a
+// render don't have to re-fetch the whole project. Ownership is checked like GetManimProjectByID.
+func GetProjectStatus(c *gin.Context) {
+	project, _, ok := loadOwnedProject(c, "GetProjectStatus")
+	if !ok {
+		return
+	}
+
+	maxAge := statusSettledMaxAge
+	switch project.RenderStatus {
+	case "pending", "generating", "rendering":
+		maxAge = statusPollMaxAge
+	}
+	c.Header("Cache-Control", "private, max-age="+strconv.Itoa(maxAge))
+
+	utils.ResponseWithSuccess(c, http.StatusOK, "Project status retrieved successfully", ProjectStatusResponse{
+		ProjectID:    project.ID.String(),
+		RenderStatus: project.RenderStatus,
+		VideoURL:     project.VideoURL.String,
+		UpdatedAt:    project.UpdatedAt.UTC().Format(http.TimeFormat),
+	})
+}
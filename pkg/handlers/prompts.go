@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/llm"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// AssessPromptRequest carries a prompt to check before spending a render on it.
+type AssessPromptRequest struct {
+	Prompt string `json:"prompt" binding:"required,min=1,max=5000"`
+}
+
+// AssessPrompt asks the LLM whether a prompt is likely to render well and returns suggestions
+// for improving it. Only available when GEMINI_PROMPT_QC is enabled.
+func (h *Handlers) AssessPrompt(c *gin.Context) {
+	if !h.Config.GeminiPromptQC {
+		utils.ResponseWithError(c, http.StatusNotImplemented, "Prompt assessment is not enabled on this server", nil)
+		return
+	}
+
+	var req AssessPromptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("AssessPrompt: Invalid request body: %v", err)
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", utils.BindingErrorDetails(err))
+		return
+	}
+
+	assessment, err := h.LLMClient.AssessPrompt(c.Request.Context(), strings.TrimSpace(req.Prompt))
+	if errors.Is(err, llm.ErrLLMUnavailable) {
+		utils.ResponseWithErrorCode(c, http.StatusServiceUnavailable, CodeLLMUnavailable, llmUnavailableMessage, nil)
+		return
+	}
+	if err != nil {
+		log.Errorf("AssessPrompt: Failed to assess prompt: %v", err)
+		utils.ResponseWithError(c, http.StatusBadGateway, "Failed to assess prompt", nil)
+		return
+	}
+
+	utils.ResponseWithSuccess(c, http.StatusOK, "Prompt assessed", assessment)
+}
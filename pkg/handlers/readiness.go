@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// readinessTimeout bounds the database ping of the readiness check.
+const readinessTimeout = 2 * time.Second
+
+// Readiness reports whether the API can serve requests end to end: the database answers and
+// the Gemini circuit breaker isn't open. With READINESS_CHECK_RENDERER on, the renderer is probed
+// too; otherwise its reachability as last probed is reported without affecting the result. Unlike
+// /health/live, it returns 503 when a dependency is down.
+func (h *Handlers) Readiness(c *gin.Context) {
+	checks := gin.H{}
+	ready := true
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+	if db.DB == nil || db.DB.PingContext(ctx) != nil {
+		checks["database"] = "unavailable"
+		ready = false
+	} else {
+		checks["database"] = "ok"
+	}
+
+	llmHealth := h.LLMClient.Health()
+	checks["llm"] = llmHealth.State
+	if !h.LLMClient.Available() {
+		ready = false
+	}
+
+	if h.RendererHealth != nil {
+		if h.Config.ReadinessCheckRenderer {
+			h.RendererHealth.Check(ctx)
+		}
+		if renderer, probed := h.RendererHealth.Last(); probed {
+			checks["renderer"] = "ok"
+			if !renderer.Reachable {
+				checks["renderer"] = "unavailable"
+				if h.Config.ReadinessCheckRenderer {
+					ready = false
+				}
+			}
+		}
+	}
+
+	if !ready {
+		log.Warnf("Readiness: Not ready: %v", checks)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "checks": checks})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "checks": checks})
+}
+
+// GetLLMHealth shows admins the state of the Gemini circuit breaker.
+func (h *Handlers) GetLLMHealth(c *gin.Context) {
+	utils.ResponseWithSuccess(c, http.StatusOK, "LLM health retrieved successfully", h.LLMClient.Health())
+}
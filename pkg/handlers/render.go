@@ -0,0 +1,339 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/llm"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/middleware"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// statusClientClosedRequest is the de facto status (from nginx) for requests abandoned by the client.
+// Nobody receives the response; it only shows up in logs.
+const statusClientClosedRequest = 499
+
+// renderError describes why a render could not be started, along with the HTTP status
+// and client-facing message the calling handler should respond with.
+type renderError struct {
+	Status  int
+	Code    string // Optional machine-readable error code
+	Message string
+	Details interface{}
+	Err     error
+}
+
+func (e *renderError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Message, e.Err)
+}
+
+// CodeLLMUnavailable is returned with 503 while the Gemini circuit breaker is open.
+const CodeLLMUnavailable = "llm_unavailable"
+
+// llmUnavailableMessage is the client-facing message for CodeLLMUnavailable.
+const llmUnavailableMessage = "Code generation is temporarily unavailable. Please try again shortly."
+
+// CodeTruncatedOutput is returned when Gemini's code was cut off at its output token limit.
+const CodeTruncatedOutput = "truncated_output"
+
+// respondRenderError writes the response for a render that could not be started.
+func respondRenderError(c *gin.Context, rerr *renderError) {
+	if rerr.Code != "" {
+		utils.ResponseWithErrorCode(c, rerr.Status, rerr.Code, rerr.Message, rerr.Details)
+		return
+	}
+	utils.ResponseWithError(c, rerr.Status, rerr.Message, rerr.Details)
+}
+
+// renderOptions are optional per-render settings, usually expanded from a saved render preset.
+// Empty fields leave the choice to the LLM service or the renderer.
+type renderOptions struct {
+	Quality string
+	Format  string
+	Model   string // Gemini model for code generation
+	Profile string
+}
+
+// renderOptionsFromPreset expands a saved preset into render options.
+func renderOptionsFromPreset(preset *db.RenderPreset) renderOptions {
+	return renderOptions{Quality: preset.Quality, Format: preset.Format, Model: preset.Model, Profile: preset.Profile}
+}
+
+// renderCooldownRemaining returns how long the caller must wait before the project may be
+// rendered again, or zero when the cooldown has elapsed, is disabled, or the user is an admin.
+func (h *Handlers) renderCooldownRemaining(project *db.ManimProject, userID uuid.UUID) time.Duration {
+	if h.Config.RenderCooldownSeconds <= 0 || !project.LastRenderStartedAt.Valid || h.Config.IsAdmin(userID) {
+		return 0
+	}
+	cooldown := time.Duration(h.Config.RenderCooldownSeconds) * time.Second
+	remaining := cooldown - time.Since(project.LastRenderStartedAt.Time)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// startRender runs the generate-and-render pipeline for a project: it marks the project as
+// generating, asks the LLM for Manim code and hands the script to the renderer, which reports
+// back asynchronously via HandleRenderCallback. On failure the project's render_status records
+// the reason. The caller is responsible for ownership and prompt checks.
+func (h *Handlers) startRender(ctx context.Context, project *db.ManimProject, opts renderOptions) *renderError {
+	projectID := project.ID
+
+	// Nothing has changed yet, so an abandoned request can simply stop
+	if err := ctx.Err(); err != nil {
+		log.WithContext(ctx).Infof("startRender: Request for project %s was cancelled before rendering started.", projectID.String())
+		return &renderError{Status: statusClientClosedRequest, Message: "Request cancelled", Err: err}
+	}
+
+	// 2. Update project status to indicate generation is in progress
+	h.markRenderStarted(ctx, project)
+
+	// 3. Generate Manim code using LLM
+	model := opts.Model
+	if model == "" {
+		model = project.Model
+	}
+	generatedManimCode, usage, err := h.LLMClient.GenerateManimCodeWithUsage(ctx, project.Prompt, model)
+	h.recordRenderUsage(ctx, project, usage)
+	if ctx.Err() != nil {
+		return h.renderCancelled(ctx, project)
+	}
+	if errors.Is(err, llm.ErrLLMUnavailable) {
+		log.WithContext(ctx).Warnf("startRender: Gemini unavailable; failing render of project %s fast.", projectID.String())
+		h.markRenderFailed(ctx, project, CodeLLMUnavailable)
+		return &renderError{Status: http.StatusServiceUnavailable, Code: CodeLLMUnavailable, Message: llmUnavailableMessage, Err: err}
+	}
+	if errors.Is(err, llm.ErrTruncatedOutput) {
+		log.WithContext(ctx).Warnf("startRender: Generated code for project %s was truncated; not rendering it.", projectID.String())
+		h.markRenderFailed(ctx, project, CodeTruncatedOutput)
+		return &renderError{Status: http.StatusBadGateway, Code: CodeTruncatedOutput, Message: "Generated Manim code was incomplete. Try simplifying the prompt.", Err: err}
+	}
+	if err != nil {
+		log.WithContext(ctx).Errorf("startRender: Failed to generate Manim code for project %s: %v", projectID.String(), err)
+		h.markRenderFailed(ctx, project, "code_gen_error")
+		return &renderError{Status: http.StatusInternalServerError, Message: "Failed to generate Manim code", Err: err}
+	}
+	log.WithContext(ctx).Infof("Manim code generated for project %s. Length: %d", projectID.String(), len(generatedManimCode))
+
+	return h.dispatchToRenderer(ctx, project, generatedManimCode, opts)
+}
+
+// dispatchToRenderer sends a Manim script for the project to the renderer, which reports back
+// asynchronously via HandleRenderCallback. On failure the project's render_status records the reason.
+func (h *Handlers) dispatchToRenderer(ctx context.Context, project *db.ManimProject, script string, opts renderOptions) *renderError {
+	projectID := project.ID
+	if ctx.Err() != nil {
+		return h.renderCancelled(ctx, project)
+	}
+	// Kept so a failed render can be retried without generating the script again
+	if err := queries.SetManimProjectCode(ctx, projectID, script); err != nil {
+		log.WithContext(ctx).Errorf("dispatchToRenderer: Failed to store Manim code of project %s: %v", projectID.String(), err)
+	}
+	callbackURL, callbackSignature, err := h.renderCallbackURL(ctx, projectID)
+	if err != nil {
+		log.WithContext(ctx).Errorf("dispatchToRenderer: Failed to build callback URL for project %s: %v", projectID.String(), err)
+		h.markRenderFailed(ctx, project, "renderer_req_error")
+		return &renderError{Status: http.StatusInternalServerError, Message: "Failed to prepare render request", Err: err}
+	}
+
+	assets, err := h.assetManifest(ctx, project)
+	if err != nil {
+		log.WithContext(ctx).Errorf("dispatchToRenderer: Failed to build asset manifest for project %s: %v", projectID.String(), err)
+		h.markRenderFailed(ctx, project, "renderer_req_error")
+		return &renderError{Status: http.StatusInternalServerError, Message: "Failed to prepare render request", Err: err}
+	}
+
+	rendererReqBody := RendererRequest{
+		ProjectID:         project.ID.String(),
+		ScriptContent:     script,
+		CallbackURL:       callbackURL,
+		CallbackSignature: callbackSignature,
+		Quality:           opts.Quality,
+		Format:            opts.Format,
+		Profile:           opts.Profile,
+		Resolution:        project.Resolution,
+		FPS:               project.FPS,
+		Assets:            assets,
+	}
+	// The script and callback signature stay out of the log
+	log.WithContext(ctx).Debugf("dispatchToRenderer: Sending project %s to the renderer (script %d bytes, quality %q, format %q, profile %q, %s at %d fps, %d assets).",
+		rendererReqBody.ProjectID, len(rendererReqBody.ScriptContent), rendererReqBody.Quality, rendererReqBody.Format,
+		rendererReqBody.Profile, rendererReqBody.Resolution, rendererReqBody.FPS, len(rendererReqBody.Assets))
+
+	jsonBody, _ := json.Marshal(rendererReqBody)
+
+	rendererURL := fmt.Sprintf("%s/render", h.Config.ManimRendererURL) // ManimRendererURL from config
+
+	req, err := http.NewRequestWithContext(ctx, "POST", rendererURL, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		log.WithContext(ctx).Errorf("dispatchToRenderer: Failed to create request to renderer: %v", err)
+		h.markRenderFailed(ctx, project, "renderer_req_error")
+		return &renderError{Status: http.StatusInternalServerError, Message: "Failed to prepare render request", Err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	middleware.PropagateRequestID(ctx, req)
+
+	resp, err := h.sendRenderRequest(ctx, projectID, req)
+	if err != nil && ctx.Err() != nil {
+		return h.renderCancelled(ctx, project)
+	}
+	if err != nil {
+		log.WithContext(ctx).Errorf("dispatchToRenderer: Failed to send request to renderer %s: %v", rendererURL, err)
+		h.markRenderFailed(ctx, project, "renderer_comm_error")
+		return &renderError{Status: http.StatusInternalServerError, Message: "Failed to connect to Manim renderer", Err: err}
+	}
+	defer resp.Body.Close()
+
+	// The renderer will respond immediately with 202 Accepted
+	if resp.StatusCode != http.StatusAccepted { // Expected 202
+		var errorResp map[string]string
+		json.NewDecoder(resp.Body).Decode(&errorResp)
+		errMsg := errorResp["error"]
+		if errMsg == "" {
+			errMsg = "Unknown error from renderer."
+		}
+		log.WithContext(ctx).Errorf("dispatchToRenderer: Renderer returned unexpected status %d: %s", resp.StatusCode, errMsg)
+		h.markRenderFailed(ctx, project, fmt.Sprintf("renderer_status_%d", resp.StatusCode))
+		return &renderError{
+			Status:  http.StatusInternalServerError,
+			Message: "Failed to start Manim rendering process",
+			Details: errMsg,
+			Err:     fmt.Errorf("renderer returned status %d: %s", resp.StatusCode, errMsg),
+		}
+	}
+
+	log.WithContext(ctx).Infof("Manim rendering process initiated for project %s. Renderer returned 202 Accepted.", projectID.String())
+	return nil
+}
+
+// sendRenderRequest posts a render request, retrying up to RENDERER_TRIGGER_RETRIES times with
+// doubling backoff while the renderer is unreachable or answers 5xx, as it does while restarting.
+// A 4xx means the request itself was rejected and is returned without retrying.
+func (h *Handlers) sendRenderRequest(ctx context.Context, projectID uuid.UUID, req *http.Request) (*http.Response, error) {
+	client := &http.Client{Timeout: 10 * time.Second} // Shorter timeout for initial request, as rendering is async
+	backoff := time.Duration(h.Config.RendererTriggerBackoffMs) * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		resp, err := client.Do(req)
+		if err == nil && resp.StatusCode < http.StatusInternalServerError {
+			return resp, nil
+		}
+		if attempt > h.Config.RendererTriggerRetries || ctx.Err() != nil {
+			return resp, err
+		}
+		if err != nil {
+			log.WithContext(ctx).Warnf("sendRenderRequest: Attempt %d to start render of project %s failed: %v; retrying in %s.", attempt, projectID.String(), err, backoff)
+		} else {
+			log.WithContext(ctx).Warnf("sendRenderRequest: Attempt %d to start render of project %s got status %d; retrying in %s.", attempt, projectID.String(), resp.StatusCode, backoff)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if req.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// markRenderStarted sets the project to generating, stamps the render start time and records a
+// new render job. Both writes are best effort; a failure is logged but doesn't stop the render.
+func (h *Handlers) markRenderStarted(ctx context.Context, project *db.ManimProject) {
+	h.markRenderStartedAs(ctx, project, "generating")
+}
+
+// markRenderStartedAs is markRenderStarted with the given status, e.g. rendering when no code
+// needs generating.
+func (h *Handlers) markRenderStartedAs(ctx context.Context, project *db.ManimProject, status string) {
+	project.RenderStatus = status
+	project.LastRenderStartedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	if err := queries.UpdateManimProject(ctx, project); err != nil {
+		log.WithContext(ctx).Errorf("markRenderStarted: Failed to update project %s status to '%s': %v", project.ID.String(), status, err)
+	} else {
+		log.WithContext(ctx).Infof("Project %s status updated to '%s'.", project.ID.String(), status)
+	}
+	if _, err := queries.CreateRenderJob(ctx, project.ID, project.UserID); err != nil {
+		log.WithContext(ctx).Errorf("markRenderStarted: Failed to record render job for project %s: %v", project.ID.String(), err)
+	}
+}
+
+// recordRenderUsage stores Gemini token usage on the project's running render job. Best effort,
+// like markRenderStarted; nothing is written when Gemini reported no usage.
+func (h *Handlers) recordRenderUsage(ctx context.Context, project *db.ManimProject, usage llm.Usage) {
+	if usage == (llm.Usage{}) {
+		return
+	}
+	if err := queries.SetLatestRenderJobUsage(ctx, project.ID, usage.InputTokens, usage.OutputTokens); err != nil {
+		log.WithContext(ctx).Errorf("recordRenderUsage: Failed to record token usage for project %s: %v", project.ID.String(), err)
+	}
+}
+
+// markRenderFailed records a failed render on the project ("failed: <reason>") and its render job.
+// Best effort, like markRenderStarted.
+func (h *Handlers) markRenderFailed(ctx context.Context, project *db.ManimProject, reason string) {
+	project.RenderStatus = "failed: " + reason
+	queries.UpdateManimProject(ctx, project)
+	queries.FinishLatestRenderJob(ctx, project.ID, queries.RenderJobFailed, reason)
+	if err := updateDecomposedParent(ctx, project); err != nil {
+		log.WithContext(ctx).Errorf("markRenderFailed: Failed to update parent of project %s: %v", project.ID.String(), err)
+	}
+}
+
+// renderCancelled records a render abandoned because the client went away ("failed: cancelled").
+func (h *Handlers) renderCancelled(ctx context.Context, project *db.ManimProject) *renderError {
+	log.WithContext(ctx).Infof("Render of project %s cancelled: the client disconnected.", project.ID.String())
+	h.markRenderFailed(ctx, project, "cancelled")
+	return &renderError{Status: statusClientClosedRequest, Message: "Request cancelled", Err: ctx.Err()}
+}
+
+// callbackURL returns the absolute URL the renderer should call back on for the given path.
+func (h *Handlers) callbackURL(path string) string {
+	orchestratorPublicHost := os.Getenv("RENDER_EXTERNAL_HOSTNAME")
+	var callbackURL string
+
+	if orchestratorPublicHost == "" {
+		// Fallback for local development if RENDER_EXTERNAL_HOSTNAME isn't set.
+		// This scenario means you're likely NOT on Render.com.
+		log.Warn("RENDER_EXTERNAL_HOSTNAME not set. Assuming local development or non-Render environment.")
+		// For local testing, ensure your h.Config.Host is set to 'localhost' or '127.0.0.1' and use http.
+		// Example: If h.Config.Host is "localhost" and h.Config.Port is "8000"
+		callbackURL = fmt.Sprintf("http://%s:%s%s", h.Config.Host, h.Config.Port, path)
+		log.Infof("Using local/fallback callback URL: %s", callbackURL)
+	} else {
+		// For Render.com, services are always accessible via HTTPS on their public domain (port 443).
+		// Do NOT include the internal application port (like :8000) in the public URL.
+		callbackURL = "https://manim-orchestrator-api.onrender.com" + path
+		log.Infof("Using public Render.com callback URL: %s", callbackURL)
+	}
+	return callbackURL
+}
+
+// queueRender starts a render for the project in the background. At most
+// Config.RenderConcurrency renders are dispatched at once; the rest wait for a free slot
+// so bulk operations don't stampede the LLM or the renderer.
+func (h *Handlers) queueRender(project *db.ManimProject) {
+	go func() {
+		h.renderSlots <- struct{}{}
+		defer func() { <-h.renderSlots }()
+
+		if rerr := h.startRender(context.Background(), project, renderOptions{}); rerr != nil {
+			log.Errorf("queueRender: Background render for project %s failed: %v", project.ID.String(), rerr)
+		}
+	}()
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/google/uuid"
+)
+
+func TestApplyRenderCallback(t *testing.T) {
+	const videoURL = "https://videos.example.com/out.mp4"
+	const thumbnailURL = "https://videos.example.com/out.png"
+
+	tests := []struct {
+		name          string
+		callback      RenderCallbackRequest
+		wantStatus    string
+		wantVideoURL  sql.NullString
+		wantThumbnail sql.NullString
+	}{
+		{"completed with URL", RenderCallbackRequest{Status: "completed", VideoURL: videoURL, ThumbnailURL: thumbnailURL}, "completed", sql.NullString{String: videoURL, Valid: true}, sql.NullString{String: thumbnailURL, Valid: true}},
+		{"completed with empty URL", RenderCallbackRequest{Status: "completed"}, "failed: missing_video_url", sql.NullString{}, sql.NullString{}},
+		{"completed with N/A", RenderCallbackRequest{Status: "completed", VideoURL: "N/A", ThumbnailURL: thumbnailURL}, "failed: missing_video_url", sql.NullString{}, sql.NullString{}},
+		{"completed with relative URL", RenderCallbackRequest{Status: "completed", VideoURL: "/videos/out.mp4"}, "failed: missing_video_url", sql.NullString{}, sql.NullString{}},
+		{"completed with non-http scheme", RenderCallbackRequest{Status: "completed", VideoURL: "file:///tmp/out.mp4"}, "failed: missing_video_url", sql.NullString{}, sql.NullString{}},
+		{"completed with host-less URL", RenderCallbackRequest{Status: "completed", VideoURL: "https:///out.mp4"}, "failed: missing_video_url", sql.NullString{}, sql.NullString{}},
+		{"completed with unparsable URL", RenderCallbackRequest{Status: "completed", VideoURL: "https://exa mple.com/%zz"}, "failed: missing_video_url", sql.NullString{}, sql.NullString{}},
+		{"failed", RenderCallbackRequest{Status: "failed", VideoURL: videoURL, ErrorDetails: "boom"}, "failed", sql.NullString{}, sql.NullString{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := &db.ManimProject{
+				ID:           uuid.New(),
+				RenderStatus: "rendering",
+				VideoURL:     sql.NullString{String: "https://videos.example.com/previous.mp4", Valid: true},
+			}
+			applyRenderCallback(project, &tt.callback)
+			if project.RenderStatus != tt.wantStatus {
+				t.Errorf("RenderStatus = %q, want %q", project.RenderStatus, tt.wantStatus)
+			}
+			if project.VideoURL != tt.wantVideoURL {
+				t.Errorf("VideoURL = %+v, want %+v", project.VideoURL, tt.wantVideoURL)
+			}
+			if project.ThumbnailURL != tt.wantThumbnail {
+				t.Errorf("ThumbnailURL = %+v, want %+v", project.ThumbnailURL, tt.wantThumbnail)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// RenderCodeRequest carries a user-written Manim script to render as-is.
+type RenderCodeRequest struct {
+	ScriptContent string `json:"script_content" binding:"required"`
+}
+
+// RenderProjectCode renders user-supplied Manim code for a project, skipping LLM generation.
+// Scripts over MAX_SCRIPT_BYTES are rejected with 413, and scripts matching the configured
+// denylist of dangerous constructs are rejected with 422.
+func (h *Handlers) RenderProjectCode(c *gin.Context) {
+	// Leave headroom for JSON escaping so the size check below produces the 413, not a bind error.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(h.Config.MaxScriptBytes)*2+4*1024)
+
+	var req RenderCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			utils.ResponseWithError(c, http.StatusRequestEntityTooLarge, "Script exceeds the maximum allowed size", gin.H{"max_script_bytes": h.Config.MaxScriptBytes})
+			return
+		}
+		log.Warnf("RenderProjectCode: Invalid request body: %v", err)
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", utils.BindingErrorDetails(err))
+		return
+	}
+	if len(req.ScriptContent) > h.Config.MaxScriptBytes {
+		utils.ResponseWithError(c, http.StatusRequestEntityTooLarge, "Script exceeds the maximum allowed size", gin.H{"max_script_bytes": h.Config.MaxScriptBytes})
+		return
+	}
+	if strings.TrimSpace(req.ScriptContent) == "" {
+		utils.ResponseWithError(c, http.StatusBadRequest, "script_content must not be empty", nil)
+		return
+	}
+
+	project, claims, ok := loadOwnedProject(c, "RenderProjectCode")
+	if !ok {
+		return
+	}
+	if project.Locked {
+		respondProjectLocked(c)
+		return
+	}
+
+	for _, pattern := range h.Config.ScriptDenylist {
+		if match := pattern.FindString(req.ScriptContent); match != "" {
+			log.Warnf("RenderProjectCode: Rejected script for project %s from user %s: matched %q", project.ID.String(), claims.UserID.String(), pattern.String())
+			utils.ResponseWithError(c, http.StatusUnprocessableEntity, "Script contains a disallowed construct", gin.H{"match": match})
+			return
+		}
+	}
+
+	// Same per-project throttle as generate-render
+	if remaining := h.renderCooldownRemaining(project, claims.UserID); remaining > 0 {
+		seconds := int(math.Ceil(remaining.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		utils.ResponseWithError(c, http.StatusTooManyRequests, "This project was rendered recently. Please wait before rendering it again.", gin.H{"retry_after_seconds": seconds})
+		return
+	}
+
+	h.markRenderStarted(c.Request.Context(), project)
+
+	if rerr := h.dispatchToRenderer(c.Request.Context(), project, req.ScriptContent, renderOptions{}); rerr != nil {
+		respondRenderError(c, rerr)
+		return
+	}
+
+	log.Infof("User-supplied script for project %s sent to renderer (%d bytes).", project.ID.String(), len(req.ScriptContent))
+	utils.ResponseWithSuccess(c, http.StatusAccepted, "Manim rendering process initiated", gin.H{
+		"project_id": project.ID.String(),
+		"status":     "rendering_initiated",
+		"message":    "Manim rendering is in progress. The video URL will be updated via callback.",
+	})
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	etaHistoryWindow  = 30 * 24 * time.Hour // Completed renders considered for the baseline
+	etaMinUserSamples = 5                   // Below this the user's history is too thin and the global history is used
+)
+
+// promptComplexityBands groups prompts by length, the best proxy for render complexity we store.
+// Render jobs don't record quality, so the baseline can't be split by it.
+var promptComplexityBands = []struct {
+	Name     string
+	Min, Max int // Max <= 0 is unbounded
+}{
+	{"simple", 0, 200},
+	{"moderate", 200, 600},
+	{"complex", 600, 0},
+}
+
+// RenderETAResponse estimates how long until a project's render finishes.
+type RenderETAResponse struct {
+	ProjectID        string   `json:"project_id"`
+	Complexity       string   `json:"complexity"`
+	Basis            string   `json:"basis"` // "user", "global", or "none" without render history
+	Samples          int      `json:"samples"`
+	RenderSeconds    *float64 `json:"render_seconds,omitempty"`     // Median render time (remaining time if already rendering)
+	QueueWaitSeconds *float64 `json:"queue_wait_seconds,omitempty"` // Wait for renders ahead of this one
+	EstimatedSeconds *float64 `json:"estimated_seconds,omitempty"`
+	QueueDepth       int      `json:"queue_depth"`
+}
+
+// GetRenderETA estimates how long a render of the project will take, from the median duration of
+// recent completed renders with a similar prompt length. The user's own history is used when it
+// has enough samples, the global history otherwise. For a project that isn't rendering yet, the
+// time to work through the current queue is added.
+func (h *Handlers) GetRenderETA(c *gin.Context) {
+	project, claims, ok := loadOwnedProject(c, "GetRenderETA")
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	band := promptComplexityBands[len(promptComplexityBands)-1]
+	promptLen := len([]rune(project.Prompt))
+	for _, b := range promptComplexityBands {
+		if promptLen >= b.Min && (b.Max <= 0 || promptLen < b.Max) {
+			band = b
+			break
+		}
+	}
+
+	since := time.Now().UTC().Add(-etaHistoryWindow)
+	resp := RenderETAResponse{ProjectID: project.ID.String(), Complexity: band.Name, Basis: "none"}
+	stats, err := queries.FindRenderDurationStats(ctx, &claims.UserID, band.Min, band.Max, since)
+	if err == nil && stats.Samples >= etaMinUserSamples {
+		resp.Basis = "user"
+	} else if err == nil {
+		stats, err = queries.FindRenderDurationStats(ctx, nil, band.Min, band.Max, since)
+		resp.Basis = "global"
+	}
+	if err != nil {
+		log.Errorf("GetRenderETA: Failed to compute render history for project %s: %v", project.ID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to estimate render time", nil)
+		return
+	}
+
+	queue, err := h.renderQueueStatus(ctx)
+	if err != nil {
+		log.Errorf("GetRenderETA: Failed to compute render queue status: %v", err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to estimate render time", nil)
+		return
+	}
+	resp.QueueDepth = queue.QueueDepth
+
+	if !stats.MedianSeconds.Valid {
+		resp.Basis = "none"
+		utils.ResponseWithSuccess(c, http.StatusOK, "Not enough render history to estimate render time", resp)
+		return
+	}
+	resp.Samples = stats.Samples
+
+	renderSeconds := stats.MedianSeconds.Float64
+	queueWait := 0.0
+	if (project.RenderStatus == "generating" || project.RenderStatus == "rendering") && project.LastRenderStartedAt.Valid {
+		// Already dispatched: only the rest of its own render remains
+		renderSeconds = math.Max(renderSeconds-time.Since(project.LastRenderStartedAt.Time).Seconds(), 0)
+	} else {
+		batches := math.Ceil(float64(queue.QueueDepth) / float64(h.Config.RenderConcurrency))
+		queueWait = batches * stats.MedianSeconds.Float64
+	}
+	renderSeconds, queueWait = math.Round(renderSeconds), math.Round(queueWait)
+	total := renderSeconds + queueWait
+	resp.RenderSeconds, resp.QueueWaitSeconds, resp.EstimatedSeconds = &renderSeconds, &queueWait, &total
+
+	utils.ResponseWithSuccess(c, http.StatusOK, "Render time estimated successfully", resp)
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/middleware"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	presetQualityValues  = []string{"low", "medium", "high", "production", "4k"} // Lowest to highest
+	presetFormatValues   = []string{"mp4", "mov", "webm", "gif"}
+	presetQualities      = stringSet(presetQualityValues)
+	presetFormats        = stringSet(presetFormatValues)
+	presetModelPattern   = regexp.MustCompile(`^gemini-[a-z0-9.\-]{1,57}$`)
+	presetProfilePattern = regexp.MustCompile(`^[a-z0-9_\-]{1,32}$`)
+)
+
+// RenderPresetRequest defines the structure for creating or replacing a render preset.
+// Omitted options fall back to the service defaults.
+type RenderPresetRequest struct {
+	Name    string `json:"name" binding:"required,min=1,max=100"`
+	Quality string `json:"quality"` // low, medium, high, production or 4k
+	Format  string `json:"format"`  // mp4, mov, webm or gif
+	Model   string `json:"model"`   // Gemini model name, e.g. gemini-1.5-pro
+	Profile string `json:"profile"` // Renderer profile name
+}
+
+// RenderPresetResponse describes a render preset.
+type RenderPresetResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Quality   string    `json:"quality,omitempty"`
+	Format    string    `json:"format,omitempty"`
+	Model     string    `json:"model,omitempty"`
+	Profile   string    `json:"profile,omitempty"`
+	CreatedAt string    `json:"created_at"`
+	UpdatedAt string    `json:"updated_at"`
+}
+
+func newRenderPresetResponse(preset *db.RenderPreset) RenderPresetResponse {
+	return RenderPresetResponse{
+		ID:        preset.ID,
+		Name:      preset.Name,
+		Quality:   preset.Quality,
+		Format:    preset.Format,
+		Model:     preset.Model,
+		Profile:   preset.Profile,
+		CreatedAt: preset.CreatedAt.Format(http.TimeFormat),
+		UpdatedAt: preset.UpdatedAt.Format(http.TimeFormat),
+	}
+}
+
+// normalize trims and lowercases the options and checks them against the allowlists,
+// returning a client-facing message for the first invalid one.
+func (r *RenderPresetRequest) normalize() string {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Quality = strings.ToLower(strings.TrimSpace(r.Quality))
+	r.Format = strings.ToLower(strings.TrimSpace(r.Format))
+	r.Model = strings.ToLower(strings.TrimSpace(r.Model))
+	r.Profile = strings.ToLower(strings.TrimSpace(r.Profile))
+
+	switch {
+	case r.Name == "":
+		return "Preset name must not be blank"
+	case r.Quality != "" && !presetQualities[r.Quality]:
+		return "Invalid quality. Must be one of: low, medium, high, production, 4k"
+	case r.Format != "" && !presetFormats[r.Format]:
+		return "Invalid format. Must be one of: mp4, mov, webm, gif"
+	case r.Model != "" && !presetModelPattern.MatchString(r.Model):
+		return "Invalid model. Must be a Gemini model name such as gemini-1.5-pro"
+	case r.Profile != "" && !presetProfilePattern.MatchString(r.Profile):
+		return "Invalid profile. Use up to 32 letters, digits, '-' or '_'"
+	}
+	return ""
+}
+
+// bindRenderPresetRequest parses and validates a preset body, writing the error response on failure.
+func bindRenderPresetRequest(c *gin.Context) (*RenderPresetRequest, bool) {
+	var req RenderPresetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", utils.BindingErrorDetails(err))
+		return nil, false
+	}
+	if msg := req.normalize(); msg != "" {
+		utils.ResponseWithError(c, http.StatusBadRequest, msg, nil)
+		return nil, false
+	}
+	return &req, true
+}
+
+// presetNameTaken reports whether the user already has a different preset with the given name.
+func presetNameTaken(c *gin.Context, userID uuid.UUID, name string, exceptID uuid.UUID) (bool, error) {
+	existing, err := queries.FindRenderPresetByName(c.Request.Context(), userID, name)
+	if err != nil {
+		return false, err
+	}
+	return existing != nil && existing.ID != exceptID, nil
+}
+
+// CreateRenderPreset saves a new named render preset for the authenticated user.
+func CreateRenderPreset(c *gin.Context) {
+	claims, exists := middleware.GetUserClaimsFromContext(c)
+	if !exists {
+		log.Error("CreateRenderPreset: User claims not found in context.")
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+		return
+	}
+	req, ok := bindRenderPresetRequest(c)
+	if !ok {
+		return
+	}
+
+	taken, err := presetNameTaken(c, claims.UserID, req.Name, uuid.Nil)
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to create render preset", nil)
+		return
+	}
+	if taken {
+		utils.ResponseWithError(c, http.StatusConflict, "A render preset with this name already exists", nil)
+		return
+	}
+
+	preset, err := queries.CreateRenderPreset(c.Request.Context(), &db.RenderPreset{
+		UserID:  claims.UserID,
+		Name:    req.Name,
+		Quality: req.Quality,
+		Format:  req.Format,
+		Model:   req.Model,
+		Profile: req.Profile,
+	})
+	if err != nil {
+		respondDBError(c, err, "Failed to create render preset")
+		return
+	}
+	log.Infof("CreateRenderPreset: User %s created render preset '%s'.", claims.UserID.String(), preset.Name)
+	utils.ResponseWithSuccess(c, http.StatusCreated, "Render preset created successfully", newRenderPresetResponse(preset))
+}
+
+// ListRenderPresets returns the authenticated user's render presets.
+func ListRenderPresets(c *gin.Context) {
+	claims, exists := middleware.GetUserClaimsFromContext(c)
+	if !exists {
+		log.Error("ListRenderPresets: User claims not found in context.")
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+		return
+	}
+	presets, err := queries.FindRenderPresetsByUserID(c.Request.Context(), claims.UserID)
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retrieve render presets", nil)
+		return
+	}
+	responses := make([]RenderPresetResponse, 0, len(presets))
+	for i := range presets {
+		responses = append(responses, newRenderPresetResponse(&presets[i]))
+	}
+	utils.ResponseWithSuccess(c, http.StatusOK, "Render presets retrieved successfully", responses)
+}
+
+// UpdateRenderPreset replaces the name and options of one of the authenticated user's presets.
+func UpdateRenderPreset(c *gin.Context) {
+	claims, exists := middleware.GetUserClaimsFromContext(c)
+	if !exists {
+		log.Error("UpdateRenderPreset: User claims not found in context.")
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+		return
+	}
+	presetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid preset ID format", nil)
+		return
+	}
+	req, ok := bindRenderPresetRequest(c)
+	if !ok {
+		return
+	}
+
+	preset, err := queries.FindRenderPresetByID(c.Request.Context(), presetID, claims.UserID)
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to update render preset", nil)
+		return
+	}
+	if preset == nil {
+		utils.ResponseWithError(c, http.StatusNotFound, "Render preset not found", nil)
+		return
+	}
+	taken, err := presetNameTaken(c, claims.UserID, req.Name, preset.ID)
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to update render preset", nil)
+		return
+	}
+	if taken {
+		utils.ResponseWithError(c, http.StatusConflict, "A render preset with this name already exists", nil)
+		return
+	}
+
+	preset.Name, preset.Quality, preset.Format, preset.Model, preset.Profile = req.Name, req.Quality, req.Format, req.Model, req.Profile
+	if err := queries.UpdateRenderPreset(c.Request.Context(), preset); err != nil {
+		if err == sql.ErrNoRows {
+			utils.ResponseWithError(c, http.StatusNotFound, "Render preset not found", nil)
+			return
+		}
+		respondDBError(c, err, "Failed to update render preset")
+		return
+	}
+	utils.ResponseWithSuccess(c, http.StatusOK, "Render preset updated successfully", newRenderPresetResponse(preset))
+}
+
+// DeleteRenderPreset deletes one of the authenticated user's presets.
+func DeleteRenderPreset(c *gin.Context) {
+	claims, exists := middleware.GetUserClaimsFromContext(c)
+	if !exists {
+		log.Error("DeleteRenderPreset: User claims not found in context.")
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+		return
+	}
+	presetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid preset ID format", nil)
+		return
+	}
+	if err := queries.DeleteRenderPreset(c.Request.Context(), presetID, claims.UserID); err != nil {
+		if err == sql.ErrNoRows {
+			utils.ResponseWithError(c, http.StatusNotFound, "Render preset not found", nil)
+			return
+		}
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to delete render preset", nil)
+		return
+	}
+	log.Infof("DeleteRenderPreset: User %s deleted render preset %s.", claims.UserID.String(), presetID.String())
+	utils.ResponseWithSuccess(c, http.StatusOK, "Render preset deleted successfully", nil)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// renderQueueCacheTTL is how long a computed queue status is served before recomputing it.
+const renderQueueCacheTTL = 10 * time.Second
+
+// RenderQueueResponse describes how busy rendering is.
+type RenderQueueResponse struct {
+	QueueDepth           int     `json:"queue_depth"`                      // Renders currently in progress
+	Concurrency          int     `json:"concurrency"`                      // Renders dispatched at once
+	EstimatedWaitSeconds float64 `json:"estimated_wait_seconds,omitempty"` // Omitted until a render has completed recently
+	ComputedAt           string  `json:"computed_at"`
+}
+
+// renderQueueCache holds the last computed queue status.
+type renderQueueCache struct {
+	mu       sync.Mutex
+	response *RenderQueueResponse
+	expires  time.Time
+}
+
+// renderQueueStatus returns the cached queue status, recomputing it from render_jobs once it expires.
+func (h *Handlers) renderQueueStatus(ctx context.Context) (*RenderQueueResponse, error) {
+	cache := &h.queueCache
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	now := time.Now()
+	if cache.response != nil && now.Before(cache.expires) {
+		return cache.response, nil
+	}
+
+	stats, err := queries.FindRenderQueueStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	response := &RenderQueueResponse{
+		QueueDepth:  stats.Running,
+		Concurrency: h.Config.RenderConcurrency,
+		ComputedAt:  now.UTC().Format(http.TimeFormat),
+	}
+	// A new render waits for the ones ahead of it, processed Concurrency at a time
+	if stats.AvgDurationSeconds.Valid {
+		batches := math.Ceil(float64(stats.Running) / float64(h.Config.RenderConcurrency))
+		response.EstimatedWaitSeconds = math.Round(batches * stats.AvgDurationSeconds.Float64)
+	}
+
+	cache.response = response
+	cache.expires = now.Add(renderQueueCacheTTL)
+	return response, nil
+}
+
+// GetRenderQueue reports the approximate render queue depth and wait, so users can tell a busy
+// system from a lost render. Computed from in-progress render jobs and cached briefly.
+func (h *Handlers) GetRenderQueue(c *gin.Context) {
+	status, err := h.renderQueueStatus(c.Request.Context())
+	if err != nil {
+		log.Errorf("GetRenderQueue: Failed to compute render queue status: %v", err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retrieve render queue status", nil)
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=10")
+	utils.ResponseWithSuccess(c, http.StatusOK, "Render queue status retrieved successfully", status)
+}
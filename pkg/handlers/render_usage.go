@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// RenderUsageResponse reports the Gemini token usage and estimated cost of one render.
+type RenderUsageResponse struct {
+	JobID            uuid.UUID `json:"job_id"`
+	Status           string    `json:"status"`
+	Billable         bool      `json:"billable"` // The render finished and Gemini reported usage for it
+	InputTokens      int64     `json:"input_tokens"`
+	OutputTokens     int64     `json:"output_tokens"`
+	EstimatedCostUSD float64   `json:"estimated_cost_usd"`
+}
+
+// GetRenderUsage returns the token usage Gemini reported for a render job along with its cost,
+// priced with GEMINI_INPUT_COST_PER_MILLION and GEMINI_OUTPUT_COST_PER_MILLION. Jobs without
+// recorded usage (still running, rendered from user code, or from before usage was recorded)
+// report zero tokens and aren't billable.
+func (h *Handlers) GetRenderUsage(c *gin.Context) {
+	project, _, ok := loadOwnedProject(c, "GetRenderUsage")
+	if !ok {
+		return
+	}
+
+	jobID, err := uuid.Parse(c.Param("jobId"))
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid render job ID format", nil)
+		return
+	}
+
+	job, err := queries.FindRenderJobByID(c.Request.Context(), jobID, project.ID)
+	if err != nil {
+		log.Errorf("GetRenderUsage: Failed to fetch render job %s: %v", jobID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retrieve render job", nil)
+		return
+	}
+	if job == nil {
+		utils.ResponseWithError(c, http.StatusNotFound, "Render job not found", nil)
+		return
+	}
+
+	resp := RenderUsageResponse{
+		JobID:        job.ID,
+		Status:       job.Status,
+		InputTokens:  job.InputTokens.Int64,
+		OutputTokens: job.OutputTokens.Int64,
+	}
+	hasUsage := job.InputTokens.Valid || job.OutputTokens.Valid
+	resp.Billable = hasUsage && job.Status != queries.RenderJobRunning
+	resp.EstimatedCostUSD = (float64(resp.InputTokens)*h.Config.GeminiInputCostPerMillion +
+		float64(resp.OutputTokens)*h.Config.GeminiOutputCostPerMillion) / 1e6
+
+	utils.ResponseWithSuccess(c, http.StatusOK, "Render usage retrieved successfully", resp)
+}
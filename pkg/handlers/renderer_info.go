@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// GetRendererInfo reports the Manim version the renderer runs, as last fetched from it, so users
+// know which Manim API their code targets.
+func (h *Handlers) GetRendererInfo(c *gin.Context) {
+	info, ok := h.RendererInfo.Get()
+	if !ok {
+		utils.ResponseWithError(c, http.StatusServiceUnavailable, "Renderer version is not known yet", nil)
+		return
+	}
+	utils.ResponseWithSuccess(c, http.StatusOK, "Renderer info retrieved successfully", info)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"database/sql"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/middleware"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxReRenderPerRequest caps how many failed projects a single re-render request queues.
+const maxReRenderPerRequest = 50
+
+// ReRenderResult reports what happened to one failed project.
+type ReRenderResult struct {
+	ProjectID         string `json:"project_id"`
+	PreviousStatus    string `json:"previous_status"`
+	Queued            bool   `json:"queued"`
+	Reason            string `json:"reason,omitempty"`
+	RetryAfterSeconds int    `json:"retry_after_seconds,omitempty"`
+}
+
+// ReRenderFailedProjects re-triggers every one of the caller's projects in a failed* status.
+// Projects still in their render cooldown are skipped, as are those beyond the caller's render
+// rate limit (one token per project). Renders are dispatched through the
+// bounded background pool so a large batch doesn't stampede the renderer.
+func (h *Handlers) ReRenderFailedProjects(c *gin.Context) {
+	claims, exists := middleware.GetUserClaimsFromContext(c)
+	if !exists {
+		log.Error("ReRenderFailedProjects: User claims not found in context.")
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+		return
+	}
+
+	projects, err := queries.FindFailedManimProjectsByUserID(c.Request.Context(), claims.UserID)
+	if err != nil {
+		log.Errorf("ReRenderFailedProjects: Failed to fetch failed projects for user %s: %v", claims.UserID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retrieve failed projects", nil)
+		return
+	}
+
+	results := make([]ReRenderResult, 0, len(projects))
+	queued := 0
+	for i := range projects {
+		project := projects[i]
+		result := ReRenderResult{ProjectID: project.ID.String(), PreviousStatus: project.RenderStatus}
+
+		switch remaining := h.renderCooldownRemaining(&project, claims.UserID); {
+		case project.Prompt == "":
+			result.Reason = "project has no prompt"
+		case project.Locked:
+			result.Reason = "project is locked"
+		case remaining > 0:
+			result.Reason = "render cooldown active"
+			result.RetryAfterSeconds = int(math.Ceil(remaining.Seconds()))
+		case queued >= maxReRenderPerRequest:
+			result.Reason = "per-request limit reached; call again to continue"
+		default:
+			// Each render is a paid Gemini generation, charged to the caller's render rate limit
+			if allowed, wait := h.RenderLimiter.Allow(claims.UserID); !allowed {
+				result.Reason = "render rate limit reached"
+				result.RetryAfterSeconds = int(math.Ceil(wait.Seconds()))
+				break
+			}
+			// Mark the project pending before queueing so a repeated call can't queue it twice.
+			project.RenderStatus = "pending"
+			project.LastRenderStartedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+			if err := queries.UpdateManimProject(c.Request.Context(), &project); err != nil {
+				log.Errorf("ReRenderFailedProjects: Failed to mark project %s pending: %v", project.ID.String(), err)
+				result.Reason = "failed to queue render"
+				break
+			}
+			h.queueRender(&project)
+			result.Queued = true
+			queued++
+		}
+		results = append(results, result)
+	}
+
+	log.Infof("ReRenderFailedProjects: Queued %d of %d failed projects for user %s.", queued, len(projects), claims.UserID.String())
+	utils.ResponseWithSuccess(c, http.StatusAccepted, "Re-render of failed projects initiated", gin.H{
+		"failed_found": len(projects),
+		"queued":       queued,
+		"skipped":      len(projects) - queued,
+		"results":      results,
+	})
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/gin-gonic/gin"
+)
+
+// projectResponseOptions holds the opt-in presentation options clients can request with query
+// parameters on project endpoints.
+type projectResponseOptions struct {
+	Humanize bool           // ?humanize=true adds created_ago/updated_ago
+	Location *time.Location // ?tz=America/New_York formats timestamps in that zone; nil means UTC
+}
+
+// projectResponseOptionsFromQuery reads the presentation options from the request's query string.
+// An invalid ?tz= falls back to UTC; handlers that reject it call timezoneFromQuery first.
+func projectResponseOptionsFromQuery(c *gin.Context) projectResponseOptions {
+	loc, _ := timezoneFromQuery(c)
+	return projectResponseOptions{
+		Humanize: c.Query("humanize") == "true",
+		Location: loc,
+	}
+}
+
+// timezoneFromQuery resolves the IANA zone named by ?tz=, or nil when it's absent.
+func timezoneFromQuery(c *gin.Context) (*time.Location, error) {
+	name := c.Query("tz")
+	if name == "" {
+		return nil, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return loc, nil
+}
+
+// render converts a project to its response, applying the requested options.
+func (o projectResponseOptions) render(project *db.ManimProject) ProjectResponse {
+	pr := newProjectResponse(project)
+	if o.Location != nil {
+		// http.TimeFormat always says GMT, so local times carry their numeric offset instead
+		pr.CreatedAt = project.CreatedAt.In(o.Location).Format(time.RFC1123Z)
+		pr.UpdatedAt = project.UpdatedAt.In(o.Location).Format(time.RFC1123Z)
+	}
+	if o.Humanize {
+		now := time.Now()
+		pr.CreatedAgo = humanizeSince(project.CreatedAt, now)
+		pr.UpdatedAgo = humanizeSince(project.UpdatedAt, now)
+	}
+	return pr
+}
+
+// humanizeSince describes how long before now t was, e.g. "2 hours ago".
+func humanizeSince(t, now time.Time) string {
+	elapsed := now.Sub(t)
+	if elapsed < time.Minute {
+		return "just now"
+	}
+	units := []struct {
+		name string
+		size time.Duration
+	}{
+		{"year", 365 * 24 * time.Hour},
+		{"month", 30 * 24 * time.Hour},
+		{"day", 24 * time.Hour},
+		{"hour", time.Hour},
+		{"minute", time.Minute},
+	}
+	for _, unit := range units {
+		if elapsed >= unit.size {
+			count := int(elapsed / unit.size)
+			if count == 1 {
+				return fmt.Sprintf("1 %s ago", unit.name)
+			}
+			return fmt.Sprintf("%d %ss ago", count, unit.name)
+		}
+	}
+	return "just now"
+}
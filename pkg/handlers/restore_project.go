@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/middleware"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// RestoreManimProject handles POST /api/projects/:id/restore. It undoes the delete of one of the
+// caller's projects, as long as it's still within DELETED_PROJECT_RETENTION_DAYS of the delete.
+func (h *Handlers) RestoreManimProject(c *gin.Context) {
+	projectIDParam := c.Param("id")
+	projectID, err := uuid.Parse(projectIDParam)
+	if err != nil {
+		log.Warnf("RestoreManimProject: Invalid project ID format '%s': %v", projectIDParam, err)
+		respondForError(c, errInvalidProjectID)
+		return
+	}
+
+	claims, exists := middleware.GetUserClaimsFromContext(c)
+	if !exists {
+		log.Error("RestoreManimProject: User claims not found in context.")
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+		return
+	}
+
+	retention := time.Duration(h.Config.DeletedProjectRetentionDays) * 24 * time.Hour
+	project, err := queries.RestoreManimProject(c.Request.Context(), projectID, claims.UserID, time.Now().UTC().Add(-retention))
+	if err != nil {
+		// sql.ErrNoRows covers a project that isn't deleted, is past the grace period or isn't the caller's
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.ResponseWithErrorCode(c, http.StatusNotFound, CodeNotFound, "Deleted project not found or can no longer be restored", nil)
+			return
+		}
+		if errors.Is(err, db.ErrDuplicate) {
+			utils.ResponseWithErrorCode(c, http.StatusConflict, CodeDuplicate, "You already have another project with the same name", nil)
+			return
+		}
+		log.Errorf("RestoreManimProject: Failed to restore project %s for user %s: %v", projectID.String(), claims.UserID.String(), err)
+		respondDBError(c, err, "Failed to restore project")
+		return
+	}
+
+	log.Infof("Manim project %s restored for user %s.", projectID.String(), claims.UserID.String())
+	utils.ResponseWithSuccess(c, http.StatusOK, "Manim project restored successfully", projectResponseOptionsFromQuery(c).render(project))
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// RetryRender re-renders a project whose last render failed. The script stored by the failed
+// render is sent to the renderer again as is; only a project without one goes back to Gemini.
+// A decomposed project with a failed scene is retried through that scene instead.
+func (h *Handlers) RetryRender(c *gin.Context) {
+	project, claims, ok := loadOwnedProject(c, "RetryRender")
+	if !ok {
+		return
+	}
+	projectID := project.ID
+	if project.Locked {
+		respondForError(c, queries.ErrProjectLocked)
+		return
+	}
+	if !strings.HasPrefix(project.RenderStatus, "failed") {
+		utils.ResponseWithError(c, http.StatusConflict, "Only failed renders can be retried", gin.H{"render_status": project.RenderStatus})
+		return
+	}
+	if project.RenderStatus == sceneFailedStatus {
+		utils.ResponseWithError(c, http.StatusConflict, "Retry the failed scenes of this project instead", gin.H{"render_status": project.RenderStatus})
+		return
+	}
+
+	// Same per-project throttle as generate-render
+	if remaining := h.renderCooldownRemaining(project, claims.UserID); remaining > 0 {
+		seconds := int(math.Ceil(remaining.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		utils.ResponseWithError(c, http.StatusTooManyRequests, "This project was rendered recently. Please wait before rendering it again.", gin.H{"retry_after_seconds": seconds})
+		return
+	}
+
+	code, err := queries.FindManimProjectCode(c.Request.Context(), projectID)
+	if err != nil {
+		log.Errorf("RetryRender: Failed to load stored code of project %s: %v", projectID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to load the project's Manim code", nil)
+		return
+	}
+
+	regenerated := code == ""
+	if regenerated {
+		if strings.TrimSpace(project.Prompt) == "" {
+			utils.ResponseWithError(c, http.StatusBadRequest, "Project prompt is empty. Please update the project with a valid prompt.", nil)
+			return
+		}
+		log.Infof("RetryRender: Project %s has no stored code; generating it again.", projectID.String())
+		if rerr := h.startRender(c.Request.Context(), project, renderOptions{}); rerr != nil {
+			respondRenderError(c, rerr)
+			return
+		}
+	} else {
+		log.Infof("RetryRender: Re-sending the stored code of project %s (previous status %q).", projectID.String(), project.RenderStatus)
+		h.markRenderStartedAs(c.Request.Context(), project, "rendering")
+		if rerr := h.dispatchToRenderer(c.Request.Context(), project, code, renderOptions{}); rerr != nil {
+			respondRenderError(c, rerr)
+			return
+		}
+	}
+
+	utils.ResponseWithSuccessCode(c, http.StatusAccepted, CodeRenderInitiated, "Render retry initiated", gin.H{
+		"project_id":  projectID.String(),
+		"status":      "rendering_initiated",
+		"regenerated": regenerated,
+		"message":     "Manim rendering is in progress. The video URL will be updated via callback.",
+	})
+}
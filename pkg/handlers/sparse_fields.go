@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// projectResponseFields maps each JSON field name of ProjectResponse to its struct field index.
+var projectResponseFields = jsonFieldIndex(reflect.TypeOf(ProjectResponse{}))
+
+// jsonFieldIndex maps the JSON names of a struct's fields to their indexes.
+func jsonFieldIndex(t reflect.Type) map[string]int {
+	index := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			index[name] = i
+		}
+	}
+	return index
+}
+
+// projectFieldsFromQuery parses ?fields=id,name,render_status into the requested project fields.
+// No parameter means the full project; unknown field names are an error.
+func projectFieldsFromQuery(c *gin.Context) ([]string, error) {
+	raw := c.Query("fields")
+	if raw == "" {
+		return nil, nil
+	}
+	var fields []string
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if _, ok := projectResponseFields[field]; !ok {
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// sparseProject restricts a project response to the given fields, or returns it whole when no
+// fields were requested.
+func sparseProject(pr ProjectResponse, fields []string) interface{} {
+	if len(fields) == 0 {
+		return pr
+	}
+	value := reflect.ValueOf(pr)
+	sparse := make(gin.H, len(fields))
+	for _, field := range fields {
+		sparse[field] = value.Field(projectResponseFields[field]).Interface()
+	}
+	return sparse
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/middleware"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxTimelineBuckets bounds the size of a timeline response.
+const maxTimelineBuckets = 1000
+
+// timelineIntervals maps the accepted interval names to their bucket width.
+var timelineIntervals = map[string]time.Duration{
+	"hour": time.Hour,
+	"day":  24 * time.Hour,
+	"week": 7 * 24 * time.Hour,
+}
+
+// parseQueryTime parses a time query parameter. It accepts RFC 3339 timestamps or plain dates (YYYY-MM-DD, midnight UTC).
+func parseQueryTime(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Parse("2006-01-02", raw)
+}
+
+// truncateUTC truncates t to the start of its interval in UTC, matching Postgres date_trunc
+// (weeks start on Monday).
+func truncateUTC(t time.Time, interval string) time.Time {
+	t = t.UTC()
+	switch interval {
+	case "hour":
+		return t.Truncate(time.Hour)
+	case "week":
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		offset := (int(day.Weekday()) + 6) % 7 // days since Monday
+		return day.AddDate(0, 0, -offset)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// GetRenderTimeline returns the caller's render counts (started/completed/failed) bucketed by
+// hour, day or week between from and to. Buckets without renders are included with zero counts.
+// Defaults: interval=day, to=now, from=30 days before to.
+func GetRenderTimeline(c *gin.Context) {
+	claims, exists := middleware.GetUserClaimsFromContext(c)
+	if !exists {
+		log.Error("GetRenderTimeline: User claims not found in context.")
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+		return
+	}
+
+	interval := c.DefaultQuery("interval", "day")
+	width, ok := timelineIntervals[interval]
+	if !ok {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid interval. Must be one of: hour, day, week", nil)
+		return
+	}
+
+	to := time.Now().UTC()
+	if raw := c.Query("to"); raw != "" {
+		parsed, err := parseQueryTime(raw)
+		if err != nil {
+			utils.ResponseWithError(c, http.StatusBadRequest, "Invalid 'to' time. Use RFC 3339 or YYYY-MM-DD", nil)
+			return
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, -30)
+	if raw := c.Query("from"); raw != "" {
+		parsed, err := parseQueryTime(raw)
+		if err != nil {
+			utils.ResponseWithError(c, http.StatusBadRequest, "Invalid 'from' time. Use RFC 3339 or YYYY-MM-DD", nil)
+			return
+		}
+		from = parsed
+	}
+	if !from.Before(to) {
+		utils.ResponseWithError(c, http.StatusBadRequest, "'from' must be before 'to'", nil)
+		return
+	}
+	if to.Sub(from)/width > maxTimelineBuckets {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Time range too large for the selected interval", gin.H{"max_buckets": maxTimelineBuckets})
+		return
+	}
+
+	rows, err := queries.RenderTimeline(c.Request.Context(), claims.UserID, interval, from, to)
+	if err != nil {
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to compute render statistics", nil)
+		return
+	}
+
+	// Fill gaps so charts get a continuous series
+	counts := make(map[int64]queries.RenderTimelineBucket, len(rows))
+	for _, row := range rows {
+		counts[row.Bucket.Unix()] = row
+	}
+	var buckets []queries.RenderTimelineBucket
+	for bucket := truncateUTC(from, interval); bucket.Before(to); bucket = bucket.Add(width) {
+		entry, ok := counts[bucket.Unix()]
+		if !ok {
+			entry = queries.RenderTimelineBucket{}
+		}
+		entry.Bucket = bucket
+		buckets = append(buckets, entry)
+	}
+
+	utils.ResponseWithSuccess(c, http.StatusOK, "Render statistics retrieved successfully", gin.H{
+		"interval": interval,
+		"from":     from.Format(time.RFC3339),
+		"to":       to.Format(time.RFC3339),
+		"buckets":  buckets,
+	})
+}
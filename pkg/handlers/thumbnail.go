@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// thumbnailCallbackNonce stands in for the render nonce in thumbnail callback signatures, keeping
+// them distinct from merge and render signatures. A replayed callback can only set the thumbnail
+// the renderer already produced.
+const thumbnailCallbackNonce = "thumbnail"
+
+// RegenerateThumbnailRequest selects the frame to extract. An empty body uses the first frame.
+type RegenerateThumbnailRequest struct {
+	TimestampSeconds float64 `json:"timestamp_seconds" binding:"omitempty,min=0"`
+}
+
+// ThumbnailRendererRequest is sent to the renderer's /thumbnail endpoint.
+type ThumbnailRendererRequest struct {
+	ProjectID         string  `json:"project_id"`
+	VideoURL          string  `json:"video_url"`
+	TimestampSeconds  float64 `json:"timestamp_seconds"`
+	CallbackURL       string  `json:"callback_url"`
+	CallbackSignature string  `json:"callback_signature"` // To send back as X-Render-Signature
+}
+
+// ThumbnailCallbackRequest is what the renderer posts back once a frame has been extracted.
+type ThumbnailCallbackRequest struct {
+	ProjectID    string `json:"project_id"`
+	Status       string `json:"status"` // "completed" or "failed"
+	ThumbnailURL string `json:"thumbnail_url"`
+	ErrorDetails string `json:"error_details"`
+}
+
+// RegenerateThumbnail asks the renderer to extract a new preview frame from the project's
+// existing video. The thumbnail_url is updated asynchronously via HandleThumbnailCallback.
+func (h *Handlers) RegenerateThumbnail(c *gin.Context) {
+	var req RegenerateThumbnailRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		log.Warnf("RegenerateThumbnail: Invalid request body: %v", err)
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", utils.BindingErrorDetails(err))
+		return
+	}
+
+	project, _, ok := loadOwnedProject(c, "RegenerateThumbnail")
+	if !ok {
+		return
+	}
+	if project.RenderStatus != "completed" || !project.VideoURL.Valid || project.VideoURL.String == "" {
+		utils.ResponseWithError(c, http.StatusConflict, "Project must have a completed video before its thumbnail can be regenerated", nil)
+		return
+	}
+
+	callbackURL, signature := h.thumbnailCallbackURL(project.ID)
+	jsonBody, _ := json.Marshal(ThumbnailRendererRequest{
+		ProjectID:         project.ID.String(),
+		VideoURL:          project.VideoURL.String,
+		TimestampSeconds:  req.TimestampSeconds,
+		CallbackURL:       callbackURL,
+		CallbackSignature: signature,
+	})
+	rendererURL := fmt.Sprintf("%s/thumbnail", h.Config.ManimRendererURL)
+	httpReq, err := http.NewRequestWithContext(c.Request.Context(), http.MethodPost, rendererURL, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		log.Errorf("RegenerateThumbnail: Failed to create request to renderer: %v", err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to prepare thumbnail request", nil)
+		return
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		log.Errorf("RegenerateThumbnail: Failed to send request to renderer %s: %v", rendererURL, err)
+		utils.ResponseWithError(c, http.StatusBadGateway, "Failed to connect to Manim renderer", nil)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		log.Errorf("RegenerateThumbnail: Renderer returned unexpected status %d for project %s", resp.StatusCode, project.ID.String())
+		utils.ResponseWithError(c, http.StatusBadGateway, "Renderer rejected the thumbnail request", nil)
+		return
+	}
+
+	log.Infof("Thumbnail regeneration requested for project %s at %.2fs.", project.ID.String(), req.TimestampSeconds)
+	utils.ResponseWithSuccess(c, http.StatusAccepted, "Thumbnail regeneration initiated", gin.H{
+		"project_id":        project.ID.String(),
+		"timestamp_seconds": req.TimestampSeconds,
+	})
+}
+
+// thumbnailCallbackURL returns the callback URL for a thumbnail extraction and the signature the
+// renderer must send back in the X-Render-Signature header. The URL carries the project (p) and an
+// expiry (exp), signed like render callbacks.
+func (h *Handlers) thumbnailCallbackURL(projectID uuid.UUID) (string, string) {
+	expires := time.Now().Add(time.Duration(h.Config.CallbackTTLSeconds) * time.Second).Unix()
+	query := url.Values{}
+	query.Set("p", projectID.String())
+	query.Set("exp", strconv.FormatInt(expires, 10))
+	signature := callbackSignature(h.Config.CallbackSigningSecret, projectID.String(), thumbnailCallbackNonce, expires)
+	return h.callbackURL("/api/projects/thumbnail-callback") + "?" + query.Encode(), signature
+}
+
+// checkThumbnailCallbackSignature verifies the X-Render-Signature header of a thumbnail callback.
+// Without CALLBACK_SIGNING_SECRET no callback can be verified, so all are rejected.
+func (h *Handlers) checkThumbnailCallbackSignature(c *gin.Context, projectID uuid.UUID) error {
+	secret := h.Config.CallbackSigningSecret
+	if secret == "" {
+		return errCallbackSignature
+	}
+	expires, err := strconv.ParseInt(c.Query("exp"), 10, 64)
+	if err != nil || c.Query("p") != projectID.String() {
+		return errCallbackSignature
+	}
+	signature := c.GetHeader(renderSignatureHeader)
+	expected := callbackSignature(secret, projectID.String(), thumbnailCallbackNonce, expires)
+	if signature == "" || !hmac.Equal([]byte(signature), []byte(expected)) {
+		return errCallbackSignature
+	}
+	if time.Now().Unix() > expires {
+		return errCallbackExpired
+	}
+	return nil
+}
+
+// HandleThumbnailCallback receives the result of a thumbnail extraction from the renderer.
+// Callbacks without a valid X-Render-Signature are rejected with 401. Failures leave the existing
+// thumbnail in place.
+func (h *Handlers) HandleThumbnailCallback(c *gin.Context) {
+	var callback ThumbnailCallbackRequest
+	if err := c.ShouldBindJSON(&callback); err != nil {
+		log.Errorf("HandleThumbnailCallback: Invalid callback request body: %v", err)
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid callback request body", utils.BindingErrorDetails(err))
+		return
+	}
+	projectID, err := uuid.Parse(callback.ProjectID)
+	if err != nil {
+		log.Errorf("HandleThumbnailCallback: Invalid ProjectID in callback '%s': %v", callback.ProjectID, err)
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid ProjectID in callback", nil)
+		return
+	}
+	if err := h.checkThumbnailCallbackSignature(c, projectID); err != nil {
+		log.Warnf("HandleThumbnailCallback: Rejected callback for project %s: %v", projectID.String(), err)
+		utils.ResponseWithError(c, http.StatusUnauthorized, "Invalid or expired callback signature", nil)
+		return
+	}
+
+	if callback.Status != "completed" || callback.ThumbnailURL == "" {
+		log.Errorf("Thumbnail regeneration for project %s failed with status %s. Details: %s", projectID.String(), callback.Status, callback.ErrorDetails)
+		utils.ResponseWithSuccess(c, http.StatusOK, "Callback processed successfully", nil)
+		return
+	}
+
+	err = queries.SetManimProjectThumbnailURL(c.Request.Context(), projectID, callback.ThumbnailURL)
+	if err == sql.ErrNoRows {
+		log.Warnf("HandleThumbnailCallback: Project %s not found for callback. Perhaps already deleted?", projectID.String())
+		utils.ResponseWithError(c, http.StatusNotFound, "Project not found for callback", nil)
+		return
+	}
+	if err != nil {
+		log.Errorf("HandleThumbnailCallback: Failed to update thumbnail for project %s: %v", projectID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to update project thumbnail", nil)
+		return
+	}
+
+	log.Infof("Project %s thumbnail updated: %s", projectID.String(), callback.ThumbnailURL)
+	utils.ResponseWithSuccess(c, http.StatusOK, "Callback processed successfully", nil)
+}
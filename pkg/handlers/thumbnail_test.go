@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/config"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestHandleThumbnailCallbackSignature(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handlers{Config: &config.Config{CallbackSigningSecret: "test-secret", CallbackTTLSeconds: 60}}
+	projectID := uuid.New()
+	callbackURL, signature := h.thumbnailCallbackURL(projectID)
+	parsed, err := url.Parse(callbackURL)
+	if err != nil {
+		t.Fatalf("invalid callback URL %q: %v", callbackURL, err)
+	}
+	// A failed extraction is acknowledged without touching the database
+	body := `{"project_id":"` + projectID.String() + `","status":"failed","error_details":"boom"}`
+
+	tests := []struct {
+		name       string
+		query      string
+		signature  string
+		wantStatus int
+	}{
+		{"valid signature", parsed.RawQuery, signature, http.StatusOK},
+		{"missing signature", parsed.RawQuery, "", http.StatusUnauthorized},
+		{"unsigned URL", "", signature, http.StatusUnauthorized},
+		{"signature for another project", strings.Replace(parsed.RawQuery, projectID.String(), uuid.NewString(), 1), signature, http.StatusUnauthorized},
+		{"tampered signature", parsed.RawQuery, strings.ToUpper(signature), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/api/projects/thumbnail-callback", h.HandleThumbnailCallback)
+			req := httptest.NewRequest(http.MethodPost, "/api/projects/thumbnail-callback?"+tt.query, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.signature != "" {
+				req.Header.Set(renderSignatureHeader, tt.signature)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/middleware"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// TransferProjectRequest is the body of POST /api/projects/:id/transfer.
+type TransferProjectRequest struct {
+	Email string `json:"email" binding:"required,email"` // Recipient's account email
+}
+
+// TransferProject hands one of the caller's projects, along with its sub-projects, to another
+// user. The transfer takes effect immediately and is recorded in the audit log.
+func TransferProject(c *gin.Context) {
+	project, claims, ok := loadOwnedProject(c, "TransferProject")
+	if !ok {
+		return
+	}
+
+	var req TransferProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", utils.BindingErrorDetails(err))
+		return
+	}
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+
+	if project.Locked {
+		respondProjectLocked(c)
+		return
+	}
+
+	recipient, err := queries.FindUserByEmail(c.Request.Context(), req.Email)
+	if err != nil {
+		log.Errorf("TransferProject: Failed to look up recipient '%s': %v", req.Email, err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to look up recipient", nil)
+		return
+	}
+	if recipient == nil {
+		utils.ResponseWithError(c, http.StatusNotFound, "Recipient not found", nil)
+		return
+	}
+	if recipient.ID == claims.UserID {
+		utils.ResponseWithError(c, http.StatusBadRequest, "You already own this project", nil)
+		return
+	}
+
+	transferred, err := queries.TransferManimProject(c.Request.Context(), project.ID, claims.UserID, recipient.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.ResponseWithError(c, http.StatusNotFound, "Manim project not found", nil)
+			return
+		}
+		if errors.Is(err, db.ErrDuplicate) {
+			utils.ResponseWithErrorCode(c, http.StatusConflict, CodeDuplicate, "Recipient already has a project with the same name", nil)
+			return
+		}
+		log.Errorf("TransferProject: Failed to transfer project %s: %v", project.ID.String(), err)
+		respondDBError(c, err, "Failed to transfer project")
+		return
+	}
+
+	if err := queries.RecordProjectTransfer(c.Request.Context(), project.ID, claims.UserID, recipient.ID); err != nil {
+		respondDBError(c, err, "Failed to transfer project")
+		return
+	}
+
+	log.Infof("AUDIT project_transfer: Project %s (%d project(s) including sub-projects) transferred from user %s to user %s.",
+		project.ID.String(), transferred, claims.UserID.String(), recipient.ID.String())
+
+	utils.ResponseWithSuccess(c, http.StatusOK, "Project transferred successfully", gin.H{
+		"id":                   project.ID,
+		"new_owner_id":         recipient.ID,
+		"transferred_projects": transferred,
+	})
+}
+
+// TransferredProjectResponse is a project received through a transfer.
+type TransferredProjectResponse struct {
+	ProjectResponse
+	FromUserID    *uuid.UUID `json:"from_user_id"` // null once the sender's account is deleted
+	FromUserEmail string     `json:"from_user_email,omitempty"`
+	TransferredAt string     `json:"transferred_at"`
+}
+
+// GetProjectsSharedWithMe lists the projects other users transferred to the caller that the caller
+// still owns, most recent first. Transfers take effect immediately, so there are no pending ones.
+func (h *Handlers) GetProjectsSharedWithMe(c *gin.Context) {
+	claims, exists := middleware.GetUserClaimsFromContext(c)
+	if !exists {
+		log.Error("GetProjectsSharedWithMe: User claims not found in context.")
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+		return
+	}
+
+	projects, err := queries.FindProjectsTransferredToUser(c.Request.Context(), claims.UserID)
+	if err != nil {
+		log.Errorf("GetProjectsSharedWithMe: Failed to fetch transferred projects for user %s: %v", claims.UserID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to retrieve shared projects", nil)
+		return
+	}
+
+	responses := make([]TransferredProjectResponse, len(projects))
+	for i := range projects {
+		pr := newProjectResponse(&projects[i].ManimProject)
+		pr.VideoURL = h.Config.RewriteVideoURL(pr.VideoURL)
+		responses[i] = TransferredProjectResponse{
+			ProjectResponse: pr,
+			FromUserEmail:   projects[i].FromUserEmail.String,
+			TransferredAt:   projects[i].TransferredAt.UTC().Format(http.TimeFormat),
+		}
+		if projects[i].FromUserID.Valid {
+			responses[i].FromUserID = &projects[i].FromUserID.UUID
+		}
+	}
+	utils.ResponseWithSuccess(c, http.StatusOK, "Shared projects retrieved successfully", responses)
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// VariantRequest is the body of POST /api/projects/:id/variant.
+type VariantRequest struct {
+	StyleDirective string `json:"style_directive" binding:"required,max=500"` // e.g. "dark mode", "slower pacing"
+}
+
+// variantPrompt appends a style directive to a project's prompt.
+func variantPrompt(prompt, directive string) string {
+	return fmt.Sprintf("%s\n\nStyle: %s", prompt, directive)
+}
+
+// CreateProjectVariant renders a variation of a project without touching it: a child project
+// (parent_project_id set) is created with the style directive appended to the original prompt,
+// and its code is generated and rendered like generate-render. The child keeps the combined
+// prompt, so re-rendering it reproduces the variant.
+func (h *Handlers) CreateProjectVariant(c *gin.Context) {
+	var req VariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("CreateProjectVariant: Invalid request body: %v", err)
+		utils.ResponseWithError(c, http.StatusBadRequest, "Invalid request body", utils.BindingErrorDetails(err))
+		return
+	}
+	directive := strings.TrimSpace(req.StyleDirective)
+	if directive == "" {
+		utils.ResponseWithError(c, http.StatusBadRequest, "style_directive must not be empty", nil)
+		return
+	}
+
+	parent, claims, ok := loadOwnedProject(c, "CreateProjectVariant")
+	if !ok {
+		return
+	}
+	if strings.TrimSpace(parent.Prompt) == "" {
+		utils.ResponseWithError(c, http.StatusBadRequest, "Project prompt is empty. Please update the project with a valid prompt.", nil)
+		return
+	}
+
+	name, err := uniqueProjectName(c.Request.Context(), claims.UserID, parent.Name+" (variant)")
+	if err != nil {
+		log.Errorf("CreateProjectVariant: Failed to name variant of project %s: %v", parent.ID.String(), err)
+		utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to generate a project name", nil)
+		return
+	}
+	variant, err := queries.CreateManimProject(c.Request.Context(), &db.ManimProject{
+		UserID:          claims.UserID,
+		Name:            name,
+		Description:     parent.Description,
+		Prompt:          variantPrompt(parent.Prompt, directive),
+		RenderStatus:    "pending",
+		VideoURL:        sql.NullString{Valid: false},
+		ParentProjectID: sql.NullString{String: parent.ID.String(), Valid: true},
+		Metadata:        parent.Metadata,
+		Model:           parent.Model,
+		Resolution:      parent.Resolution,
+		FPS:             parent.FPS,
+	})
+	if err != nil {
+		log.Errorf("CreateProjectVariant: Failed to create variant of project %s: %v", parent.ID.String(), err)
+		respondForError(c, err)
+		return
+	}
+	log.Infof("Created variant %s of project %s with style directive %q.", variant.ID.String(), parent.ID.String(), directive)
+
+	if rerr := h.startRender(c.Request.Context(), variant, renderOptions{}); rerr != nil {
+		respondRenderError(c, rerr)
+		return
+	}
+
+	utils.ResponseWithSuccess(c, http.StatusAccepted, "Variant rendering process initiated", gin.H{
+		"project_id": parent.ID.String(),
+		"variant":    projectResponseOptionsFromQuery(c).render(variant),
+		"status":     "rendering_initiated",
+		"message":    "The variant is a new child project. Its video URL will be updated via callback.",
+	})
+}
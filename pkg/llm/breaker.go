@@ -0,0 +1,108 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrLLMUnavailable is returned without calling Gemini while the circuit breaker is open.
+var ErrLLMUnavailable = errors.New("gemini is unavailable: circuit breaker open")
+
+// Breaker states reported by Health.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open" // Cooldown elapsed; the next call decides whether to close
+)
+
+// breaker is a consecutive-failure circuit breaker around Gemini calls. After threshold failures in
+// a row it rejects calls for cooldown, then lets calls through again; one more failure reopens it
+// and a success closes it. A zero threshold disables it.
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	lastError string
+}
+
+// allow returns ErrLLMUnavailable while the breaker is open.
+func (b *breaker) allow() error {
+	if b.threshold <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures >= b.threshold && time.Since(b.openedAt) < b.cooldown {
+		return ErrLLMUnavailable
+	}
+	return nil
+}
+
+// record updates the breaker with the outcome of a Gemini call. Only outages (see isGeminiOutage)
+// count as failures; cancelled calls and rejections of the request itself, such as safety blocks
+// or other 4xx responses, say nothing about Gemini's health and are ignored.
+func (b *breaker) record(err error) {
+	if b.threshold <= 0 || errors.Is(err, context.Canceled) {
+		return
+	}
+	if err != nil && !isGeminiOutage(err) {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		if b.failures >= b.threshold {
+			log.Info("Gemini call succeeded; closing the LLM circuit breaker.")
+		}
+		b.failures = 0
+		b.lastError = ""
+		return
+	}
+	b.failures++
+	b.lastError = err.Error()
+	if b.failures >= b.threshold {
+		if b.failures == b.threshold {
+			log.Errorf("Gemini failed %d times in a row; opening the LLM circuit breaker for %s.", b.failures, b.cooldown)
+		}
+		b.openedAt = time.Now()
+	}
+}
+
+// Health describes the Gemini circuit breaker, for readiness checks and admins.
+type Health struct {
+	State               string     `json:"state"` // closed, open or half_open
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+	RetryAt             *time.Time `json:"retry_at,omitempty"` // When an open breaker lets calls through again
+	LastError           string     `json:"last_error,omitempty"`
+}
+
+// Health reports the state of the Gemini circuit breaker.
+func (s *Service) Health() Health {
+	b := &s.breaker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	health := Health{State: BreakerClosed, ConsecutiveFailures: b.failures, LastError: b.lastError}
+	if b.threshold > 0 && b.failures >= b.threshold {
+		openedAt := b.openedAt
+		retryAt := openedAt.Add(b.cooldown)
+		health.OpenedAt, health.RetryAt = &openedAt, &retryAt
+		health.State = BreakerOpen
+		if time.Now().After(retryAt) {
+			health.State = BreakerHalfOpen
+		}
+	}
+	return health
+}
+
+// Available reports whether Gemini calls are currently let through.
+func (s *Service) Available() bool {
+	return s.breaker.allow() == nil
+}
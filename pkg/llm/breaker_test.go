@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/googleapi"
+)
+
+func TestBreakerRecordCountsOnlyOutages(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantFailure bool
+	}{
+		{"server error", &googleapi.Error{Code: http.StatusServiceUnavailable}, true},
+		{"rate limited", &googleapi.Error{Code: http.StatusTooManyRequests}, true},
+		{"timeout", fmt.Errorf("call: %w", context.DeadlineExceeded), true},
+		{"unauthorized", &googleapi.Error{Code: http.StatusUnauthorized}, true},
+		{"forbidden", &googleapi.Error{Code: http.StatusForbidden}, true},
+		{"bad request", &googleapi.Error{Code: http.StatusBadRequest}, false},
+		{"safety block", &genai.BlockedError{}, false},
+		{"cancelled", context.Canceled, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &breaker{threshold: 3, failures: 1}
+			b.record(tt.err)
+			want := 1
+			if tt.wantFailure {
+				want = 2
+			}
+			if b.failures != want {
+				t.Errorf("failures = %d, want %d", b.failures, want)
+			}
+		})
+	}
+}
+
+func TestBreakerGuardsAssessAndDecompose(t *testing.T) {
+	outage := &googleapi.Error{Code: http.StatusInternalServerError}
+	calls := 0
+	s := &Service{
+		client:      &genai.GenerativeModel{},
+		ctx:         context.Background(),
+		breaker:     breaker{threshold: 2, cooldown: time.Minute},
+		assessments: make(map[string]*PromptAssessment),
+		generateContent: func(context.Context, *genai.GenerativeModel, string) (*genai.GenerateContentResponse, error) {
+			calls++
+			return nil, outage
+		},
+	}
+
+	if _, err := s.AssessPrompt(context.Background(), "Draw a circle"); !errors.Is(err, outage) {
+		t.Fatalf("AssessPrompt error = %v, want %v", err, outage)
+	}
+	if _, err := s.DecomposePrompt(context.Background(), "Draw a circle, then a square"); !errors.Is(err, outage) {
+		t.Fatalf("DecomposePrompt error = %v, want %v", err, outage)
+	}
+	if _, err := s.AssessPrompt(context.Background(), "Draw a square"); !errors.Is(err, ErrLLMUnavailable) {
+		t.Errorf("AssessPrompt with the breaker open: error = %v, want ErrLLMUnavailable", err)
+	}
+	if _, err := s.DecomposePrompt(context.Background(), "Draw a square, then a circle"); !errors.Is(err, ErrLLMUnavailable) {
+		t.Errorf("DecomposePrompt with the breaker open: error = %v, want ErrLLMUnavailable", err)
+	}
+	if calls != 2 {
+		t.Errorf("Gemini called %d times, want 2", calls)
+	}
+}
@@ -0,0 +1,82 @@
+// pkg/llm/decompose.go
+
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxDecomposedPrompts caps how many scenes a prompt may be split into.
+const maxDecomposedPrompts = 10
+
+const decomposePromptTemplate = `You are an expert Manim animation designer.
+Decompose the following complex Manim animation request into an ordered JSON array of simple, self-contained Manim animation descriptions.
+Each description should be a single string that can be used to generate a small, complete Manim animation segment.
+Ensure the entire response is a valid JSON array of strings, with no additional text or formatting outside the array.
+
+Example Request: "Animate a red square fading in, then a blue circle transforms into a green triangle, and finally, a text 'The End' appears."
+Example Response: ["Animate a red square fading in.", "A blue circle transforms into a green triangle.", "Display the text 'The End'."]
+
+Complex animation request to decompose: "%s"`
+
+// DecomposePrompt asks Gemini to break a complex prompt into an ordered list of simpler,
+// independent animation descriptions. Empty entries are dropped and at most
+// maxDecomposedPrompts are returned.
+func (s *Service) DecomposePrompt(ctx context.Context, complexPrompt string) ([]string, error) {
+	if err := s.breaker.allow(); err != nil {
+		log.WithContext(ctx).Warn("Skipping prompt decomposition: the LLM circuit breaker is open.")
+		return nil, err
+	}
+	log.WithContext(ctx).Debugf("Attempting to decompose complex prompt: %s", complexPrompt)
+
+	resp, err := s.callModel(ctx, s.client, fmt.Sprintf(decomposePromptTemplate, complexPrompt))
+	s.breaker.record(err)
+	if err != nil {
+		log.WithContext(ctx).Errorf("Error generating content for decomposition: %v", err)
+		return nil, fmt.Errorf("gemini API call failed during decomposition: %w", err)
+	}
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
+		return nil, fmt.Errorf("gemini API returned no content for decomposition")
+	}
+	text, ok := resp.Candidates[0].Content.Parts[0].(genai.Text)
+	if !ok {
+		return nil, fmt.Errorf("gemini API returned non-text content for decomposition")
+	}
+
+	// Gemini sometimes wraps the array in markdown fences
+	cleaned := strings.TrimSpace(string(text))
+	cleaned = strings.TrimPrefix(cleaned, "```json")
+	cleaned = strings.TrimPrefix(cleaned, "```")
+	cleaned = strings.TrimSuffix(cleaned, "```")
+
+	var parts []string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(cleaned)), &parts); err != nil {
+		log.WithContext(ctx).Errorf("Failed to unmarshal Gemini decomposition response: %v. Raw response: %s", err, string(text))
+		return nil, fmt.Errorf("failed to parse decomposition JSON from Gemini: %w", err)
+	}
+
+	prompts := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			prompts = append(prompts, part)
+		}
+	}
+	if len(prompts) > maxDecomposedPrompts {
+		prompts = prompts[:maxDecomposedPrompts]
+	}
+	log.WithContext(ctx).Infof("Successfully decomposed prompt into %d parts.", len(prompts))
+	return prompts, nil
+}
+
+// IsEmptyResponse reports whether err means Gemini produced no code at all, which usually
+// happens when a request is too involved to answer within the output limit.
+func IsEmptyResponse(err error) bool {
+	return errors.Is(err, errEmptyResponse)
+}
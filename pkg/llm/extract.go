@@ -0,0 +1,26 @@
+package llm
+
+import (
+	"regexp"
+	"strings"
+)
+
+// fencedBlockPattern matches the first markdown code fence and its body. The closing fence is
+// optional, since Gemini occasionally stops after opening one.
+var fencedBlockPattern = regexp.MustCompile("(?s)```[ \\t]*[A-Za-z0-9_+.-]*[ \\t]*\\r?\\n(.*?)(?:```|\\z)")
+
+// leadingLabelPattern matches a label line such as "Output:" or "Here is the code:" that Gemini
+// sometimes puts before the code.
+var leadingLabelPattern = regexp.MustCompile(`(?i)^\s*(output|code|python|python code|answer|result|solution|here(?:'s| is) (?:the |your )?(?:manim |python )?code)\s*:[ \t]*(\r?\n)?`)
+
+// extractCode pulls the Python code out of a raw Gemini response: the body of the first fenced
+// code block when there is one (ignoring any prose around it), otherwise the whole response
+// without a leading label line.
+func extractCode(response string) string {
+	if match := fencedBlockPattern.FindStringSubmatch(response); match != nil {
+		return strings.TrimSpace(match[1])
+	}
+	code := strings.TrimSpace(response)
+	code = leadingLabelPattern.ReplaceAllString(code, "")
+	return strings.TrimSpace(code)
+}
@@ -0,0 +1,38 @@
+package llm
+
+import "testing"
+
+func TestExtractCode(t *testing.T) {
+	const code = "from manim import *\n\nclass MyScene(Scene):\n    def construct(self):\n        self.play(Create(Circle()))"
+
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{"bare code", code, code},
+		{"bare code with surrounding whitespace", "\n\n  " + code + "\n\n", code},
+		{"python fence", "```python\n" + code + "\n```", code},
+		{"py fence with trailing spaces", "```py  \n" + code + "\n```", code},
+		{"fence without language", "```\n" + code + "\n```", code},
+		{"CRLF line endings", "```python\r\n" + code + "\r\n```\r\n", code},
+		{"prose around fence", "Sure! Here is your animation:\n\n```python\n" + code + "\n```\n\nThis draws a circle. Let me know if you need changes.", code},
+		{"unclosed fence", "```python\n" + code + "\n", code},
+		{"only first of several fences", "```python\n" + code + "\n```\n\nTo run it:\n```bash\nmanim -pql scene.py MyScene\n```", code},
+		{"output label", "Output:\n" + code, code},
+		{"here is the code label", "Here's the Manim code:\n" + code, code},
+		{"label on the same line", "Python code: " + code, code},
+		{"label in another case", "HERE IS YOUR PYTHON CODE:\n" + code, code},
+		{"label-like text inside code is kept", code + "\n# Output: a circle", code + "\n# Output: a circle"},
+		{"empty", "", ""},
+		{"only whitespace", " \n\t ", ""},
+		{"empty fence", "```python\n```", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractCode(tt.response); got != tt.want {
+				t.Errorf("extractCode(%q) =\n%q\nwant\n%q", tt.response, got, tt.want)
+			}
+		})
+	}
+}
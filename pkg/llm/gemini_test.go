@@ -0,0 +1,82 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+const testSceneCode = "from manim import *\n\nclass MyScene(Scene):\n    def construct(self):\n        self.play(Create(Circle()))"
+
+// fakeResponse builds a single-candidate Gemini response.
+func fakeResponse(finish genai.FinishReason, text string) *genai.GenerateContentResponse {
+	candidate := &genai.Candidate{FinishReason: finish}
+	if text != "" {
+		candidate.Content = &genai.Content{Parts: []genai.Part{genai.Text(text)}}
+	}
+	return &genai.GenerateContentResponse{
+		Candidates:    []*genai.Candidate{candidate},
+		UsageMetadata: &genai.UsageMetadata{PromptTokenCount: 100, CandidatesTokenCount: 50},
+	}
+}
+
+func TestGenerateManimCodeTruncatedOutput(t *testing.T) {
+	truncated := fakeResponse(genai.FinishReasonMaxTokens, "```python\nfrom manim import *\n\nclass MyScene(Scene):\n    def construct(se")
+	complete := fakeResponse(genai.FinishReasonStop, "```python\n"+testSceneCode+"\n```")
+
+	tests := []struct {
+		name            string
+		retryTokens     int32
+		responses       []*genai.GenerateContentResponse
+		wantCode        string
+		wantErr         error
+		wantCalls       int
+		wantRetryTokens int32 // MaxOutputTokens of the retry call
+	}{
+		{"complete output", 4096, []*genai.GenerateContentResponse{complete}, testSceneCode, nil, 1, 0},
+		{"truncated without retry", 0, []*genai.GenerateContentResponse{truncated}, "", ErrTruncatedOutput, 1, 0},
+		{"truncated then complete", 4096, []*genai.GenerateContentResponse{truncated, complete}, testSceneCode, nil, 2, 4096},
+		{"truncated twice", 4096, []*genai.GenerateContentResponse{truncated, truncated}, "", ErrTruncatedOutput, 2, 4096},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var models []*genai.GenerativeModel
+			s := &Service{
+				client: &genai.GenerativeModel{},
+				opts:   Options{TruncationRetryTokens: tt.retryTokens},
+				generateContent: func(_ context.Context, model *genai.GenerativeModel, _ string) (*genai.GenerateContentResponse, error) {
+					models = append(models, model)
+					if len(models) > len(tt.responses) {
+						t.Fatalf("unexpected Gemini call %d", len(models))
+					}
+					return tt.responses[len(models)-1], nil
+				},
+			}
+
+			code, usage, err := s.GenerateManimCodeWithUsage(context.Background(), "Draw a circle", "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if code != tt.wantCode {
+				t.Errorf("code = %q, want %q", code, tt.wantCode)
+			}
+			if len(models) != tt.wantCalls {
+				t.Fatalf("Gemini called %d times, want %d", len(models), tt.wantCalls)
+			}
+			if usage.InputTokens != int64(100*tt.wantCalls) || usage.OutputTokens != int64(50*tt.wantCalls) {
+				t.Errorf("usage = %+v, want it summed over %d calls", usage, tt.wantCalls)
+			}
+			if tt.wantRetryTokens > 0 {
+				retry := models[len(models)-1]
+				if retry.MaxOutputTokens == nil || *retry.MaxOutputTokens != tt.wantRetryTokens {
+					t.Errorf("retry MaxOutputTokens = %v, want %d", retry.MaxOutputTokens, tt.wantRetryTokens)
+				}
+				if s.client.MaxOutputTokens != nil {
+					t.Errorf("retry changed the default model's MaxOutputTokens to %d", *s.client.MaxOutputTokens)
+				}
+			}
+		})
+	}
+}
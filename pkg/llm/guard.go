@@ -0,0 +1,65 @@
+package llm
+
+import (
+	"errors"
+	"regexp"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrMissingSceneClass is returned when generated code no longer defines the required MyScene
+// class, which usually means the prompt talked Gemini out of the template's requirements.
+var ErrMissingSceneClass = errors.New("generated code does not define class MyScene")
+
+// injectionPatterns match common attempts to override the template's instructions from inside
+// the user request. Matches are replaced by removedPlaceholder when the guard is enabled.
+var injectionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override|bypass)\b[^.\n]{0,40}\b(previous|prior|above|earlier|all|any|system|these|the)\b[^.\n]{0,20}\b(instructions?|rules?|requirements?|prompts?|constraints?)\b`),
+	regexp.MustCompile(`(?i)\b(new|updated|real)\s+(system\s+)?instructions?\s*:`),
+	regexp.MustCompile(`(?i)\byou\s+are\s+(now|no\s+longer)\b`),
+	regexp.MustCompile(`(?i)\b(system\s+prompt|developer\s+mode|jailbreak)\b`),
+	regexp.MustCompile(`(?i)\bdo\s+not\s+name\s+the\s+class\b`),
+}
+
+const removedPlaceholder = "[removed]"
+
+// sceneClassPattern matches the class definition the renderer expects.
+var sceneClassPattern = regexp.MustCompile(`(?m)^class\s+MyScene\s*\(`)
+
+// userRequestTagPattern matches opening and closing user_request tags in any case and spacing.
+var userRequestTagPattern = regexp.MustCompile(`(?i)(<\s*/?\s*)user_request`)
+
+// escapeUserRequest keeps user text from closing the <user_request> block in the template.
+func escapeUserRequest(prompt string) string {
+	return userRequestTagPattern.ReplaceAllString(prompt, "${1}user-request")
+}
+
+// SanitizePrompt removes phrases that try to override the generation instructions. It reports
+// whether anything was removed.
+func SanitizePrompt(prompt string) (string, bool) {
+	sanitized := prompt
+	for _, pattern := range injectionPatterns {
+		sanitized = pattern.ReplaceAllString(sanitized, removedPlaceholder)
+	}
+	return sanitized, sanitized != prompt
+}
+
+// guardPrompt applies SanitizePrompt when the prompt guard is enabled, logging any removal.
+func (s *Service) guardPrompt(prompt string) string {
+	if !s.opts.PromptGuard {
+		return prompt
+	}
+	sanitized, changed := SanitizePrompt(prompt)
+	if changed {
+		log.Warnf("Prompt guard removed instruction-override phrases from a user prompt (%d -> %d bytes).", len(prompt), len(sanitized))
+	}
+	return sanitized
+}
+
+// checkSceneClass verifies generated code still defines MyScene when the prompt guard is enabled.
+func (s *Service) checkSceneClass(code string) error {
+	if s.opts.PromptGuard && !sceneClassPattern.MatchString(code) {
+		return ErrMissingSceneClass
+	}
+	return nil
+}
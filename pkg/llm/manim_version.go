@@ -0,0 +1,19 @@
+package llm
+
+import "fmt"
+
+// SetManimVersion records the Manim version the renderer runs. Once set, code-generation prompts
+// ask Gemini to stick to that version's API, so it doesn't use features the renderer lacks.
+// An empty version removes the hint.
+func (s *Service) SetManimVersion(version string) {
+	s.manimVersion.Store(version)
+}
+
+// manimVersionHint is appended to code-generation prompts when the renderer's version is known.
+func (s *Service) manimVersionHint() string {
+	version, _ := s.manimVersion.Load().(string)
+	if version == "" {
+		return ""
+	}
+	return fmt.Sprintf("\n\nThe code will be run with Manim Community v%s. Only use classes, functions and arguments available in that version.", version)
+}
@@ -0,0 +1,95 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/api/googleapi"
+)
+
+// generateContentWithRetry calls model.GenerateContent, retrying transient failures up to
+// Options.RetryAttempts calls in total. The delay doubles from Options.RetryBaseDelay after each
+// failure, plus up to half again of random jitter so that concurrent renders don't retry in step.
+func (s *Service) generateContentWithRetry(ctx context.Context, model *genai.GenerativeModel, prompt string) (*genai.GenerateContentResponse, error) {
+	attempts := max(s.opts.RetryAttempts, 1)
+	delay := s.opts.RetryBaseDelay
+	for attempt := 1; ; attempt++ {
+		resp, err := s.callModel(ctx, model, prompt)
+		if err == nil || attempt >= attempts || ctx.Err() != nil || !isRetryableGeminiError(err) {
+			return resp, err
+		}
+
+		wait := delay
+		if delay > 0 {
+			wait += time.Duration(rand.Int63n(int64(delay)/2 + 1))
+		}
+		log.WithContext(ctx).Warnf("Gemini call failed (attempt %d of %d): %v. Retrying in %s.", attempt, attempts, err, wait.Round(time.Millisecond))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+// callModel sends prompt to model, through Service.generateContent when it is set.
+func (s *Service) callModel(ctx context.Context, model *genai.GenerativeModel, prompt string) (*genai.GenerateContentResponse, error) {
+	if s.generateContent != nil {
+		return s.generateContent(ctx, model, prompt)
+	}
+	return model.GenerateContent(ctx, genai.Text(prompt))
+}
+
+// isRetryableGeminiError reports whether a failed Gemini call may succeed if repeated: rate
+// limiting, server errors and timeouts. Safety blocks and other rejections of the request itself
+// would fail the same way again.
+func isRetryableGeminiError(err error) bool {
+	var blocked *genai.BlockedError
+	if errors.As(err, &blocked) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	switch geminiHTTPCode(err) {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isGeminiOutage reports whether a failed Gemini call means Gemini can't currently serve us:
+// the retryable failures plus rejected credentials. Safety blocks and other rejections of the
+// request itself say nothing about Gemini's health.
+func isGeminiOutage(err error) bool {
+	if isRetryableGeminiError(err) {
+		return true
+	}
+	switch geminiHTTPCode(err) {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return true
+	}
+	return false
+}
+
+// geminiHTTPCode returns the HTTP status of a failed Gemini call, or 0 if err carries none.
+func geminiHTTPCode(err error) int {
+	var apiErr *googleapi.Error
+	var httpErr interface{ HTTPCode() int }
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr.Code
+	case errors.As(err, &httpErr):
+		return httpErr.HTTPCode()
+	}
+	return 0
+}
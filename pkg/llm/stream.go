@@ -0,0 +1,120 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/api/iterator"
+)
+
+// CodeStream is a Manim code generation in progress, started by GenerateManimCodeStream.
+type CodeStream struct {
+	Tokens <-chan string // Raw text as Gemini produces it; closed when the generation ends
+
+	done chan struct{}
+	code string
+	err  error
+}
+
+// Result waits for the generation to end and returns the cleaned code, or why it failed. Tokens
+// not yet received are discarded.
+func (cs *CodeStream) Result() (string, error) {
+	for range cs.Tokens {
+	}
+	<-cs.done
+	return cs.code, cs.err
+}
+
+// GenerateManimCodeStream is GenerateManimCode streaming Gemini's output as it's produced. The
+// cleaned code is available from the stream's Result once its Tokens channel is closed.
+// Streamed output can't be taken back, so unlike GenerateManimCode a failed call isn't retried.
+func (s *Service) GenerateManimCodeStream(ctx context.Context, prompt string) (*CodeStream, error) {
+	return s.GenerateManimCodeStreamWithModel(ctx, prompt, "")
+}
+
+// GenerateManimCodeStreamWithModel is GenerateManimCodeStream using the named Gemini model instead
+// of the service default. An empty modelName uses the default.
+func (s *Service) GenerateManimCodeStreamWithModel(ctx context.Context, prompt, modelName string) (*CodeStream, error) {
+	if err := s.breaker.allow(); err != nil {
+		log.WithContext(ctx).Warn("Skipping streamed Manim code generation: the LLM circuit breaker is open.")
+		return nil, err
+	}
+	model := s.client
+	if modelName != "" {
+		model = s.genaiClient.GenerativeModel(modelName)
+	}
+	log.WithContext(ctx).Debugf("Attempting to stream Manim code for prompt: %s", prompt)
+
+	tokens := make(chan string)
+	stream := &CodeStream{Tokens: tokens, done: make(chan struct{})}
+	go func() {
+		defer close(stream.done)
+		raw, err := s.streamCode(ctx, model, s.EffectivePrompt(prompt), tokens)
+		close(tokens)
+		if err != nil {
+			stream.err = err
+			return
+		}
+		code := extractCode(raw)
+		if err := s.checkSceneClass(code); err != nil {
+			log.WithContext(ctx).Warnf("Rejected streamed Manim code for prompt %q: %v", prompt, err)
+			stream.err = err
+			return
+		}
+		log.WithContext(ctx).Infof("Successfully streamed Manim code for prompt: %s", prompt)
+		stream.code = code
+	}()
+	return stream, nil
+}
+
+// streamCode sends a code-generation prompt to Gemini, forwarding the text of the first candidate
+// to tokens as it arrives, and returns the whole raw text.
+func (s *Service) streamCode(ctx context.Context, model *genai.GenerativeModel, prompt string, tokens chan<- string) (string, error) {
+	var raw strings.Builder
+	iter := model.GenerateContentStream(ctx, genai.Text(prompt))
+	for {
+		resp, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			s.breaker.record(err)
+			log.WithContext(ctx).Errorf("Error streaming content for Manim code: %v", err)
+			return "", fmt.Errorf("gemini API call failed during code generation: %w", err)
+		}
+		if len(resp.Candidates) == 0 {
+			continue
+		}
+		candidate := resp.Candidates[0]
+		if candidate.Content != nil {
+			for _, part := range candidate.Content.Parts {
+				text, ok := part.(genai.Text)
+				if !ok || text == "" {
+					continue
+				}
+				raw.WriteString(string(text))
+				select {
+				case tokens <- string(text):
+				case <-ctx.Done():
+					return "", ctx.Err()
+				}
+			}
+		}
+		if candidate.FinishReason == genai.FinishReasonMaxTokens {
+			s.breaker.record(nil)
+			log.WithContext(ctx).Warn("Gemini stopped at the max output token limit; discarding the incomplete streamed Manim code.")
+			return "", ErrTruncatedOutput
+		}
+	}
+	s.breaker.record(nil)
+
+	if strings.TrimSpace(raw.String()) == "" {
+		log.WithContext(ctx).Warn("Gemini streamed no content for Manim code generation.")
+		return "", errEmptyResponse
+	}
+	return raw.String(), nil
+}
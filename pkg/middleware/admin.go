@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/config"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// RequireAdmin restricts a route to users listed in ADMIN_USER_IDS.
+// It must run after AuthMiddleware so the user claims are available.
+func RequireAdmin(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, exists := GetUserClaimsFromContext(c)
+		if !exists {
+			log.Error("RequireAdmin: User claims not found in context. AuthMiddleware must run first.")
+			utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+			c.Abort()
+			return
+		}
+		if !cfg.IsAdmin(claims.UserID) {
+			log.Warnf("RequireAdmin: Non-admin user %s denied access to %s %s.", claims.UserID.String(), c.Request.Method, c.FullPath())
+			utils.ResponseWithError(c, http.StatusForbidden, "Admin access required", nil)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/config"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	adminID := uuid.New()
+	cfg := &config.Config{AdminUserIDs: []uuid.UUID{adminID}}
+
+	tests := []struct {
+		name       string
+		claims     *services.Claims
+		wantStatus int
+	}{
+		{"admin", &services.Claims{UserID: adminID, Email: "someone@example.com"}, http.StatusOK},
+		{"non-admin", &services.Claims{UserID: uuid.New(), Email: "admin@example.com"}, http.StatusForbidden},
+		{"no claims", nil, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/api/admin/users", func(c *gin.Context) {
+				if tt.claims != nil {
+					c.Set(UserClaimsContextKey, tt.claims)
+				}
+			}, RequireAdmin(cfg), func(c *gin.Context) { c.Status(http.StatusOK) })
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/admin/users", nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
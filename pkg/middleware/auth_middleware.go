@@ -1,25 +1,74 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/ASHISH26940/manim-orchestrator-api/pkg/services" // For JWT service
 	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"     // For HTTP responses
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	log "github.com/sirupsen/logrus"
 )
 
 // Gin context key for storing user claims.
 const UserClaimsContextKey = "userClaims"
 
+// Error codes returned when a token is rejected, so clients can react (e.g. prompt a re-login).
+const (
+	CodeTokenSignatureInvalid = "TOKEN_SIGNATURE_INVALID" // Signed with a key the server no longer accepts
+	CodeTokenExpired          = "TOKEN_EXPIRED"
+	CodeTokenMalformed        = "TOKEN_MALFORMED"
+	CodeTokenInvalid          = "TOKEN_INVALID"
+	CodeTokenRevoked          = "TOKEN_REVOKED" // Logged out
+	CodeAPIKeyInvalid         = "API_KEY_INVALID"
+)
+
+// tokenErrorResponse maps a token validation error to an error code and client-facing message.
+func tokenErrorResponse(err error) (string, string) {
+	switch {
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, services.ErrUnknownSigningKey):
+		// Typically the signing secret changed (rotation or redeploy); the token can never become valid again.
+		return CodeTokenSignatureInvalid, "Your session is no longer valid. Please log in again."
+	case errors.Is(err, services.ErrTokenRevoked):
+		return CodeTokenRevoked, "You have been logged out. Please log in again."
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return CodeTokenExpired, "Your session has expired. Please log in again."
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return CodeTokenMalformed, "Malformed token"
+	default:
+		return CodeTokenInvalid, "Invalid or expired token"
+	}
+}
+
 // AuthMiddleware is a Gin middleware to authenticate requests using JWT.
+// Requests may instead carry an API key in the X-API-Key header, which resolves to the key's owner.
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+			claims, err := services.ResolveAPIKey(c.Request.Context(), apiKey)
+			if err != nil {
+				if errors.Is(err, services.ErrInvalidAPIKey) {
+					log.Debug("AuthMiddleware: Invalid API key.")
+					utils.ResponseWithErrorCode(c, http.StatusUnauthorized, CodeAPIKeyInvalid, "Invalid or revoked API key", nil)
+				} else {
+					log.Errorf("AuthMiddleware: Failed to resolve API key: %v", err)
+					utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to authenticate request", nil)
+				}
+				c.Abort()
+				return
+			}
+			c.Set(UserClaimsContextKey, claims)
+			log.Debugf("AuthMiddleware: User %s (ID: %s) authenticated with an API key.", claims.Email, claims.UserID.String())
+			c.Next()
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			log.Debug("AuthMiddleware: Missing Authorization header.")
-			utils.ResponseWithError(c, http.StatusUnauthorized, "Authorization header required", nil)
+			utils.ResponseWithError(c, http.StatusUnauthorized, "Authorization header or X-API-Key required", nil)
 			c.Abort() // Stop processing this request
 			return
 		}
@@ -35,10 +84,17 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		tokenString := parts[1]
 
-		claims, err := services.ValidateToken(tokenString)
+		claims, err := services.ValidateToken(c.Request.Context(), tokenString)
+		if errors.Is(err, services.ErrRevocationCheck) {
+			log.Errorf("AuthMiddleware: Failed to validate JWT: %v", err)
+			utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to authenticate request", nil)
+			c.Abort()
+			return
+		}
 		if err != nil {
 			log.Debugf("AuthMiddleware: Invalid or expired JWT token: %v", err)
-			utils.ResponseWithError(c, http.StatusUnauthorized, "Invalid or expired token", err.Error())
+			code, message := tokenErrorResponse(err)
+			utils.ResponseWithErrorCode(c, http.StatusUnauthorized, code, message, err.Error())
 			c.Abort()
 			return
 		}
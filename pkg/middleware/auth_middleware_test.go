@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// withTestConfig gives config.LoadConfig the minimum settings it needs to load.
+func withTestConfig(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), nil, 0o600); err != nil {
+		t.Fatalf("write .env: %v", err)
+	}
+	t.Chdir(dir)
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("DATABASE_URL", "postgres://test")
+	t.Setenv("GEMINI_API_KEY", "test-key")
+	t.Setenv("RENDERER_ENABLED", "false")
+}
+
+func TestAuthMiddlewareRevocationCheckFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	withTestConfig(t)
+	withFakeDB(t, &fakeTxDriver{}) // Every query fails
+
+	token, err := services.GenerateToken(uuid.New(), "user@example.com", "user")
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	called := false
+	router := gin.New()
+	router.GET("/", AuthMiddleware(), func(c *gin.Context) { called = true })
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d; body: %s", w.Code, http.StatusInternalServerError, w.Body.String())
+	}
+	if called {
+		t.Error("handler ran although the token's revocation status is unknown")
+	}
+}
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+const redacted = "[REDACTED]"
+
+// redactedHeaders are never logged verbatim.
+var redactedHeaders = []string{"Authorization", "X-API-Key", "Cookie", "Set-Cookie"}
+
+// BodyLogging logs request and response bodies, truncated to maxBytes, for debugging client
+// integrations. Routes under /auth are skipped entirely; elsewhere JSON fields that look like
+// credentials (password, token, secret, key, authorization) and credential headers are redacted.
+func BodyLogging(maxBytes int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if strings.HasPrefix(c.Request.URL.Path, "/auth/") {
+			c.Next()
+			return
+		}
+
+		var requestBody []byte
+		if c.Request.Body != nil {
+			// Peek at most maxBytes+1 bytes and hand the handler the full, untouched stream
+			requestBody, _ = io.ReadAll(io.LimitReader(c.Request.Body, int64(maxBytes)+1))
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(requestBody), c.Request.Body), c.Request.Body}
+		}
+
+		writer := &bodyLogWriter{ResponseWriter: c.Writer, maxBytes: maxBytes}
+		c.Writer = writer
+		c.Next()
+
+		log.Infof("BodyLogging: %s %s headers=%v request=%s", c.Request.Method, c.Request.URL.Path,
+			redactHeaders(c.Request.Header), formatLoggedBody(requestBody, maxBytes))
+		log.Infof("BodyLogging: %s %s status=%d response=%s", c.Request.Method, c.Request.URL.Path,
+			writer.Status(), formatLoggedBody(writer.body.Bytes(), maxBytes))
+	}
+}
+
+// bodyLogWriter keeps a copy of the first maxBytes+1 bytes written to the response.
+type bodyLogWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	maxBytes int
+}
+
+func (w *bodyLogWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyLogWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *bodyLogWriter) capture(data []byte) {
+	if room := w.maxBytes + 1 - w.body.Len(); room > 0 {
+		if len(data) > room {
+			data = data[:room]
+		}
+		w.body.Write(data)
+	}
+}
+
+// formatLoggedBody redacts a captured body and truncates it to maxBytes. Bodies that were cut
+// short can't be parsed as JSON, so they are only logged when they hold no credential-like text.
+func formatLoggedBody(body []byte, maxBytes int) string {
+	if len(body) == 0 {
+		return "(empty)"
+	}
+	truncated := len(body) > maxBytes
+	if truncated {
+		body = body[:maxBytes]
+	}
+
+	var parsed interface{}
+	if !truncated && json.Unmarshal(body, &parsed) == nil {
+		out, _ := json.Marshal(redactJSON(parsed))
+		return string(out)
+	}
+	if containsSensitiveText(string(body)) {
+		return "(not logged: may contain credentials)"
+	}
+	if truncated {
+		return string(body) + "...(truncated)"
+	}
+	return string(body)
+}
+
+// redactJSON replaces the values of credential-like fields anywhere in a decoded JSON document.
+func redactJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if isSensitiveField(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redactJSON(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactJSON(item)
+		}
+	}
+	return value
+}
+
+func isSensitiveField(name string) bool {
+	name = strings.ToLower(name)
+	if name == "authorization" || name == "key" || name == "api_key" {
+		return true
+	}
+	return containsSensitiveText(name)
+}
+
+func containsSensitiveText(s string) bool {
+	s = strings.ToLower(s)
+	return strings.Contains(s, "password") || strings.Contains(s, "token") || strings.Contains(s, "secret")
+}
+
+func redactHeaders(header http.Header) http.Header {
+	clone := header.Clone()
+	for _, name := range redactedHeaders {
+		if clone.Get(name) != "" {
+			clone.Set(name, redacted)
+		}
+	}
+	return clone
+}
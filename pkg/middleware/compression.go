@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Compression compresses responses with gzip or deflate when the client accepts it, the
+// Content-Type is in contentTypes and the body is at least minBytes long. The first minBytes
+// of the body are buffered to make that decision; Server-Sent Events are never compressed.
+func Compression(minBytes int, contentTypes []string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(contentTypes))
+	for _, contentType := range contentTypes {
+		allowed[strings.ToLower(strings.TrimSpace(contentType))] = true
+	}
+
+	return func(c *gin.Context) {
+		encoding := negotiateEncoding(c.Request.Header.Get("Accept-Encoding"))
+		if encoding == "" || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		writer := &compressWriter{
+			ResponseWriter: c.Writer,
+			encoding:       encoding,
+			minBytes:       minBytes,
+			allowed:        allowed,
+		}
+		c.Writer = writer
+		defer writer.finish()
+		c.Next()
+	}
+}
+
+// negotiateEncoding picks gzip or deflate from an Accept-Encoding header, honoring q=0.
+func negotiateEncoding(header string) string {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if parsed, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > 0 {
+			accepted[name] = true
+		}
+	}
+	switch {
+	case accepted["gzip"] || accepted["*"]:
+		return "gzip"
+	case accepted["deflate"]:
+		return "deflate"
+	}
+	return ""
+}
+
+// compressWriter holds back the status and the start of the body until it knows whether the
+// response qualifies for compression.
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	minBytes int
+	allowed  map[string]bool
+
+	status     int
+	buf        bytes.Buffer
+	decided    bool
+	compressor io.WriteCloser
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if !w.decided {
+		w.status = code
+	}
+}
+
+func (w *compressWriter) Status() int {
+	if !w.decided && w.status != 0 {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *compressWriter) Written() bool {
+	return w.status != 0 || w.ResponseWriter.Written()
+}
+
+func (w *compressWriter) WriteHeaderNow() {
+	if !w.decided {
+		w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		if w.compressor != nil {
+			return w.compressor.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+	w.buf.Write(data)
+	if w.buf.Len() >= w.minBytes {
+		if err := w.decide(w.eligible()); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush commits to a decision with whatever has been buffered so far, so streamed responses
+// reach the client promptly.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(w.buf.Len() >= w.minBytes && w.eligible())
+	}
+	if flusher, ok := w.compressor.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// eligible reports whether the response headers allow compression.
+func (w *compressWriter) eligible() bool {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if status < 200 || status == http.StatusNoContent || status == http.StatusNotModified {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil || mediaType == "text/event-stream" {
+		return false
+	}
+	return w.allowed[mediaType]
+}
+
+// decide writes the held-back status and buffered body, switching to a compressor if asked.
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+	header := w.Header()
+	header.Add("Vary", "Accept-Encoding")
+	if compress {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+	}
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if compress {
+		if w.encoding == "gzip" {
+			w.compressor = gzip.NewWriter(w.ResponseWriter)
+		} else {
+			w.compressor, _ = flate.NewWriter(w.ResponseWriter, flate.DefaultCompression)
+		}
+	}
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if w.compressor != nil {
+		_, err = w.compressor.Write(w.buf.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
+
+// finish sends anything still buffered (bodies under minBytes go out uncompressed) and
+// terminates the compressed stream.
+func (w *compressWriter) finish() {
+	if !w.decided {
+		if w.status == 0 && w.buf.Len() == 0 {
+			return
+		}
+		w.decide(false)
+	}
+	if w.compressor != nil {
+		w.compressor.Close()
+	}
+}
@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// tokenBucket tracks the remaining tokens for a single user.
+type tokenBucket struct {
+	tokens     float64
+	lastRefill time.Time
+}
+
+// RateLimiter is an in-memory, per-user token-bucket rate limiter.
+// Each user gets a bucket holding up to `limit` tokens that refills evenly over `per`.
+// Buckets that have refilled to capacity are dropped periodically, as a missing bucket starts full.
+type RateLimiter struct {
+	mu            sync.Mutex
+	capacity      float64
+	refillRate    float64 // tokens per second
+	buckets       map[uuid.UUID]*tokenBucket
+	sweepInterval time.Duration
+	lastSweep     time.Time
+}
+
+// NewRateLimiter creates a limiter allowing `limit` requests per `per` for each user.
+func NewRateLimiter(limit int, per time.Duration) *RateLimiter {
+	if limit < 1 {
+		limit = 1
+	}
+	return &RateLimiter{
+		capacity:      float64(limit),
+		refillRate:    float64(limit) / per.Seconds(),
+		buckets:       make(map[uuid.UUID]*tokenBucket),
+		sweepInterval: per,
+		lastSweep:     time.Now(),
+	}
+}
+
+// RateLimitStatus describes a user's bucket after a call to Take.
+type RateLimitStatus struct {
+	Allowed    bool
+	Limit      int           // Bucket capacity
+	Remaining  int           // Whole tokens left after this request
+	RetryAfter time.Duration // Wait until the next token when not allowed
+	Reset      time.Duration // Time until the bucket is full again
+}
+
+// Allow consumes a token for the given user. When no token is available it returns
+// false along with how long the caller must wait before the next token is available.
+func (rl *RateLimiter) Allow(userID uuid.UUID) (bool, time.Duration) {
+	status := rl.Take(userID)
+	return status.Allowed, status.RetryAfter
+}
+
+// Take is Allow reporting the full bucket state, for rate-limit response headers.
+func (rl *RateLimiter) Take(userID uuid.UUID) RateLimitStatus {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(rl.lastSweep) >= rl.sweepInterval {
+		rl.sweep(now)
+	}
+	bucket, ok := rl.buckets[userID]
+	if !ok {
+		bucket = &tokenBucket{tokens: rl.capacity, lastRefill: now}
+		rl.buckets[userID] = bucket
+	}
+
+	elapsed := now.Sub(bucket.lastRefill).Seconds()
+	bucket.tokens = math.Min(rl.capacity, bucket.tokens+elapsed*rl.refillRate)
+	bucket.lastRefill = now
+
+	status := RateLimitStatus{Limit: int(rl.capacity)}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		status.Allowed = true
+	} else {
+		status.RetryAfter = time.Duration((1 - bucket.tokens) / rl.refillRate * float64(time.Second))
+	}
+	status.Remaining = int(bucket.tokens)
+	status.Reset = time.Duration((rl.capacity - bucket.tokens) / rl.refillRate * float64(time.Second))
+	return status
+}
+
+// sweep drops the buckets that have refilled to capacity since they were last used. rl.mu must be held.
+func (rl *RateLimiter) sweep(now time.Time) {
+	for userID, bucket := range rl.buckets {
+		if bucket.tokens+now.Sub(bucket.lastRefill).Seconds()*rl.refillRate >= rl.capacity {
+			delete(rl.buckets, userID)
+		}
+	}
+	rl.lastSweep = now
+}
+
+// RateLimit is a Gin middleware that applies the limiter to the authenticated user.
+// It must run after AuthMiddleware so the user claims are available.
+// Every response carries X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset
+// (seconds until the bucket is full again) so clients can throttle before hitting 429s.
+func RateLimit(rl *RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, exists := GetUserClaimsFromContext(c)
+		if !exists {
+			log.Error("RateLimit: User claims not found in context. AuthMiddleware must run first.")
+			utils.ResponseWithError(c, http.StatusInternalServerError, "Authentication error: User claims not found", nil)
+			c.Abort()
+			return
+		}
+
+		status := rl.Take(claims.UserID)
+		c.Header("X-RateLimit-Limit", strconv.Itoa(status.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(status.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(status.Reset.Seconds()))))
+		if !status.Allowed {
+			seconds := int(math.Ceil(status.RetryAfter.Seconds()))
+			log.Warnf("RateLimit: User %s exceeded rate limit for %s %s. Retry after %ds.", claims.UserID.String(), c.Request.Method, c.FullPath(), seconds)
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			utils.ResponseWithError(c, http.StatusTooManyRequests, "Rate limit exceeded. Please try again later.", gin.H{"retry_after_seconds": seconds})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRateLimiterSweepsFullBuckets(t *testing.T) {
+	rl := NewRateLimiter(2, time.Minute)
+	idle, active := uuid.New(), uuid.New()
+	rl.Take(idle)
+	rl.Take(active)
+	rl.Take(active)
+
+	// A minute later the idle user's bucket is full again; the active user's drained bucket
+	// is backdated less than a full refill
+	rl.buckets[idle].lastRefill = rl.buckets[idle].lastRefill.Add(-time.Minute)
+	rl.buckets[active].lastRefill = rl.buckets[active].lastRefill.Add(-40 * time.Second)
+	rl.lastSweep = rl.lastSweep.Add(-time.Minute)
+
+	rl.Take(uuid.New())
+	if _, ok := rl.buckets[idle]; ok {
+		t.Error("bucket refilled to capacity was not swept")
+	}
+	if _, ok := rl.buckets[active]; !ok {
+		t.Fatal("partially refilled bucket was swept")
+	}
+	if status := rl.Take(active); !status.Allowed || status.Remaining != 0 {
+		t.Errorf("Take after sweep = %+v, want the one refilled token", status)
+	}
+	if status := rl.Take(idle); !status.Allowed || status.Remaining != 1 {
+		t.Errorf("Take for a swept user = %+v, want a full bucket", status)
+	}
+}
+
+func TestRateLimiterSweepsOncePerInterval(t *testing.T) {
+	rl := NewRateLimiter(1, time.Hour)
+	userID := uuid.New()
+	rl.Take(userID)
+	rl.buckets[userID].lastRefill = rl.buckets[userID].lastRefill.Add(-time.Hour)
+
+	rl.Take(uuid.New())
+	if _, ok := rl.buckets[userID]; !ok {
+		t.Error("bucket swept before the sweep interval elapsed")
+	}
+}
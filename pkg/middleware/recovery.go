@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"runtime/debug"
+	"syscall"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// CodeInternalError is returned with 500 when a handler panics.
+const CodeInternalError = "INTERNAL_ERROR"
+
+// Recovery replaces gin's default recovery: a panicking handler is logged with its stack, the
+// request and the authenticated user, and the client gets the usual JSON error envelope rather
+// than an empty 500.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			fields := log.Fields{
+				"method":     c.Request.Method,
+				"path":       c.Request.URL.Path,
+				"request_id": GetRequestID(c),
+				"stack":      string(debug.Stack()),
+			}
+			if claims, ok := GetUserClaimsFromContext(c); ok {
+				fields["user_id"] = claims.UserID.String()
+			}
+
+			// A client that hung up mid-response can't be answered; don't report it as a crash.
+			if err, ok := recovered.(error); ok && (errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)) {
+				log.WithFields(fields).Warnf("Recovery: Client connection lost: %v", err)
+				c.Abort()
+				return
+			}
+
+			log.WithFields(fields).Errorf("Recovery: Panic while handling request: %v", recovered)
+			if c.Writer.Written() {
+				c.Abort() // Too late to change the response
+				return
+			}
+			utils.ResponseWithErrorCode(c, http.StatusInternalServerError, CodeInternalError, "An internal error occurred", nil)
+			c.Abort()
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/config"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// CodeRendererDisabled is returned with 501 by render endpoints when RENDERER_ENABLED=false.
+const CodeRendererDisabled = "renderer_disabled"
+
+// RequireRenderer answers 501 Not Implemented on routes that need the Manim renderer when the
+// service is deployed in generation-only mode (RENDERER_ENABLED=false).
+func RequireRenderer(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !cfg.RendererEnabled {
+			utils.ResponseWithErrorCode(c, http.StatusNotImplemented, CodeRendererDisabled,
+				"Rendering is disabled on this deployment. Use POST /api/projects/:id/generate-code to get the Manim code.", nil)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// RequestIDHeader carries the ID correlating a request's log lines across the API, Gemini calls
+// and the renderer.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds a client-supplied request ID, which ends up in every log line.
+const maxRequestIDLength = 128
+
+// requestIDKey is the gin context key the request ID is stored under.
+const requestIDKey = "requestID"
+
+type requestIDContextKey struct{}
+
+// RequestID assigns every request an ID: the caller's X-Request-ID when it's a sensible token,
+// otherwise a new UUID. The ID is stored in the gin context and the request's context, where
+// RequestIDHook adds it to log entries, and echoed back in the response header.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.New().String()
+		}
+		c.Set(requestIDKey, id)
+		c.Request = c.Request.WithContext(WithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// GetRequestID returns the ID assigned by RequestID, or "" if it didn't run.
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, or "" if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// PropagateRequestID sets the X-Request-ID header of an outbound request to the ID in ctx, so the
+// renderer can log under the same ID.
+func PropagateRequestID(ctx context.Context, req *http.Request) {
+	if id := RequestIDFromContext(ctx); id != "" {
+		req.Header.Set(RequestIDHeader, id)
+	}
+}
+
+// validRequestID accepts IDs of letters, digits, '-', '_' and '.', so a client can't inject
+// arbitrary text into the logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// RequestIDHook adds a request_id field to log entries made with log.WithContext(ctx) when ctx
+// carries a request ID.
+type RequestIDHook struct{}
+
+// Levels implements log.Hook for every level.
+func (RequestIDHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+// Fire implements log.Hook.
+func (RequestIDHook) Fire(entry *log.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	if id := RequestIDFromContext(entry.Context); id != "" {
+		entry.Data["request_id"] = id
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// RequireServiceToken restricts a route to sibling services presenting the shared service
+// credential as "Authorization: Bearer <token>". An empty token leaves the route open.
+func RequireServiceToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.Next()
+			return
+		}
+		presented := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			log.Warnf("RequireServiceToken: Rejected %s %s from %s without a valid service credential.", c.Request.Method, c.FullPath(), c.ClientIP())
+			utils.ResponseWithError(c, http.StatusUnauthorized, "Valid service credential required", nil)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
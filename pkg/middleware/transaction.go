@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// Transaction wraps a request in a database transaction. The transaction is stored
+// in the request context (see db.Conn), committed when the handler responds with a
+// 2xx status, and rolled back on any other status, a recorded gin error, or a panic.
+// The handler's response is held back until the transaction has been committed, so a
+// client never sees a success for a write that didn't persist: a failed commit is
+// answered with 500 instead. Apply it only to routes that write to the database and
+// don't stream their responses.
+func Transaction() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tx, err := db.DB.BeginTxx(c.Request.Context(), nil)
+		if err != nil {
+			log.Errorf("Transaction: Failed to begin transaction for %s %s: %v", c.Request.Method, c.FullPath(), err)
+			utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to start database transaction", nil)
+			c.Abort()
+			return
+		}
+		c.Request = c.Request.WithContext(db.WithTx(c.Request.Context(), tx))
+
+		writer := &txResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		defer func() {
+			if r := recover(); r != nil {
+				c.Writer = writer.ResponseWriter // Discard the held-back response
+				if rbErr := tx.Rollback(); rbErr != nil {
+					log.Errorf("Transaction: Rollback after panic failed: %v", rbErr)
+				}
+				log.Warnf("Transaction: Rolled back %s %s after panic.", c.Request.Method, c.FullPath())
+				panic(r) // Let gin's recovery middleware produce the response
+			}
+		}()
+
+		c.Next()
+
+		c.Writer = writer.ResponseWriter
+		status := writer.Status()
+		if status >= 200 && status < 300 && len(c.Errors) == 0 {
+			if err := tx.Commit(); err != nil {
+				log.Errorf("Transaction: Commit failed for %s %s: %v", c.Request.Method, c.FullPath(), err)
+				utils.ResponseWithError(c, http.StatusInternalServerError, "Failed to save changes", nil)
+				return
+			}
+			writer.release()
+			return
+		}
+
+		if err := tx.Rollback(); err != nil {
+			log.Errorf("Transaction: Rollback failed for %s %s: %v", c.Request.Method, c.FullPath(), err)
+		} else {
+			log.Debugf("Transaction: Rolled back %s %s (status %d).", c.Request.Method, c.FullPath(), status)
+		}
+		writer.release()
+	}
+}
+
+// txResponseWriter holds back a handler's status and body until its transaction has been
+// committed or rolled back. Headers are set on the underlying writer as usual.
+type txResponseWriter struct {
+	gin.ResponseWriter
+
+	status  int
+	written bool
+	buf     bytes.Buffer
+}
+
+func (w *txResponseWriter) WriteHeader(code int) {
+	if code > 0 {
+		w.status = code
+	}
+}
+
+func (w *txResponseWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *txResponseWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.buf.Write(data)
+}
+
+func (w *txResponseWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.buf.WriteString(s)
+}
+
+func (w *txResponseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *txResponseWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.buf.Len()
+}
+
+func (w *txResponseWriter) Written() bool {
+	return w.written
+}
+
+// Flush is a no-op: nothing may reach the client before the transaction ends.
+func (w *txResponseWriter) Flush() {}
+
+// release sends the held-back status and body.
+func (w *txResponseWriter) release() {
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if !w.written {
+		return
+	}
+	w.ResponseWriter.WriteHeaderNow()
+	if w.buf.Len() > 0 {
+		w.ResponseWriter.Write(w.buf.Bytes())
+	}
+}
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// fakeTxDriver is a database connector that only records how its transactions end.
+type fakeTxDriver struct {
+	commits, rollbacks int
+	commitErr          error
+}
+
+func (d *fakeTxDriver) Connect(context.Context) (driver.Conn, error) { return &fakeTxConn{d: d}, nil }
+func (d *fakeTxDriver) Driver() driver.Driver                        { return nil }
+
+type fakeTxConn struct{ d *fakeTxDriver }
+
+func (c *fakeTxConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *fakeTxConn) Close() error                        { return nil }
+func (c *fakeTxConn) Begin() (driver.Tx, error)           { return &fakeTx{d: c.d}, nil }
+
+type fakeTx struct{ d *fakeTxDriver }
+
+func (t *fakeTx) Commit() error {
+	t.d.commits++
+	return t.d.commitErr
+}
+
+func (t *fakeTx) Rollback() error {
+	t.d.rollbacks++
+	return nil
+}
+
+// withFakeDB points db.DB at a fakeTxDriver for the duration of the test.
+func withFakeDB(t *testing.T, d *fakeTxDriver) {
+	t.Helper()
+	previous := db.DB
+	db.DB = sqlx.NewDb(sql.OpenDB(d), "postgres")
+	t.Cleanup(func() {
+		db.DB.Close()
+		db.DB = previous
+	})
+}
+
+func TestTransaction(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	errCommit := errors.New("commit failed")
+
+	tests := []struct {
+		name          string
+		handler       gin.HandlerFunc
+		commitErr     error
+		wantStatus    int
+		wantBody      string
+		wantCommits   int
+		wantRollbacks int
+	}{
+		{
+			name:        "2xx commits",
+			handler:     func(c *gin.Context) { c.JSON(http.StatusCreated, gin.H{"id": 1}) },
+			wantStatus:  http.StatusCreated,
+			wantBody:    `{"id":1}`,
+			wantCommits: 1,
+		},
+		{
+			name:        "status without body commits",
+			handler:     func(c *gin.Context) { c.Status(http.StatusNoContent) },
+			wantStatus:  http.StatusNoContent,
+			wantCommits: 1,
+		},
+		{
+			name:          "handler error rolls back",
+			handler:       func(c *gin.Context) { c.JSON(http.StatusConflict, gin.H{"error": "duplicate"}) },
+			wantStatus:    http.StatusConflict,
+			wantBody:      `{"error":"duplicate"}`,
+			wantRollbacks: 1,
+		},
+		{
+			name: "recorded gin error rolls back",
+			handler: func(c *gin.Context) {
+				c.Error(errors.New("write failed"))
+				c.Status(http.StatusOK)
+			},
+			wantStatus:    http.StatusOK,
+			wantRollbacks: 1,
+		},
+		{
+			name:        "failed commit hides the success",
+			handler:     func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"saved": true}) },
+			commitErr:   errCommit,
+			wantStatus:  http.StatusInternalServerError,
+			wantBody:    "Failed to save changes",
+			wantCommits: 1,
+		},
+		{
+			name:          "panic rolls back",
+			handler:       func(c *gin.Context) { panic("boom") },
+			wantStatus:    http.StatusInternalServerError,
+			wantRollbacks: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &fakeTxDriver{commitErr: tt.commitErr}
+			withFakeDB(t, d)
+
+			router := gin.New()
+			router.Use(gin.CustomRecovery(func(c *gin.Context, _ any) {
+				c.AbortWithStatus(http.StatusInternalServerError)
+			}))
+			router.POST("/write", Transaction(), tt.handler)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/write", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", rec.Body.String(), tt.wantBody)
+			}
+			if tt.commitErr != nil && strings.Contains(rec.Body.String(), "saved") {
+				t.Errorf("body %q leaks the handler's response after a failed commit", rec.Body.String())
+			}
+			if d.commits != tt.wantCommits || d.rollbacks != tt.wantRollbacks {
+				t.Errorf("commits = %d, rollbacks = %d, want %d and %d", d.commits, d.rollbacks, tt.wantCommits, tt.wantRollbacks)
+			}
+		})
+	}
+}
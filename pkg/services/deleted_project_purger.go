@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/config"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/storage"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// deletedProjectPurgeInterval is how often soft-deleted projects past their retention are removed.
+const deletedProjectPurgeInterval = time.Hour
+
+// purgedObjectDeleteTimeout bounds the removal of one of a purged project's files from the bucket.
+const purgedObjectDeleteTimeout = 30 * time.Second
+
+// StartDeletedProjectPurger periodically removes projects that were soft-deleted more than
+// DELETED_PROJECT_RETENTION_DAYS ago, once they can no longer be restored, along with their video
+// and thumbnail in the bucket. store may be nil, in which case the files are left in place. It
+// stops when ctx is done.
+func StartDeletedProjectPurger(ctx context.Context, cfg *config.Config, store *storage.Client) {
+	retention := time.Duration(cfg.DeletedProjectRetentionDays) * 24 * time.Hour
+	go func() {
+		purgeDeletedProjects(ctx, cfg, store, retention)
+		ticker := time.NewTicker(deletedProjectPurgeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				purgeDeletedProjects(ctx, cfg, store, retention)
+			}
+		}
+	}()
+}
+
+func purgeDeletedProjects(ctx context.Context, cfg *config.Config, store *storage.Client, retention time.Duration) {
+	purged, err := queries.PurgeDeletedManimProjects(ctx, time.Now().UTC().Add(-retention))
+	if err != nil {
+		log.Errorf("DeletedProjectPurger: Failed to purge deleted projects: %v", err)
+		return
+	}
+	if len(purged) == 0 {
+		return
+	}
+	log.Infof("DeletedProjectPurger: Purged %d deleted projects.", len(purged))
+	for _, project := range purged {
+		if project.VideoURL.Valid {
+			deletePurgedObject(ctx, cfg, store, project.ID, project.VideoURL.String)
+		}
+		if project.ThumbnailURL.Valid {
+			deletePurgedObject(ctx, cfg, store, project.ID, project.ThumbnailURL.String)
+		}
+	}
+}
+
+// deletePurgedObject removes a file of a purged project from the bucket. Only objects in our
+// bucket whose key names the project are removed, so a URL a client or renderer supplied can't
+// be used to delete anything else.
+func deletePurgedObject(ctx context.Context, cfg *config.Config, store *storage.Client, projectID uuid.UUID, objectURL string) {
+	if objectURL == "" {
+		return
+	}
+	if store == nil {
+		log.Warnf("DeletedProjectPurger: File of purged project %s left at %s: R2 credentials are not configured.", projectID.String(), objectURL)
+		return
+	}
+	key, err := store.KeyFromURL(cfg.RewriteVideoURL(objectURL))
+	if err != nil || !strings.Contains(key, projectID.String()) {
+		log.Warnf("DeletedProjectPurger: File of purged project %s left at %s: not an object of this project.", projectID.String(), objectURL)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, purgedObjectDeleteTimeout)
+	defer cancel()
+	if err := store.Delete(ctx, key); err != nil {
+		log.Errorf("DeletedProjectPurger: Failed to delete %s of purged project %s: %v", key, projectID.String(), err)
+		return
+	}
+	log.Infof("DeletedProjectPurger: Deleted %s of purged project %s.", key, projectID.String())
+}
@@ -1,9 +1,13 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/ASHISH26940/manim-orchestrator-api/pkg/config" // To get JWT_SECRET
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid" // For user ID (if using UUIDs in claims)
 	log "github.com/sirupsen/logrus"
@@ -18,6 +22,16 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
+// ErrUnknownSigningKey is returned when a token's "kid" header names a key that is no longer accepted.
+var ErrUnknownSigningKey = errors.New("unknown JWT signing key ID")
+
+// ErrTokenRevoked is returned for a token whose "jti" was revoked by logging out.
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// ErrRevocationCheck is returned when a token's revocation status can't be looked up. It says
+// nothing about the token, so callers should answer with a server error rather than reject it.
+var ErrRevocationCheck = errors.New("failed to check token revocation")
+
 // GenerateToken generates a new JWT token for a given user.
 func GenerateToken(userID uuid.UUID, email, username string) (string, error) {
 	// Get JWT secret from configuration
@@ -38,11 +52,14 @@ func GenerateToken(userID uuid.UUID, email, username string) (string, error) {
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "manim-orchestrator-api",
 			Subject:   userID.String(), // Subject is typically the user ID
+			ID:        uuid.NewString(), // "jti", so the token can be revoked on logout
 		},
 	}
 
-	// Create the token with the claims and signing method
+	// Create the token with the claims and signing method.
+	// The "kid" header records which key signed it so validation keeps working after rotation.
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = cfg.JwtKeyID
 
 	// Sign the token with the secret key
 	tokenString, err := token.SignedString(jwtSecret)
@@ -56,10 +73,12 @@ func GenerateToken(userID uuid.UUID, email, username string) (string, error) {
 }
 
 // ValidateToken validates a JWT token and returns the claims if valid.
-// (This function will be used in the JWT authentication middleware later)
-func ValidateToken(tokenString string) (*Claims, error) {
+// The verification key is selected by the token's "kid" header; tokens issued before
+// key IDs were introduced carry no "kid" and are verified against the current secret.
+// Tokens whose "jti" has been revoked are rejected with ErrTokenRevoked; tokens issued before
+// jti was added carry none and can't be revoked. ErrRevocationCheck means the lookup failed.
+func ValidateToken(ctx context.Context, tokenString string) (*Claims, error) {
 	cfg := config.LoadConfig()
-	jwtSecret := []byte(cfg.JwtSecret)
 
 	claims := &Claims{}
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
@@ -67,7 +86,15 @@ func ValidateToken(tokenString string) (*Claims, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, jwt.ErrSignatureInvalid
 		}
-		return jwtSecret, nil
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return []byte(cfg.JwtSecret), nil
+		}
+		secret, ok := cfg.JwtVerificationKeys[kid]
+		if !ok {
+			return nil, ErrUnknownSigningKey
+		}
+		return []byte(secret), nil
 	})
 
 	if err != nil {
@@ -80,5 +107,24 @@ func ValidateToken(tokenString string) (*Claims, error) {
 		return nil, jwt.ErrInvalidKey
 	}
 
+	if claims.ID != "" {
+		revoked, err := queries.IsTokenRevoked(ctx, claims.ID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrRevocationCheck, err)
+		}
+		if revoked {
+			log.Debugf("Rejected revoked JWT %s for user %s.", claims.ID, claims.UserID.String())
+			return nil, ErrTokenRevoked
+		}
+	}
+
 	return claims, nil
+}
+
+// RevokeToken blocklists the token described by claims until it expires.
+func RevokeToken(ctx context.Context, claims *Claims) error {
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return fmt.Errorf("token has no jti or expiry and can't be revoked")
+	}
+	return queries.RevokeToken(ctx, claims.ID, claims.ExpiresAt.Time)
 }
\ No newline at end of file
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db"
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// refreshTokenTTL is how long a refresh token can be exchanged for a new access token.
+const refreshTokenTTL = 7 * 24 * time.Hour
+
+// ErrInvalidRefreshToken is returned when a refresh token is unknown, expired, already used or
+// its owner no longer exists.
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+// GenerateRefreshToken issues a new random refresh token for the user, storing only its hash.
+func GenerateRefreshToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	_, err := queries.CreateRefreshToken(ctx, &db.RefreshToken{
+		UserID:    userID,
+		TokenHash: hashRefreshToken(token),
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// RefreshSession exchanges a refresh token for a new access token and a replacement refresh token.
+// The presented token is revoked in the same transaction, so it can't be used again.
+func RefreshSession(ctx context.Context, refreshToken string) (string, string, error) {
+	tx, err := db.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // No-op once committed
+	txCtx := db.WithTx(ctx, tx)
+
+	stored, err := queries.ConsumeRefreshToken(txCtx, hashRefreshToken(refreshToken))
+	if err != nil {
+		return "", "", err
+	}
+	if stored == nil {
+		return "", "", ErrInvalidRefreshToken
+	}
+	user, err := queries.FindUserByID(txCtx, stored.UserID)
+	if err != nil {
+		return "", "", err
+	}
+	if user == nil {
+		return "", "", ErrInvalidRefreshToken
+	}
+
+	newRefreshToken, err := GenerateRefreshToken(txCtx, user.ID)
+	if err != nil {
+		return "", "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", "", fmt.Errorf("failed to commit refresh token rotation: %w", err)
+	}
+
+	accessToken, err := GenerateToken(user.ID, user.Email, user.Username)
+	if err != nil {
+		return "", "", err
+	}
+	log.Debugf("Rotated refresh token for user %s.", user.ID.String())
+	return accessToken, newRefreshToken, nil
+}
+
+// RevokeRefreshToken invalidates a refresh token, e.g. on logout. Unknown, expired or already used
+// tokens are ignored.
+func RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	_, err := queries.ConsumeRefreshToken(ctx, hashRefreshToken(refreshToken))
+	return err
+}
+
+// hashRefreshToken returns the hex SHA-256 of a token. Tokens carry 256 bits of randomness, so a
+// fast hash is sufficient.
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	log "github.com/sirupsen/logrus"
+)
+
+// reconcileInterval is how often the reconciler looks for stuck renders.
+const reconcileInterval = time.Minute
+
+// StartRenderReconciler periodically fails renders that have been in flight (pending, generating or
+// rendering) for longer than timeout, so a lost renderer callback doesn't leave a project stuck
+// forever. Projects pinned via POST /api/projects/:id/pin-render (auto_reconcile = false) are
+// skipped. It stops when ctx is done.
+func StartRenderReconciler(ctx context.Context, timeout time.Duration) {
+	go func() {
+		ticker := time.NewTicker(reconcileInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				reconcileStuckRenders(ctx, timeout)
+			}
+		}
+	}()
+}
+
+func reconcileStuckRenders(ctx context.Context, timeout time.Duration) {
+	ids, err := queries.FailStuckManimProjects(ctx, time.Now().UTC().Add(-timeout))
+	if err != nil {
+		log.Errorf("RenderReconciler: Failed to reconcile stuck renders: %v", err)
+		return
+	}
+	for _, id := range ids {
+		log.Warnf("RenderReconciler: Project %s exceeded the render timeout of %s; marked as failed.", id.String(), timeout)
+		if err := queries.FinishLatestRenderJob(ctx, id, queries.RenderJobFailed, "render_timeout"); err != nil {
+			log.Errorf("RenderReconciler: Failed to finish render job for project %s: %v", id.String(), err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RendererHealthStatus is the outcome of the most recent renderer health probe.
+type RendererHealthStatus struct {
+	Reachable bool      `json:"reachable"`
+	Error     string    `json:"error,omitempty"` // Why the probe failed
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// RendererHealth probes the renderer's GET /health endpoint and remembers the result. It's safe
+// for concurrent use.
+type RendererHealth struct {
+	rendererURL string
+	timeout     time.Duration
+
+	mu   sync.RWMutex
+	last *RendererHealthStatus
+}
+
+// NewRendererHealth returns a prober for the renderer at rendererURL whose probes give up after timeout.
+func NewRendererHealth(rendererURL string, timeout time.Duration) *RendererHealth {
+	return &RendererHealth{rendererURL: rendererURL, timeout: timeout}
+}
+
+// Check probes the renderer now, records the result and returns why it's unreachable, if it is.
+func (r *RendererHealth) Check(ctx context.Context) error {
+	err := r.probe(ctx)
+	status := &RendererHealthStatus{Reachable: err == nil, CheckedAt: time.Now().UTC()}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	r.mu.Lock()
+	r.last = status
+	r.mu.Unlock()
+	return err
+}
+
+// Last returns the result of the most recent probe, or false if the renderer hasn't been probed.
+func (r *RendererHealth) Last() (RendererHealthStatus, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.last == nil {
+		return RendererHealthStatus{}, false
+	}
+	return *r.last, true
+}
+
+func (r *RendererHealth) probe(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.rendererURL+"/health", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("renderer returned status %d", resp.StatusCode)
+	}
+	return nil
+}
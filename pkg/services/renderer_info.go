@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// rendererInfoTimeout bounds a single request to the renderer's version endpoint.
+const rendererInfoTimeout = 5 * time.Second
+
+// RendererInfo describes the renderer, as reported by its GET /version endpoint.
+type RendererInfo struct {
+	ManimVersion  string    `json:"manim_version"`
+	PythonVersion string    `json:"python_version,omitempty"`
+	FetchedAt     time.Time `json:"fetched_at"`
+}
+
+// RendererInfoCache keeps the most recently fetched RendererInfo. It's safe for concurrent use.
+type RendererInfoCache struct {
+	rendererURL string
+	onUpdate    func(RendererInfo) // Called after every successful fetch; may be nil
+
+	mu   sync.RWMutex
+	info *RendererInfo
+}
+
+// NewRendererInfoCache returns an empty cache for the renderer at rendererURL. onUpdate, if not
+// nil, is called with each successfully fetched RendererInfo.
+func NewRendererInfoCache(rendererURL string, onUpdate func(RendererInfo)) *RendererInfoCache {
+	return &RendererInfoCache{rendererURL: rendererURL, onUpdate: onUpdate}
+}
+
+// Get returns the cached info, or false if it hasn't been fetched successfully yet.
+func (r *RendererInfoCache) Get() (RendererInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.info == nil {
+		return RendererInfo{}, false
+	}
+	return *r.info, true
+}
+
+// Start fetches the renderer's info now and then every interval, keeping the last good value
+// when a refresh fails. It stops when ctx is done.
+func (r *RendererInfoCache) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		r.refresh(ctx)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.refresh(ctx)
+			}
+		}
+	}()
+}
+
+func (r *RendererInfoCache) refresh(ctx context.Context) {
+	info, err := r.fetch(ctx)
+	if err != nil {
+		log.Warnf("RendererInfo: Failed to fetch renderer version: %v", err)
+		return
+	}
+	r.mu.Lock()
+	previous := r.info
+	r.info = info
+	r.mu.Unlock()
+
+	if previous == nil || previous.ManimVersion != info.ManimVersion {
+		log.Infof("RendererInfo: Renderer runs Manim %s.", info.ManimVersion)
+	}
+	if r.onUpdate != nil {
+		r.onUpdate(*info)
+	}
+}
+
+func (r *RendererInfoCache) fetch(ctx context.Context) (*RendererInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, rendererInfoTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.rendererURL+"/version", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("renderer returned status %d", resp.StatusCode)
+	}
+
+	info := &RendererInfo{}
+	if err := json.NewDecoder(resp.Body).Decode(info); err != nil {
+		return nil, fmt.Errorf("invalid version response: %w", err)
+	}
+	if info.ManimVersion == "" {
+		return nil, fmt.Errorf("version response has no manim_version")
+	}
+	info.FetchedAt = time.Now().UTC()
+	return info, nil
+}
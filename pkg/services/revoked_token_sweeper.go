@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/ASHISH26940/manim-orchestrator-api/pkg/db/queries"
+	log "github.com/sirupsen/logrus"
+)
+
+// revokedTokenSweepInterval is how often expired entries are removed from the token blocklist.
+const revokedTokenSweepInterval = time.Hour
+
+// StartRevokedTokenSweeper periodically deletes blocklist entries of revoked tokens that have
+// since expired, so the table doesn't grow without bound. It stops when ctx is done.
+func StartRevokedTokenSweeper(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(revokedTokenSweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				sweepRevokedTokens(ctx)
+			}
+		}
+	}()
+}
+
+func sweepRevokedTokens(ctx context.Context) {
+	deleted, err := queries.DeleteExpiredRevokedTokens(ctx)
+	if err != nil {
+		log.Errorf("RevokedTokenSweeper: Failed to delete expired revoked tokens: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Infof("RevokedTokenSweeper: Deleted %d expired revoked tokens.", deleted)
+	}
+}
@@ -0,0 +1,203 @@
+// pkg/storage/r2.go
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// R2 ignores the region but SigV4 requires one; "auto" is what Cloudflare documents.
+	defaultRegion = "auto"
+	// maxPresignTTL is the longest expiry SigV4 allows for presigned URLs.
+	maxPresignTTL = 7 * 24 * time.Hour
+)
+
+// Client talks to an S3-compatible object store (Cloudflare R2) through the AWS S3 SDK, using
+// path-style requests.
+type Client struct {
+	s3            *s3.Client
+	presign       *s3.PresignClient
+	bucket        string
+	publicBaseURL string
+}
+
+// Config holds the settings needed to reach a bucket.
+type Config struct {
+	Endpoint        string // e.g. https://<account>.r2.cloudflarestorage.com
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	PublicBaseURL   string // Public URL prefix for objects in the bucket, used to build video URLs
+}
+
+// NewClient creates a new storage client for the given bucket.
+func NewClient(cfg Config) (*Client, error) {
+	endpoint, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid storage endpoint %q", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" || cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("storage bucket and credentials are required")
+	}
+	client := s3.New(s3.Options{
+		BaseEndpoint: aws.String(endpoint.String()),
+		Region:       defaultRegion,
+		Credentials:  credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
+		UsePathStyle: true,
+		HTTPClient:   &http.Client{Timeout: 5 * time.Minute},
+		// R2 doesn't support every checksum the SDK sends by default
+		RequestChecksumCalculation: aws.RequestChecksumCalculationWhenRequired,
+		ResponseChecksumValidation: aws.ResponseChecksumValidationWhenRequired,
+	})
+	return &Client{
+		s3:            client,
+		presign:       s3.NewPresignClient(client),
+		bucket:        cfg.Bucket,
+		publicBaseURL: strings.TrimRight(cfg.PublicBaseURL, "/"),
+	}, nil
+}
+
+// PublicURL returns the public URL of an object, or an empty string when no public base URL is configured.
+func (c *Client) PublicURL(key string) string {
+	if c.publicBaseURL == "" {
+		return ""
+	}
+	return c.publicBaseURL + "/" + encodePath(key)
+}
+
+// Put uploads body under key.
+func (c *Client) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(c.bucket),
+		Key:           aws.String(objectKey(key)),
+		Body:          bytes.NewReader(body),
+		ContentLength: aws.Int64(int64(len(body))),
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	if _, err := c.s3.PutObject(ctx, input); err != nil {
+		return fmt.Errorf("failed to upload object %q: %w", key, err)
+	}
+	return nil
+}
+
+// ErrObjectNotFound is returned by Head when the object doesn't exist.
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectInfo is the metadata returned by Head.
+type ObjectInfo struct {
+	Size        int64
+	ContentType string
+}
+
+// Head returns an object's size and content type without downloading it.
+func (c *Client) Head(ctx context.Context, key string) (*ObjectInfo, error) {
+	out, err := c.s3.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(objectKey(key)),
+	})
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to head object %q: %w", key, err)
+	}
+	return &ObjectInfo{Size: aws.ToInt64(out.ContentLength), ContentType: aws.ToString(out.ContentType)}, nil
+}
+
+// Delete removes the object under key. Deleting a missing object succeeds.
+func (c *Client) Delete(ctx context.Context, key string) error {
+	_, err := c.s3.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(objectKey(key)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete object %q: %w", key, err)
+	}
+	return nil
+}
+
+// PresignGet returns a URL that allows anyone holding it to download the object until ttl elapses.
+// Works for private buckets, as the request is authorized by the signature in the query string.
+func (c *Client) PresignGet(key string, ttl time.Duration) (string, error) {
+	if ttl < time.Second || ttl > maxPresignTTL {
+		return "", fmt.Errorf("presign TTL must be between 1s and %s", maxPresignTTL)
+	}
+	req, err := c.presign.PresignGetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(objectKey(key)),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign object %q: %w", key, err)
+	}
+	return req.URL, nil
+}
+
+// KeyFromURL extracts the object key from a stored video URL. URLs under the configured public
+// base URL have that prefix stripped; any other URL is assumed to be served from the bucket root.
+func (c *Client) KeyFromURL(rawURL string) (string, error) {
+	if c.publicBaseURL != "" && strings.HasPrefix(rawURL, c.publicBaseURL+"/") {
+		rest := strings.TrimPrefix(rawURL, c.publicBaseURL+"/")
+		if i := strings.IndexAny(rest, "?#"); i >= 0 {
+			rest = rest[:i]
+		}
+		key, err := url.PathUnescape(rest)
+		if err != nil || key == "" {
+			return "", fmt.Errorf("object URL %q has no valid key", rawURL)
+		}
+		return key, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid object URL %q: %w", rawURL, err)
+	}
+	key := strings.TrimLeft(u.Path, "/")
+	if key == "" {
+		return "", fmt.Errorf("object URL %q has no key", rawURL)
+	}
+	return key, nil
+}
+
+// objectKey returns key without leading slashes, as stored in the bucket.
+func objectKey(key string) string {
+	return strings.TrimLeft(key, "/")
+}
+
+// encodePath percent-encodes each segment of an object key, leaving the slashes between them.
+func encodePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = encodeComponent(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// encodeComponent percent-encodes everything except RFC 3986 unreserved characters.
+func encodeComponent(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if (ch >= 'A' && ch <= 'Z') || (ch >= 'a' && ch <= 'z') || (ch >= '0' && ch <= '9') ||
+			ch == '-' || ch == '_' || ch == '.' || ch == '~' {
+			b.WriteByte(ch)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", ch)
+	}
+	return b.String()
+}
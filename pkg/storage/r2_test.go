@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, endpoint string) *Client {
+	t.Helper()
+	client, err := NewClient(Config{
+		Endpoint:        endpoint,
+		Bucket:          "videos",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		PublicBaseURL:   "https://cdn.example.com/media/",
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client
+}
+
+func TestKeyFromURLRoundTrip(t *testing.T) {
+	client := newTestClient(t, "https://account.r2.cloudflarestorage.com")
+
+	for _, key := range []string{
+		"renders/abc.mp4",
+		"assets/user 1/logo (final).png",
+		"assets/ü/100%+ß?.svg",
+	} {
+		t.Run(key, func(t *testing.T) {
+			got, err := client.KeyFromURL(client.PublicURL(key))
+			if err != nil || got != key {
+				t.Errorf("KeyFromURL(PublicURL(%q)) = %q, %v", key, got, err)
+			}
+			got, err = client.KeyFromURL(client.PublicURL(key) + "?v=2")
+			if err != nil || got != key {
+				t.Errorf("KeyFromURL with query = %q, %v; want %q", got, err, key)
+			}
+		})
+	}
+
+	if _, err := client.KeyFromURL("https://cdn.example.com/media/"); err == nil {
+		t.Error("KeyFromURL accepted a URL without a key")
+	}
+}
+
+func TestPresignGet(t *testing.T) {
+	client := newTestClient(t, "https://account.r2.cloudflarestorage.com")
+
+	raw, err := client.PresignGet("renders/my video.mp4", 15*time.Minute)
+	if err != nil {
+		t.Fatalf("PresignGet: %v", err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("PresignGet returned an invalid URL %q: %v", raw, err)
+	}
+	if u.Host != "account.r2.cloudflarestorage.com" || u.EscapedPath() != "/videos/renders/my%20video.mp4" {
+		t.Errorf("presigned URL = %s, want a path-style URL for videos/renders/my video.mp4", raw)
+	}
+	query := u.Query()
+	if query.Get("X-Amz-Expires") != "900" {
+		t.Errorf("X-Amz-Expires = %q, want 900", query.Get("X-Amz-Expires"))
+	}
+	if !strings.HasPrefix(query.Get("X-Amz-Credential"), "AKIDEXAMPLE/") || !strings.Contains(query.Get("X-Amz-Credential"), "/auto/s3/") {
+		t.Errorf("X-Amz-Credential = %q, want the access key scoped to region auto", query.Get("X-Amz-Credential"))
+	}
+	if query.Get("X-Amz-Signature") == "" {
+		t.Error("presigned URL has no signature")
+	}
+
+	for _, ttl := range []time.Duration{0, maxPresignTTL + time.Second} {
+		if _, err := client.PresignGet("renders/abc.mp4", ttl); err == nil {
+			t.Errorf("PresignGet accepted TTL %s", ttl)
+		}
+	}
+}
+
+func TestHead(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead || r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/videos/renders/abc.mp4":
+			w.Header().Set("Content-Type", "video/mp4")
+			w.Header().Set("Content-Length", "1234")
+			w.WriteHeader(http.StatusOK)
+		case "/videos/renders/forbidden.mp4":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client := newTestClient(t, server.URL)
+
+	info, err := client.Head(context.Background(), "renders/abc.mp4")
+	if err != nil {
+		t.Fatalf("Head: %v", err)
+	}
+	if info.Size != 1234 || info.ContentType != "video/mp4" {
+		t.Errorf("Head = %+v, want size 1234 and type video/mp4", info)
+	}
+	if _, err := client.Head(context.Background(), "renders/missing.mp4"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("Head of a missing object: error = %v, want ErrObjectNotFound", err)
+	}
+	if _, err := client.Head(context.Background(), "renders/forbidden.mp4"); err == nil || errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("Head of a forbidden object: error = %v, want a non-not-found error", err)
+	}
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"fmt"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -9,6 +11,7 @@ type JSONResponse struct{
 	Message string		`json:"message"`
 	Data interface{}	`json:"data,omitempty"`
 	Error interface{}	`json:"error,omitempty"`
+	Code string		`json:"code,omitempty"` // Machine-readable outcome, for successes and errors clients handle specially
 }
 
 func ResponseWithSuccess(
@@ -17,10 +20,31 @@ func ResponseWithSuccess(
 	message string,
 	data interface{},
 ){
+	ResponseWithSuccessCode(c, statusCode, "", message, data)
+}
+
+// ResponseWithSuccessCode is ResponseWithSuccess with a machine-readable code clients can branch
+// on instead of the message. An empty code is omitted.
+func ResponseWithSuccessCode(
+	c *gin.Context,
+	statusCode int,
+	code string,
+	message string,
+	data interface{},
+){
+	if wantsPlainText(c) {
+		line := message
+		if code != "" {
+			line = "[" + code + "] " + line
+		}
+		c.String(statusCode, "%s\n", line)
+		return
+	}
 	c.JSON(statusCode, JSONResponse{
 		Success: true,
 		Message: message,
 		Data: data,
+		Code: code,
 	})
 }
 
@@ -30,9 +54,59 @@ func ResponseWithError(
 	message string,
 	errorDetails interface{},
 ){
+	if wantsPlainText(c) {
+		c.String(statusCode, "%s\n", plainTextError("", message, errorDetails))
+		return
+	}
 	c.JSON(statusCode, JSONResponse{
 		Success: false,
 		Message: message,
 		Error: errorDetails,
 	})
-}
\ No newline at end of file
+}
+
+// ResponseWithErrorCode is ResponseWithError with a machine-readable code clients can branch on.
+func ResponseWithErrorCode(
+	c *gin.Context,
+	statusCode int,
+	code string,
+	message string,
+	errorDetails interface{},
+){
+	if wantsPlainText(c) {
+		c.String(statusCode, "%s\n", plainTextError(code, message, errorDetails))
+		return
+	}
+	c.JSON(statusCode, JSONResponse{
+		Success: false,
+		Message: message,
+		Error: errorDetails,
+		Code: code,
+	})
+}
+
+// wantsPlainText reports whether the client asked for text/plain over JSON in its Accept header
+// (e.g. curl -H 'Accept: text/plain'). JSON stays the default, including for */*.
+func wantsPlainText(c *gin.Context) bool {
+	return c.NegotiateFormat(gin.MIMEJSON, gin.MIMEPlain) == gin.MIMEPlain
+}
+
+// plainTextError renders an error as one concise line: "[code] message: details".
+// Details are included only when they're a plain string or error.
+func plainTextError(code, message string, details interface{}) string {
+	line := message
+	if code != "" {
+		line = "[" + code + "] " + line
+	}
+	switch d := details.(type) {
+	case string:
+		if d != "" {
+			line += ": " + d
+		}
+	case error:
+		line += ": " + d.Error()
+	case fmt.Stringer:
+		line += ": " + d.String()
+	}
+	return line
+}
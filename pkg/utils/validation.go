@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// UseJSONFieldNames makes gin's validator report fields by their JSON names ("name") rather than
+// their Go names ("Name"), so BindingErrorDetails matches what clients send. Call once at startup.
+func UseJSONFieldNames() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+}
+
+// BindingErrorDetails turns a ShouldBind error into error details for the client. Validation
+// failures become a map of field to message, e.g. {"name": "must be at least 3 characters"}, and
+// a value of the wrong JSON type is reported against its field. Other errors, such as malformed
+// JSON, are returned as their message.
+func BindingErrorDetails(err error) interface{} {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		details := make(map[string]string, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			field := fieldErr.Field()
+			// Namespace is "Struct.outer.inner"; drop the struct name but keep nested paths
+			if _, path, ok := strings.Cut(fieldErr.Namespace(), "."); ok {
+				field = path
+			}
+			if _, seen := details[field]; !seen {
+				details[field] = validationMessage(fieldErr)
+			}
+		}
+		return details
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return map[string]string{typeErr.Field: "must be a " + jsonTypeName(typeErr.Type)}
+	}
+	return err.Error()
+}
+
+// validationMessage describes a failed validation tag in plain words.
+func validationMessage(fieldErr validator.FieldError) string {
+	param := fieldErr.Param()
+	sized := ""
+	switch fieldErr.Kind() {
+	case reflect.String:
+		sized = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		sized = " items"
+	}
+
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		return "must be at least " + param + sized
+	case "max", "lte":
+		return "must be at most " + param + sized
+	case "len":
+		return "must be exactly " + param + sized
+	case "gt":
+		return "must be greater than " + param
+	case "lt":
+		return "must be less than " + param
+	case "email":
+		return "must be a valid email address"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(param), ", ")
+	case "alphanum":
+		return "must contain only letters and digits"
+	case "dive":
+		return "contains an invalid item"
+	}
+	if param != "" {
+		return fmt.Sprintf("failed the %s=%s check", fieldErr.Tag(), param)
+	}
+	return fmt.Sprintf("failed the %s check", fieldErr.Tag())
+}
+
+// jsonTypeName names a Go type the way a JSON client thinks of it.
+func jsonTypeName(t reflect.Type) string {
+	if t == nil {
+		return "valid value"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "whole number"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+	return "valid value"
+}